@@ -0,0 +1,21 @@
+package sqlinjector
+
+import (
+	"github.com/prorochestvo/sqlinjector/internal/sandbox"
+	"reflect"
+)
+
+// Comparator describes how DummyRepository recognizes, filters and sorts values of one Go type.
+type Comparator = sandbox.Comparator
+
+// RegisterComparator registers the comparator for values of type T used by DummyRepository in where and order by expressions.
+// Built-in comparators are registered for types.Decimal, types.NullDecimal, null.Float32 and null.Float64.
+func RegisterComparator[T any](c Comparator) {
+	sandbox.RegisterComparator(reflect.TypeOf((*T)(nil)).Elem(), c)
+}
+
+// NewComparator makes the comparator for type T from the three-way compare function.
+// The convert function turns expected values of other types (e.g. strings from OData query) into T, it could be nil.
+func NewComparator[T any](cmp func(a, b T) int, convert func(interface{}) (T, error)) Comparator {
+	return sandbox.NewComparator[T](cmp, convert)
+}
@@ -0,0 +1,60 @@
+package sqlinjector
+
+import (
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/sqlboiler/v4/types"
+	"testing"
+)
+
+func TestRegisterComparator(t *testing.T) {
+	type version struct {
+		Major int
+		Minor int
+	}
+	type release struct {
+		ID      string  `boil:"id"`
+		Version version `boil:"version"`
+	}
+
+	RegisterComparator[version](NewComparator[version](func(a, b version) int {
+		if a.Major != b.Major {
+			return a.Major - b.Major
+		}
+		return a.Minor - b.Minor
+	}, nil))
+
+	repo, err := NewDummySqlBoilerRepository[string, release](
+		&release{ID: "1", Version: version{Major: 1, Minor: 2}},
+		&release{ID: "2", Version: version{Major: 2, Minor: 0}},
+		&release{ID: "3", Version: version{Major: 1, Minor: 10}},
+	)
+	require.NoError(t, err)
+
+	items, err := repo.ObtainAll(Where("version", GreaterThan, version{Major: 1, Minor: 5}), OrderBy("version", Ascending))
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	require.Equal(t, "3", items[0].ID)
+	require.Equal(t, "2", items[1].ID)
+}
+
+func TestBuiltinComparator(t *testing.T) {
+	type product struct {
+		ID    string        `boil:"id"`
+		Price types.Decimal `boil:"price"`
+	}
+
+	var p1, p2 types.Decimal
+	require.NoError(t, p1.Scan("9.99"))
+	require.NoError(t, p2.Scan("10.01"))
+
+	repo, err := NewDummySqlBoilerRepository[string, product](
+		&product{ID: "1", Price: p1},
+		&product{ID: "2", Price: p2},
+	)
+	require.NoError(t, err)
+
+	items, err := repo.ObtainAll(Where("price", GreaterThan, "10"))
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	require.Equal(t, "2", items[0].ID)
+}
@@ -1,9 +1,17 @@
 package sqlinjector
 
 import (
+	"encoding/json"
+	"fmt"
+	"github.com/prorochestvo/sqlinjector/internal"
 	"github.com/prorochestvo/sqlinjector/internal/expression"
+	"github.com/volatiletech/sqlboiler/v4/drivers"
+	"github.com/volatiletech/sqlboiler/v4/queries"
 	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+	"github.com/volatiletech/strmangle"
 	"net/url"
+	"slices"
+	"strconv"
 	"strings"
 )
 
@@ -13,21 +21,74 @@ type Expression interface {
 
 // ODataExpression parses Expression options from the request and returns a slice of query mods for SQLBoiler.
 // The following options are supported:
-// - $select - a comma-separated list of Columns to select
-// - $limit - the maximum number of records to return
-// - $offset - the number of records to skip
-// - $filter - a filter expression in the form of "Column operator value" Where operator is one of eq, ne, gt, ge, lt, le, in, contains, startswith, endswith and value is a string or a number (without quotes and not supported Or operator).
-// Example: $filter=field1 eq 'value1' and field2 ne 'value2' or field3 gt 10
+// - $select - a comma-separated list of Columns to select, the column of related table is prefixed by its name, e.g. user.name
+// - $Limit (or $top) - the maximum number of records to return
+// - $Offset (or $skip) - the number of records to skip
+// - $filter - a filter expression of conditions "Column operator value" combined by and, or, not and parentheses, Where operator is one of eq, ne, gt, ge, lt, le, in, contains, startswith, endswith and value is a literal: 'quoted string' (the quote is escaped by doubling), number (e.g. -1, 9.99), true, false, null, datetime (2024-01-01T00:00:00Z), date (2024-01-01), duration'P1DT2H' or GUID.
+// The canonical functions tolower, toupper, trim, length, indexof, substring, concat, year, month, day, hour, date, now, round, floor and ceiling could be applied to columns, e.g. year(created_at) eq 2024.
+// The lambda operators any and all are applied to the array columns and the one-to-many relations, e.g. tags/any(t: t eq 'x') or items/all(i: i/qty gt 0).
+// The in operator takes the list of values, e.g. status in ('new','paid') or not (id in (1,2,3)).
+// Example: $filter=field1 eq 'value1' and (field2 ne 'value2' or field3 gt 10)
+// - $sort (or $orderby) - a comma-separated list of columns to order by, e.g. field1 desc
+// - $count - true requests the total count of entities along with the page, see ObtainPage.
+// - $apply - the transformations groupby, aggregate and filter separated by "/", the aggregate methods are sum, average, min, max, countdistinct and $count,
+// e.g. $apply=groupby((status),aggregate(amount with sum as total,$count as count))/filter(total gt 100), the filter after aggregation is turned into Having.
+// - $expand - a comma-separated list of relations to load, it is rejected by ODataExpression, see ODataExpressionWithOptions.
 // The function returns an error if the filter expression is invalid or an unsupported operator is used.
-func ODataExpression(q *url.Values, defaultPagination ...int) (Expression, error) {
+func ODataExpression(q *url.Values) (Expression, error) {
+	return ODataExpressionWithOptions(q, ODataOptions{})
+}
+
+// ODataExpressionWithOptions parses Expression options from the request the same way as ODataExpression, the options restrict the request.
+// - $expand - a comma-separated list of relations to load, each one could have the nested options $filter, $orderby, $select, $top, $skip and $expand separated by ";",
+// e.g. $expand=Author,Comments($filter=approved eq true;$top=5;$expand=Author).
+func ODataExpressionWithOptions(q *url.Values, options ODataOptions) (Expression, error) {
 	var expr []Expression
 
-	qLimit := strings.TrimSpace(q.Get(defaultQueryNameLimit))
-	qOffset := strings.TrimSpace(q.Get(defaultQueryNameOffset))
-	qFilter := strings.TrimSpace(q.Get(defaultQueryNameWhere))
-	qSort := strings.TrimSpace(q.Get(defaultQueryNameOrder))
+	names := options.Names.withDefaults()
+	policy := fieldPolicy(options.Fields)
 
-	lDefaultPagination := len(defaultPagination)
+	if options.Strict {
+		if err := names.check(q, !options.DisableAliases); err != nil {
+			return nil, err
+		}
+	}
+
+	lookup := func(name, alias string) (string, string) {
+		if v := strings.TrimSpace(q.Get(name)); v != "" || options.DisableAliases {
+			return v, name
+		}
+		if v := strings.TrimSpace(q.Get(alias)); v != "" {
+			return v, alias
+		}
+		return "", name
+	}
+
+	maxInLength := options.MaxInLength
+	if maxInLength <= 0 {
+		maxInLength = defaultMaxInLength
+	}
+	filterOptions := expression.FilterOptions{
+		MaxInLength:   maxInLength,
+		MaxLength:     options.MaxFilterLength,
+		MaxPredicates: options.MaxPredicates,
+		MaxOrBranches: options.MaxOrBranches,
+		MaxDepth:      options.MaxDepth,
+		JSONColumns:   options.JSONColumns,
+	}
+
+	qLimit, nLimit := lookup(names.Top, aliasQueryNameLimit)
+	qOffset, nOffset := lookup(names.Skip, aliasQueryNameOffset)
+	if qOffset == "" && nLimit == aliasQueryNameLimit {
+		// the next link of the request by $top continues by $skip
+		nOffset = aliasQueryNameOffset
+	}
+	qFilter, _ := lookup(names.Filter, aliasQueryNameWhere)
+	qSort, _ := lookup(names.OrderBy, aliasQueryNameOrder)
+	qSelect, _ := lookup(names.Select, aliasQueryNameSelect)
+	qExpand, _ := lookup(names.Expand, aliasQueryNameExpand)
+	qCount, _ := lookup(names.Count, aliasQueryNameCount)
+	qApply, _ := lookup(names.Apply, aliasQueryNameApply)
 
 	if qLimit != "" {
 		e, err := expression.NewLimitFrom(qLimit)
@@ -35,10 +96,16 @@ func ODataExpression(q *url.Values, defaultPagination ...int) (Expression, error
 			return nil, err
 		}
 		for _, limit := range e {
+			if options.MaxLimit > 0 && limit.Limit() > options.MaxLimit {
+				return nil, &ComplexityError{Measure: MeasureLimit, Max: options.MaxLimit}
+			}
+			if options.MaxPageSize > 0 && limit.Limit() > options.MaxPageSize {
+				limit = expression.NewLimit(options.MaxPageSize)
+			}
 			expr = append(expr, limit)
 		}
-	} else if lDefaultPagination > 0 {
-		expr = append(expr, expression.NewLimit(defaultPagination[0]))
+	} else if size := options.pageSize(); size > 0 {
+		expr = append(expr, expression.NewLimit(size))
 	}
 
 	if qOffset != "" {
@@ -49,78 +116,580 @@ func ODataExpression(q *url.Values, defaultPagination ...int) (Expression, error
 		for _, offset := range e {
 			expr = append(expr, offset)
 		}
-	} else if lDefaultPagination > 1 {
-		expr = append(expr, expression.NewLimit(defaultPagination[1]))
 	}
 
 	if qFilter != "" {
-		e, err := expression.NewWhereFrom(qFilter)
+		filter := filterOptions
+		filter.Table = options.Table
+		e, err := expression.NewWhereFromWithOptions(qFilter, filter)
 		if err != nil {
 			return nil, err
 		}
 		for _, where := range e {
+			if policy != nil {
+				if err = policy.where(where); err != nil {
+					return nil, err
+				}
+			}
 			expr = append(expr, where)
 		}
 	}
 
+	if qApply != "" {
+		apply := filterOptions
+		apply.Table = options.Table
+		e, err := expression.NewApplyFrom(qApply, apply)
+		if err != nil {
+			return nil, err
+		}
+		if policy != nil {
+			if err = policy.aggregation(e); err != nil {
+				return nil, err
+			}
+		}
+		for _, m := range e {
+			expr = append(expr, m)
+		}
+	}
+
 	if qSort != "" {
 		e, err := expression.NewOrderByFrom(qSort)
 		if err != nil {
 			return nil, err
 		}
 		for _, orderBy := range e {
+			if policy != nil {
+				if err = policy.orderBy(orderBy); err != nil {
+					return nil, err
+				}
+			}
 			expr = append(expr, orderBy)
 		}
 	}
 
+	if qSelect != "" && policy != nil {
+		var err error
+		if qSelect, err = policy.selection(qSelect); err != nil {
+			return nil, err
+		}
+	}
+
+	if qSelect != "" {
+		e, err := expression.NewSelectFrom(qSelect)
+		if err != nil {
+			return nil, err
+		}
+		expr = append(expr, e)
+	}
+
+	if qExpand != "" {
+		depth := options.MaxExpandDepth
+		if depth <= 0 {
+			depth = defaultExpandDepth
+		}
+		expandOptions := expression.ExpandOptions{FilterOptions: filterOptions, MaxLimit: options.MaxLimit, MaxPageSize: options.MaxPageSize}
+		e, err := expression.NewRelationFromWithOptions(qExpand, depth, expandOptions, options.Expandable...)
+		if err != nil {
+			return nil, err
+		}
+		for _, relation := range e {
+			if policy != nil {
+				if err = policy.relation(relation); err != nil {
+					return nil, err
+				}
+			}
+			expr = append(expr, relation)
+		}
+	}
+
+	if qCount != "" {
+		e, err := expression.NewCountFrom(qCount)
+		if err != nil {
+			return nil, err
+		}
+		for _, count := range e {
+			expr = append(expr, count)
+		}
+	}
+
+	if options.MaxJoins > 0 && joins(expr) > options.MaxJoins {
+		return nil, &ComplexityError{Measure: MeasureJoins, Max: options.MaxJoins}
+	}
+
+	return &combiner{expressions: expr, skip: nOffset}, nil
+}
+
+// joins counts the tables joined by the expressions: the related tables of conditions and orders, the relations of lambda operators and the expanded relations
+func joins(expressions []Expression) int {
+	tables := make(map[string]struct{})
+	for _, e := range expressions {
+		switch v := e.(type) {
+		case expression.Condition:
+			for _, w := range expression.Leaves(v) {
+				tables[w.Table] = struct{}{}
+				if w.Function != nil {
+					for _, f := range w.Function.Fields() {
+						tables[f.Table] = struct{}{}
+					}
+				}
+			}
+			for _, l := range expression.Lambdas(v) {
+				if l.Relation {
+					tables[l.Column] = struct{}{}
+				}
+			}
+		case *expression.OrderBy:
+			tables[v.Table] = struct{}{}
+		case *expression.Join:
+			tables[v.Reference()] = struct{}{}
+		case *expression.Relation:
+			tables[strings.Join(v.Relation(), ".")] = struct{}{}
+		}
+	}
+	delete(tables, "")
+	return len(tables)
+}
+
+// ToOData renders the expression back to the OData query parameters of the standard names ($filter, $orderby, $top, $skip, $select, $expand, $count),
+// it is the reverse of ODataExpression (and of ODataExpressionWithOptions with $expand), unless the aliases are disabled.
+// The conditions are joined by the and operator, the last Limit and Offset take effect the same way as in the query.
+// GroupBy, aggregates and Having are rendered into $apply, the conditions of rows are rendered into $filter, the columns of groups are not repeated by $select;
+// Having combined with other conditions by Or, And or Not is not supported.
+func ToOData(e Expression) (url.Values, error) {
+	q := url.Values{}
+
+	var conditions []expression.Condition
+	var orderBy []*expression.OrderBy
+	var selects []*expression.Select
+	var relations []*expression.Relation
+	var groupBy []*expression.GroupBy
+	var aggregates []*expression.Aggregate
+	var having []*expression.Having
+	for _, item := range flatten([]Expression{e}) {
+		switch v := item.(type) {
+		case *expression.Having:
+			having = append(having, v)
+		case expression.Condition:
+			if expression.Grouped(v) {
+				return nil, fmt.Errorf("having combined with other conditions is not supported by OData: %s", v.ToString())
+			}
+			conditions = append(conditions, v)
+		case *expression.GroupBy:
+			groupBy = append(groupBy, v)
+		case *expression.Aggregate:
+			aggregates = append(aggregates, v)
+		case *expression.OrderBy:
+			orderBy = append(orderBy, v)
+		case *expression.Select:
+			selects = append(selects, v)
+		case *expression.Relation:
+			relations = append(relations, v)
+		case *expression.Limit:
+			q.Set(aliasQueryNameLimit, strconv.Itoa(v.Limit()))
+		case *expression.Offset:
+			q.Set(aliasQueryNameOffset, strconv.Itoa(v.Offset()))
+		case *expression.Count:
+			q.Set(aliasQueryNameCount, "true")
+		default:
+			return nil, fmt.Errorf("expression %T is not supported by OData", item)
+		}
+	}
+
+	if len(conditions) > 0 {
+		filter, err := expression.ToFilter(conditions...)
+		if err != nil {
+			return nil, err
+		}
+		q.Set(aliasQueryNameWhere, filter)
+	}
+	if len(groupBy) > 0 || len(aggregates) > 0 || len(having) > 0 {
+		apply, err := expression.ToApply(groupBy, aggregates, having)
+		if err != nil {
+			return nil, err
+		}
+		q.Set(aliasQueryNameApply, apply)
+		// the columns of groups are selected by groupby of $apply
+		selects = slices.DeleteFunc(selects, func(s *expression.Select) bool {
+			return slices.EqualFunc(s.Select(), groupBy, func(c string, g *expression.GroupBy) bool {
+				return c == strings.TrimPrefix(g.Table+"."+g.Column, ".")
+			})
+		})
+	}
+	if len(orderBy) > 0 {
+		q.Set(aliasQueryNameOrder, expression.ToOrderBy(orderBy...))
+	}
+	if len(selects) > 0 {
+		q.Set(aliasQueryNameSelect, expression.ToSelect(selects...))
+	}
+	if len(relations) > 0 {
+		expand, err := expression.ToExpand(relations...)
+		if err != nil {
+			return nil, err
+		}
+		q.Set(aliasQueryNameExpand, expand)
+	}
+
+	return q, nil
+}
+
+// MarshalExpression encodes the expression into JSON of the versioned schema, e.g. {"version":1,"expressions":[{"type":"where",...}]}.
+// The values keep their types, e.g. {"type":"int64","value":1}, so the expression is decoded by UnmarshalExpression as it was.
+// The raw conditions of Join are not supported, JoinOn is expected instead.
+func MarshalExpression(e Expression) ([]byte, error) {
+	items := flatten([]Expression{e})
+	doc := expressionDocument{Version: expressionVersion, Expressions: make([]*expression.Node, 0, len(items))}
+	for _, item := range items {
+		n, err := expression.NodeOf(item)
+		if err != nil {
+			return nil, err
+		}
+		doc.Expressions = append(doc.Expressions, n)
+	}
+	return json.Marshal(doc)
+}
+
+// UnmarshalExpression decodes the expression from JSON made by MarshalExpression.
+// The unknown fields are ignored, the documents of newer version and the unknown types of expressions are rejected.
+func UnmarshalExpression(data []byte) (Expression, error) {
+	var doc expressionDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Version < 1 || doc.Version > expressionVersion {
+		return nil, fmt.Errorf("unsupported version of expression: %d", doc.Version)
+	}
+
+	expr := make([]Expression, 0, len(doc.Expressions))
+	for _, n := range doc.Expressions {
+		if n == nil {
+			return nil, fmt.Errorf("expression is null")
+		}
+		e, err := n.Expression()
+		if err != nil {
+			return nil, err
+		}
+		expr = append(expr, e)
+	}
+
 	return &combiner{expressions: expr}, nil
 }
 
+// ToSQL renders the SELECT statement of the table restricted by the expressions with the placeholders and the quotes of the dialect,
+// e.g. $1 and "name" for PostgreSQL, ? and `name` for MySQL, ? and "name" for SQLite. The relations are not rendered, since they are loaded by separate queries.
+// The columns of other tables require Join of the table (or its alias) and the lambda over relation (e.g. items/any(i: i/qty gt 1)) is rejected,
+// unless the join heuristic is enabled (see SetJoinHeuristic).
+func ToSQL(d dialect, table string, expressions ...Expression) (string, []any, error) {
+	var quote rune = '"'
+	switch d {
+	case DialectPostgreSQL, DialectSQLite3:
+	case DialectMySQL:
+		quote = '`'
+	default:
+		return "", nil, fmt.Errorf("unsupported dialect: %s", d)
+	}
+
+	items := flatten(expressions)
+	if !expression.JoinHeuristic() {
+		if err := unjoined(table, items); err != nil {
+			return "", nil, err
+		}
+	}
+
+	q := &queries.Query{}
+	queries.SetDialect(q, &drivers.Dialect{LQ: quote, RQ: quote, UseIndexPlaceholders: d == DialectPostgreSQL})
+	queries.SetFrom(q, strmangle.IdentQuote(quote, quote, table))
+
+	for _, e := range items {
+		if de, ok := e.(expression.DialectExpression); ok {
+			qm.Apply(q, de.QueryModOf(d)...)
+			continue
+		}
+		qm.Apply(q, e.QueryMod()...)
+	}
+
+	s, args := queries.BuildQuery(q)
+	if quote != '"' {
+		// the IN clause of sqlboiler quotes the column once again, e.g. `"id"`
+		s = strings.NewReplacer(string(quote)+`"`, `"`, `"`+string(quote), `"`).Replace(s)
+		s = requote(s, quote)
+	}
+	return s, args, nil
+}
+
+// unjoined returns an error if the columns of expressions refer to the table, which is neither the table of query nor joined by Join,
+// the tables are matched exactly, i.e. "book/title" of the table "books" is not joined
+func unjoined(table string, items []Expression) error {
+	joined := map[string]struct{}{table: {}}
+	var columns [][2]string
+	for _, item := range items {
+		switch v := item.(type) {
+		case *expression.Join:
+			joined[v.Reference()] = struct{}{}
+		case *expression.Having:
+			columns = append(columns, [2]string{v.Aggregate.Table, v.Aggregate.Column})
+		case expression.Condition:
+			for _, l := range expression.Lambdas(v) {
+				if l.Relation {
+					return fmt.Errorf("lambda over the relation %s guesses its table and foreign key, which requires the join heuristic (see SetJoinHeuristic)", l.Column)
+				}
+			}
+			for _, w := range expression.Leaves(v) {
+				columns = append(columns, [2]string{w.Table, w.Column})
+				if w.Function != nil {
+					for _, f := range w.Function.Fields() {
+						columns = append(columns, [2]string{f.Table, f.Column})
+					}
+				}
+			}
+		case *expression.OrderBy:
+			columns = append(columns, [2]string{v.Table, v.Column})
+		case *expression.GroupBy:
+			columns = append(columns, [2]string{v.Table, v.Column})
+		case *expression.Aggregate:
+			columns = append(columns, [2]string{v.Table, v.Column})
+		case *expression.Select:
+			for _, c := range v.Select() {
+				if t, column, ok := strings.Cut(c, "."); ok {
+					columns = append(columns, [2]string{t, column})
+				}
+			}
+		}
+	}
+
+	for _, c := range columns {
+		if _, ok := joined[c[0]]; !ok && c[0] != "" {
+			return fmt.Errorf("column %s/%s refers to the table %s, which is not joined (see Join and SetJoinHeuristic)", c[0], c[1], c[0])
+		}
+	}
+	return nil
+}
+
+// requote replaces the double quotes of identifiers by the given one, the string literals are kept as is
+func requote(s string, quote rune) string {
+	b := []rune(s)
+	literal := false
+	for i, c := range b {
+		switch {
+		case c == '\'':
+			literal = !literal
+		case c == '"' && !literal:
+			b[i] = quote
+		}
+	}
+	return string(b)
+}
+
+// expressionDocument is the versioned JSON form of expressions
+type expressionDocument struct {
+	Version     int                `json:"version"`
+	Expressions []*expression.Node `json:"expressions"`
+}
+
+// ODataOptions restricts the options of OData request
+type ODataOptions struct {
+	// Names overrides the names of parameters, the standard names ($filter, $orderby, $top, $skip, $select, $expand, $count, $apply) are accepted as aliases.
+	Names ODataNames
+	// DisableAliases turns off the standard names of parameters, only Names are accepted.
+	DisableAliases bool
+	// DefaultPageSize limits the records, when the request has no limit, MaxPageSize is used by default.
+	DefaultPageSize int
+	// MaxPageSize caps the limit of the request and the nested $top of $expand, 0 means no cap.
+	MaxPageSize int
+	// Fields is the policy of fields of $filter, $orderby and $select, the key is the public name of field,
+	// the field of related table is prefixed by its name, e.g. "author/name". The fields are not restricted when it is nil, otherwise the unknown fields are rejected by FieldError.
+	// The nested options of $expand are checked by the fields of related table, e.g. "author/name" is the field "name" of $expand=Author($filter=name eq 'Leo').
+	Fields map[string]FieldPolicy
+	// Table is the table of entity, it is required by the lambda operators over relations of $filter, e.g. items/any(i: i/qty gt 0).
+	Table string
+	// JSONColumns are the JSON columns (the public fields, when Fields is set), which members are filtered by the path of $filter,
+	// e.g. metadata/color eq 'red' when JSONColumns is []string{"metadata"}.
+	JSONColumns []string
+	// MaxInLength limits the number of values of in operator of $filter, 100 is used by default.
+	MaxInLength int
+	// Strict rejects the request, which has unknown parameters prefixed by "$".
+	Strict bool
+	// Expandable is the list of relations allowed by $expand, the nested ones are separated by ".", e.g. "Comments.Author".
+	Expandable []string
+	// MaxExpandDepth limits the nesting of relations of $expand, 1 is used by default.
+	MaxExpandDepth int
+	// MaxFilterLength, MaxPredicates, MaxOrBranches and MaxDepth limit the complexity of $filter (and of the nested ones of $expand),
+	// i.e. the length in characters, the number of comparisons, the total number of or branches and the nesting of parentheses; 0 means no limit,
+	// except MaxFilterLength: 0 means 8192 characters and a negative value means no limit.
+	MaxFilterLength int
+	MaxPredicates   int
+	MaxOrBranches   int
+	MaxDepth        int
+	// MaxLimit rejects the request, which limit (or the nested $top of $expand) exceeds it (unlike MaxPageSize, which caps the limit), 0 means no limit.
+	MaxLimit int
+	// MaxJoins limits the number of tables joined by $filter, $orderby and $expand, 0 means no limit.
+	MaxJoins int
+}
+
+// pageSize returns the limit of the request without one
+func (o ODataOptions) pageSize() int {
+	if o.DefaultPageSize <= 0 {
+		return max(o.MaxPageSize, 0)
+	}
+	if o.MaxPageSize > 0 {
+		return min(o.DefaultPageSize, o.MaxPageSize)
+	}
+	return o.DefaultPageSize
+}
+
+// ODataNames are the names of parameters of OData request, the empty ones are replaced by the default names
+type ODataNames struct {
+	Filter  string
+	OrderBy string
+	Top     string
+	Skip    string
+	Select  string
+	Expand  string
+	Count   string
+	Apply   string
+}
+
+// withDefaults replaces the empty names by the default ones
+func (n ODataNames) withDefaults() ODataNames {
+	or := func(name, defaultName string) string {
+		if name = strings.TrimSpace(name); name == "" {
+			return defaultName
+		}
+		return name
+	}
+	return ODataNames{
+		Filter:  or(n.Filter, defaultQueryNameWhere),
+		OrderBy: or(n.OrderBy, defaultQueryNameOrder),
+		Top:     or(n.Top, defaultQueryNameLimit),
+		Skip:    or(n.Skip, defaultQueryNameOffset),
+		Select:  or(n.Select, defaultQueryNameSelect),
+		Expand:  or(n.Expand, defaultQueryNameExpand),
+		Count:   or(n.Count, defaultQueryNameCount),
+		Apply:   or(n.Apply, defaultQueryNameApply),
+	}
+}
+
+// check returns an error if the request has unknown parameters prefixed by "$"
+func (n ODataNames) check(q *url.Values, aliases bool) error {
+	known := []string{n.Filter, n.OrderBy, n.Top, n.Skip, n.Select, n.Expand, n.Count, n.Apply}
+	if aliases {
+		known = append(known, aliasQueryNameWhere, aliasQueryNameOrder, aliasQueryNameLimit, aliasQueryNameOffset, aliasQueryNameSelect, aliasQueryNameExpand, aliasQueryNameCount, aliasQueryNameApply)
+	}
+
+	var unknown []string
+	for k := range *q {
+		if strings.HasPrefix(k, "$") && !slices.Contains(known, k) {
+			unknown = append(unknown, k)
+		}
+	}
+	if len(unknown) > 0 {
+		slices.Sort(unknown)
+		return fmt.Errorf("unsupported parameters: %s", strings.Join(unknown, ", "))
+	}
+
+	return nil
+}
+
 func Relation(t string, extra ...string) Expression {
 	return expression.NewRelation(t, extra...)
 }
 
+func Select(c string, extra ...string) Expression {
+	return expression.NewSelect(c, extra...)
+}
+
 func Where(c string, o operator, v ...interface{}) Expression {
 	return expression.NewWhere(c, o, v...)
 }
 
+// WhereJSON is the condition over the member of JSON column by its path, e.g. WhereJSON("metadata", []string{"color"}, Equal, "red")
+func WhereJSON(c string, path []string, o operator, v ...interface{}) Expression {
+	return expression.NewWhereOfJSON(c, path, o, v...)
+}
+
+// Search is the full-text search of the query over the text columns of the table, the row is matched when it has all words of the query,
+// e.g. Search("articles", []string{"title", "body"}, "go generics"). The indexes required by the search are created by NewSearchMigration.
+func Search(table string, columns []string, query string) Expression {
+	return expression.NewSearch(table, columns, query)
+}
+
+// SearchRank orders the rows by the relevance of the search of the query over the columns of the table, the most relevant rows are the first ones.
+func SearchRank(table string, columns []string, query string) Expression {
+	return expression.NewSearchRank(expression.NewSearch(table, columns, query))
+}
+
+// Or combines the conditions of expressions by the or operator, the expression could be any condition (e.g. Where, And, Not, nested Or)
+// or the combined one (e.g. of ODataExpression), which conditions are joined by the and operator. The rest of expressions (e.g. OrderBy) are kept as is.
 func Or(l Expression, r Expression, extra ...Expression) Expression {
-	expr := make([]Expression, 0, len(extra)+2)
+	return combine(expression.NewCombinerOR, true, l, r, extra...)
+}
 
-	lW, ok := l.(*expression.Where)
-	if !ok {
-		expr = append(expr, l)
-		expr = append(expr, r)
-		expr = append(expr, extra...)
-		return &combiner{expressions: expr}
-	}
+// And combines the conditions of expressions by the and operator, the rest of expressions (e.g. OrderBy) are kept as is.
+func And(l Expression, r Expression, extra ...Expression) Expression {
+	return combine(expression.NewCombinerAND, false, l, r, extra...)
+}
 
-	rW, ok := r.(*expression.Where)
-	if !ok {
-		expr = append(expr, l)
-		expr = append(expr, r)
-		expr = append(expr, extra...)
-		return &combiner{expressions: expr}
+// Not negates the conditions of expression joined by the and operator, the rest of expressions (e.g. OrderBy) are kept as is.
+// The negation of Having (or of conditions with Having) is rendered into HAVING, the same as Or of them.
+func Not(e Expression) Expression {
+	c, rest := condition(e, true)
+	if c == nil {
+		return &combiner{expressions: rest}
+	}
+	n := expression.NewCombinerNOT(c)
+	if len(rest) == 0 {
+		return n
 	}
+	return &combiner{expressions: append(rest, n)}
+}
 
-	extraW := make([]*expression.Where, 0, len(extra))
-	for _, e := range extra {
-		var eW *expression.Where
-		if eW, ok = e.(*expression.Where); ok {
-			extraW = append(extraW, eW)
-		} else {
-			expr = append(expr, e)
+// combine joins the conditions of expressions by the combinator, the expression without conditions is not combined.
+// Having is combined when grouping is set, otherwise it is kept as is, since HAVING is joined with WHERE by the and operator.
+func combine[T expression.Condition](combinator func(l, r expression.Condition, e ...expression.Condition) T, grouping bool, l Expression, r Expression, extra ...Expression) Expression {
+	conditions := make([]expression.Condition, 0, len(extra)+2)
+	expr := make([]Expression, 0)
+	for _, e := range append([]Expression{l, r}, extra...) {
+		c, rest := condition(e, grouping)
+		if c != nil {
+			conditions = append(conditions, c)
 		}
+		expr = append(expr, rest...)
 	}
 
-	e := expression.NewCombinerOR(lW, rW, extraW...)
-	if len(expr) == 0 {
-		return e
+	var c expression.Condition
+	switch len(conditions) {
+	case 0:
+		return &combiner{expressions: expr}
+	case 1:
+		c = conditions[0]
+	default:
+		c = combinator(conditions[0], conditions[1], conditions[2:]...)
 	}
 
-	expr = append(expr, e)
+	if len(expr) == 0 {
+		return c
+	}
+	return &combiner{expressions: append(expr, c)}
+}
 
-	return &combiner{expressions: expr}
+// condition returns the conditions of expression joined by the and operator and the rest of expressions,
+// Having is one of the conditions when grouping is set, the combination with Having is rendered into HAVING as a whole
+func condition(e Expression, grouping bool) (expression.Condition, []Expression) {
+	var conditions []expression.Condition
+	var rest []Expression
+	for _, item := range flatten([]Expression{e}) {
+		if c, ok := item.(expression.Condition); ok && (grouping || !expression.Grouped(c)) {
+			conditions = append(conditions, c)
+			continue
+		}
+		rest = append(rest, item)
+	}
+	switch len(conditions) {
+	case 0:
+		return nil, rest
+	case 1:
+		return conditions[0], rest
+	}
+	return expression.NewCombinerAND(conditions[0], conditions[1], conditions[2:]...), rest
 }
 
 func GroupBy(c string, extra ...string) Expression {
@@ -157,6 +726,39 @@ func OrderBy(c string, d direction, extra ...string) Expression {
 	return &combiner{expressions: expr}
 }
 
+// Count selects the number of rows of group as the alias, the column (unless it is "*") counts the rows, which column is not null
+func Count(c, alias string) *aggregate {
+	return expression.NewAggregate(expression.AggregateCount, c, alias)
+}
+
+// Sum selects the sum of column over the rows of group as the alias
+func Sum(c, alias string) *aggregate {
+	return expression.NewAggregate(expression.AggregateSum, c, alias)
+}
+
+// Avg selects the average of column over the rows of group as the alias
+func Avg(c, alias string) *aggregate {
+	return expression.NewAggregate(expression.AggregateAverage, c, alias)
+}
+
+// Min selects the minimal value of column over the rows of group as the alias
+func Min(c, alias string) *aggregate {
+	return expression.NewAggregate(expression.AggregateMin, c, alias)
+}
+
+// Max selects the maximal value of column over the rows of group as the alias
+func Max(c, alias string) *aggregate {
+	return expression.NewAggregate(expression.AggregateMax, c, alias)
+}
+
+// Having filters the groups by the aggregate with the operators of Where, e.g. Having(Sum("amount", "total"), GreaterThan, 100).
+// The aggregate is rendered instead of its alias, so it is not required to be selected.
+func Having(a *aggregate, o operator, v ...interface{}) Expression {
+	return expression.NewHaving(a, o, v...)
+}
+
+type aggregate = expression.Aggregate
+
 func Limit(v int) Expression {
 	return expression.NewLimit(v)
 }
@@ -170,6 +772,39 @@ const (
 	defaultQueryNameOrder  = "$sort"
 	defaultQueryNameLimit  = "$Limit"
 	defaultQueryNameOffset = "$Offset"
+	defaultQueryNameSelect = "$select"
+	defaultQueryNameExpand = "$expand"
+	defaultQueryNameCount  = "$count"
+	defaultQueryNameApply  = "$apply"
+
+	aliasQueryNameWhere  = "$filter"
+	aliasQueryNameOrder  = "$orderby"
+	aliasQueryNameLimit  = "$top"
+	aliasQueryNameOffset = "$skip"
+	aliasQueryNameSelect = "$select"
+	aliasQueryNameExpand = "$expand"
+	aliasQueryNameCount  = "$count"
+	aliasQueryNameApply  = "$apply"
+
+	defaultExpandDepth = 1
+	defaultMaxInLength = 100
+
+	expressionVersion = 1 // the version of JSON schema of MarshalExpression
+)
+
+// ComplexityError is returned when the request exceeds the limit of complexity of ODataOptions, it means 400 Bad Request.
+type ComplexityError = expression.ComplexityError
+
+type measure = expression.Measure
+
+const (
+	MeasureFilterLength measure = expression.MeasureFilterLength
+	MeasurePredicates   measure = expression.MeasurePredicates
+	MeasureOrBranches   measure = expression.MeasureOrBranches
+	MeasureDepth        measure = expression.MeasureDepth
+	MeasureInLength     measure = expression.MeasureInLength
+	MeasureLimit        measure = expression.MeasureLimit
+	MeasureJoins        measure = expression.MeasureJoins
 )
 
 type operator = expression.Operator
@@ -188,6 +823,70 @@ const (
 	Contains           operator = expression.Contains
 	StartsWith         operator = expression.StartsWith
 	EndsWith           operator = expression.EndsWith
+	Between            operator = expression.Between
+	NotBetween         operator = expression.NotBetween
+	IContains          operator = expression.IContains
+	IStartsWith        operator = expression.IStartsWith
+	IEndsWith          operator = expression.IEndsWith
+	NotContains        operator = expression.NotContains
+	Matches            operator = expression.Matches
+)
+
+// Join joins the table by the condition, the alias (the table, when it is empty) qualifies the columns of Where, OrderBy and Select,
+// e.g. Join("users", "author", `"author"."id" = "books"."author_id"`, LeftJoin) and Where("author/name", Equal, "Leo").
+// The identifiers of condition are quoted by double quotes, they are requoted by ToSQL for MySQL.
+func Join(table, alias, on string, kind joinKind) Expression {
+	return expression.NewJoin(table, alias, on, kind)
+}
+
+// JoinOn joins the table by the equality of its column and the foreign column, e.g. JoinOn("users", "author", "id", "books/author_id", LeftJoin),
+// unlike Join it is supported by MarshalExpression.
+func JoinOn(table, alias, column, foreign string, kind joinKind) Expression {
+	return expression.NewJoinOf(table, alias, column, foreign, kind)
+}
+
+type joinKind = expression.JoinKind
+
+const (
+	InnerJoin joinKind = expression.InnerJoin
+	LeftJoin  joinKind = expression.LeftJoin
+	RightJoin joinKind = expression.RightJoin
+)
+
+// SetJoinHeuristic enables the guess of related tables, which are not joined explicitly by Join (it is disabled by default):
+// the related table of column, e.g. "author/name", is turned into the plural snake case and joined by the foreign key,
+// i.e. INNER JOIN "authors" ON "authors"."id" = "author_id", the lambda over relation is guessed the same way.
+// The related tables are referred as is and the lambda over relation is rejected by ToSQL without the heuristic.
+func SetJoinHeuristic(enabled bool) {
+	expression.SetJoinHeuristic(enabled)
+}
+
+// NamingStrategy names the tables and columns of models and relations, it is used by the join heuristic,
+// the relations of sandbox and the tables of NewStructMigration and NewSandboxSqlBoilerRepository.
+type NamingStrategy = internal.NamingStrategy
+
+// NewBoilNamingStrategy returns the naming of sqlboiler built on strmangle, the tables are the plural snake case of models
+// with the prefix, e.g. "UserGroup" is stored in "app_user_groups" by the prefix "app_".
+func NewBoilNamingStrategy(prefix string) NamingStrategy {
+	return internal.BoilNaming{Prefix: prefix}
+}
+
+// SetNamingStrategy sets the naming strategy of tables and columns, nil restores the naming of sqlboiler without prefix used by default.
+func SetNamingStrategy(n NamingStrategy) {
+	internal.SetNaming(n)
+}
+
+// SetDialect sets the dialect of database used by QueryMod of expressions (e.g. for functions of $filter), PostgreSQL is used by default.
+func SetDialect(d dialect) {
+	expression.SetDialect(d)
+}
+
+type dialect = internal.Dialect
+
+const (
+	DialectPostgreSQL dialect = internal.DialectPostgreSQL
+	DialectMySQL      dialect = internal.DialectMySQL
+	DialectSQLite3    dialect = internal.DialectSQLite3
 )
 
 type direction = expression.Direction
@@ -199,6 +898,7 @@ const (
 
 type combiner struct {
 	expressions []Expression
+	skip        string // the name of offset parameter of OData request, it is used by the next link of page
 }
 
 func (c *combiner) QueryMod() []qm.QueryMod {
@@ -208,3 +908,16 @@ func (c *combiner) QueryMod() []qm.QueryMod {
 	}
 	return mods
 }
+
+// flatten unwraps combined expressions into the plain list.
+func flatten(expressions []Expression) []Expression {
+	res := make([]Expression, 0, len(expressions))
+	for _, e := range expressions {
+		if c, ok := e.(*combiner); ok {
+			res = append(res, flatten(c.expressions)...)
+			continue
+		}
+		res = append(res, e)
+	}
+	return res
+}
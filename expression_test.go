@@ -5,6 +5,7 @@ import (
 	"github.com/prorochestvo/sqlinjector/internal/expression"
 	"github.com/stretchr/testify/require"
 	"net/url"
+	"strings"
 	"testing"
 )
 
@@ -79,3 +80,602 @@ func TestODataExpression(t *testing.T) {
 		}
 	})
 }
+
+func TestODataExpressionWithOptions(t *testing.T) {
+	toString := func(e Expression) []string {
+		c, ok := e.(*combiner)
+		require.True(t, ok)
+		res := make([]string, 0, len(c.expressions))
+		for _, expr := range c.expressions {
+			res = append(res, expr.(interface{ ToString() string }).ToString())
+		}
+		return res
+	}
+
+	t.Run("StandardAliases", func(t *testing.T) {
+		q := url.Values{"$top": {"10"}, "$skip": {"20"}, "$orderby": {"name desc"}}
+		e, err := ODataExpression(&q)
+		require.NoError(t, err)
+		require.Equal(t, []string{"Limit 10", "Offset 20", "name DESC"}, toString(e))
+		require.Equal(t, "$skip", e.(*combiner).skip)
+	})
+	t.Run("DisableAliases", func(t *testing.T) {
+		q := url.Values{"$top": {"10"}, "$Offset": {"20"}}
+		e, err := ODataExpressionWithOptions(&q, ODataOptions{DisableAliases: true})
+		require.NoError(t, err)
+		require.Equal(t, []string{"Offset 20"}, toString(e))
+	})
+	t.Run("Names", func(t *testing.T) {
+		q := url.Values{"limit": {"5"}, "page_offset": {"15"}, "$filter": {"name eq 'John'"}}
+		e, err := ODataExpressionWithOptions(&q, ODataOptions{Names: ODataNames{Top: "limit", Skip: "page_offset"}})
+		require.NoError(t, err)
+		require.Equal(t, []string{"Limit 5", "Offset 15", "name eq John"}, toString(e))
+		require.Equal(t, "page_offset", e.(*combiner).skip)
+	})
+	t.Run("PageSize", func(t *testing.T) {
+		q := url.Values{}
+		e, err := ODataExpressionWithOptions(&q, ODataOptions{DefaultPageSize: 20, MaxPageSize: 50})
+		require.NoError(t, err)
+		require.Equal(t, []string{"Limit 20"}, toString(e))
+
+		e, err = ODataExpressionWithOptions(&q, ODataOptions{MaxPageSize: 50})
+		require.NoError(t, err)
+		require.Equal(t, []string{"Limit 50"}, toString(e))
+
+		q = url.Values{"$top": {"100"}}
+		e, err = ODataExpressionWithOptions(&q, ODataOptions{DefaultPageSize: 20, MaxPageSize: 50})
+		require.NoError(t, err)
+		require.Equal(t, []string{"Limit 50"}, toString(e))
+	})
+	t.Run("Strict", func(t *testing.T) {
+		q := url.Values{"$top": {"10"}, "$search": {"john"}, "$format": {"json"}, "page": {"2"}}
+		_, err := ODataExpression(&q)
+		require.NoError(t, err)
+
+		_, err = ODataExpressionWithOptions(&q, ODataOptions{Strict: true})
+		require.EqualError(t, err, "unsupported parameters: $format, $search")
+
+		q = url.Values{"$top": {"10"}, "page": {"2"}}
+		_, err = ODataExpressionWithOptions(&q, ODataOptions{Strict: true})
+		require.NoError(t, err)
+
+		_, err = ODataExpressionWithOptions(&q, ODataOptions{Strict: true, DisableAliases: true})
+		require.EqualError(t, err, "unsupported parameters: $top")
+	})
+	t.Run("Apply", func(t *testing.T) {
+		q := url.Values{"$apply": {"filter(price gt 0)/groupby((state),aggregate(price with sum as total,$count as count))/filter(total gt 100)"}, "$orderby": {"state"}}
+		options := ODataOptions{Fields: map[string]FieldPolicy{
+			"state": {Column: "status", Selectable: true, Sortable: true},
+			"price": {Column: "amount", Filterable: true, Selectable: true},
+		}}
+		e, err := ODataExpressionWithOptions(&q, options)
+		require.NoError(t, err)
+		s, args, err := ToSQL(DialectMySQL, "orders", e)
+		require.NoError(t, err)
+		require.Equal(t, "SELECT `status`, SUM(`amount`) AS `total`, COUNT(*) AS `count` FROM `orders` WHERE (`amount` > ?) GROUP BY status HAVING SUM(`amount`) > ? ORDER BY `status`;", s)
+		require.Equal(t, []any{0, 100}, args)
+
+		q = url.Values{"$apply": {"aggregate(secret with max as m)"}}
+		options.Fields["secret"] = FieldPolicy{Filterable: true}
+		_, err = ODataExpressionWithOptions(&q, options)
+		var fieldErr *FieldError
+		require.ErrorAs(t, err, &fieldErr)
+		require.Equal(t, "secret", fieldErr.Field)
+
+		q = url.Values{"$apply": {"groupby((status))"}}
+		_, err = ODataExpressionWithOptions(&q, ODataOptions{Strict: true})
+		require.NoError(t, err)
+	})
+	t.Run("Complexity", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			q       url.Values
+			options ODataOptions
+			measure measure
+		}{
+			{name: "limit", q: url.Values{"$top": {"100"}}, options: ODataOptions{MaxLimit: 100, MaxPageSize: 10}},
+			{name: "limit exceeded", q: url.Values{"$top": {"101"}}, options: ODataOptions{MaxLimit: 100}, measure: MeasureLimit},
+			{name: "in length exceeded by default", q: url.Values{"$filter": {"id in (" + strings.TrimSuffix(strings.Repeat("1,", 101), ",") + ")"}}, measure: MeasureInLength},
+			{name: "filter length exceeded", q: url.Values{"$filter": {"name eq 'John'"}}, options: ODataOptions{MaxFilterLength: 10}, measure: MeasureFilterLength},
+			{name: "predicates exceeded", q: url.Values{"$filter": {"a eq 1 and b eq 2"}}, options: ODataOptions{MaxPredicates: 1}, measure: MeasurePredicates},
+			{name: "or branches exceeded", q: url.Values{"$filter": {"a eq 1 or a eq 2"}}, options: ODataOptions{MaxOrBranches: 1}, measure: MeasureOrBranches},
+			{name: "depth exceeded", q: url.Values{"$filter": {"((a eq 1))"}}, options: ODataOptions{MaxDepth: 1}, measure: MeasureDepth},
+			{
+				name:    "predicates of expand exceeded",
+				q:       url.Values{"$expand": {"Comments($filter=a eq 1 and b eq 2)"}},
+				options: ODataOptions{Expandable: []string{"Comments"}, MaxPredicates: 1},
+				measure: MeasurePredicates,
+			},
+			{
+				name:    "limit of expand exceeded",
+				q:       url.Values{"$expand": {"Comments($top=100000000)"}},
+				options: ODataOptions{Expandable: []string{"Comments"}, MaxLimit: 100},
+				measure: MeasureLimit,
+			},
+			{
+				name:    "limit of nested expand exceeded",
+				q:       url.Values{"$expand": {"Comments($expand=Author($top=101))"}},
+				options: ODataOptions{Expandable: []string{"Comments", "Comments.Author"}, MaxExpandDepth: 2, MaxLimit: 100},
+				measure: MeasureLimit,
+			},
+			{
+				name:    "joins",
+				q:       url.Values{"$filter": {"author/name eq 'Leo' and tolower(author/city) eq 'moscow'"}, "$orderby": {"author.name"}, "$expand": {"Comments"}},
+				options: ODataOptions{Expandable: []string{"Comments"}, MaxJoins: 2},
+			},
+			{
+				name:    "joins exceeded",
+				q:       url.Values{"$filter": {"author/name eq 'Leo' and items/any(i: i/qty gt 1)"}, "$orderby": {"publisher.name"}},
+				options: ODataOptions{Table: "books", MaxJoins: 2},
+				measure: MeasureJoins,
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := ODataExpressionWithOptions(&tt.q, tt.options)
+				if tt.measure == "" {
+					require.NoError(t, err)
+					return
+				}
+				var e *ComplexityError
+				require.ErrorAs(t, err, &e)
+				require.Equal(t, tt.measure, e.Measure)
+			})
+		}
+
+		t.Run("limit of expand capped", func(t *testing.T) {
+			q := url.Values{"$expand": {"Comments($top=100000000)"}}
+			e, err := ODataExpressionWithOptions(&q, ODataOptions{Expandable: []string{"Comments"}, MaxPageSize: 10})
+			require.NoError(t, err)
+			var relation *expression.Relation
+			for _, item := range flatten([]Expression{e}) {
+				if r, ok := item.(*expression.Relation); ok {
+					relation = r
+				}
+			}
+			require.NotNil(t, relation)
+			require.Equal(t, []expression.Modifier{expression.NewLimit(10)}, relation.Mods())
+		})
+	})
+}
+
+func TestToOData(t *testing.T) {
+	options := ODataOptions{Table: "orders", Expandable: []string{"Author", "Comments"}}
+
+	tests := []url.Values{
+		{defaultQueryNameLimit: {"10"}, defaultQueryNameOffset: {"20"}, defaultQueryNameCount: {"true"}},
+		{defaultQueryNameWhere: {"name eq 'O''Neil' and (age ge 18 or age eq null) and not (status in ('new','paid'))"}},
+		{defaultQueryNameWhere: {"year(created_at) eq 2024 and created_at lt 2024-06-01T10:00:00.5+03:00 and timeout gt duration'PT1.5S'"}},
+		{defaultQueryNameWhere: {"tags/any(t: t eq 'x') or items/all(i: i/qty gt 1.5)"}},
+		{defaultQueryNameOrder: {"name desc, user.id"}, defaultQueryNameSelect: {"id, user.name"}},
+		{defaultQueryNameExpand: {"Author,Comments($filter=approved eq true;$orderby=id desc;$top=5)"}},
+		{defaultQueryNameApply: {"groupby((status),aggregate(amount with sum as total,$count as count))"}},
+		{defaultQueryNameApply: {"filter(amount gt 5)/groupby((status, user.id),aggregate(order/amount with average as avg,id with countdistinct as ids))/filter(avg ge 60 and ids lt 3)"}, defaultQueryNameOrder: {"status"}},
+		{defaultQueryNameApply: {"aggregate(amount with max as top)"}, defaultQueryNameSelect: {"id"}},
+	}
+
+	for _, q := range tests {
+		t.Run(q.Encode(), func(t *testing.T) {
+			expected, err := ODataExpressionWithOptions(&q, options)
+			require.NoError(t, err)
+
+			values, err := ToOData(expected)
+			require.NoError(t, err)
+
+			actually, err := ODataExpressionWithOptions(&values, options)
+			require.NoError(t, err)
+			require.Equal(t, expected.(*combiner).expressions, actually.(*combiner).expressions)
+		})
+	}
+
+	t.Run("Builders", func(t *testing.T) {
+		values, err := ToOData(&combiner{expressions: []Expression{
+			Or(Where("name", StartsWith, "Jo"), Where("id", In, 1, 2)),
+			Where("user/age", GreaterThan, 18),
+			OrderBy("name", Descending),
+			Limit(5),
+		}})
+		require.NoError(t, err)
+		require.Equal(t, url.Values{
+			"$filter":  {"(startswith(name,'Jo') or id in (1,2)) and user/age gt 18"},
+			"$orderby": {"name desc"},
+			"$top":     {"5"},
+		}, values)
+	})
+	t.Run("Standard names", func(t *testing.T) {
+		q := url.Values{"$filter": {"name eq 'John'"}, "$orderby": {"id desc"}, "$top": {"10"}, "$skip": {"20"}, "$select": {"id, name"}, "$count": {"true"}}
+		expected, err := ODataExpression(&q)
+		require.NoError(t, err)
+
+		values, err := ToOData(expected)
+		require.NoError(t, err)
+		require.Equal(t, q, values)
+
+		actually, err := ODataExpressionWithOptions(&values, ODataOptions{Strict: true})
+		require.NoError(t, err)
+		require.Equal(t, expected, actually)
+	})
+	t.Run("Apply", func(t *testing.T) {
+		values, err := ToOData(&combiner{expressions: []Expression{GroupBy("status"), Sum("amount", "total"), Having(Sum("amount", "total"), GreaterThan, 100)}})
+		require.NoError(t, err)
+		require.Equal(t, url.Values{"$apply": {"groupby((status),aggregate(amount with sum as total))/filter(total gt 100)"}}, values)
+	})
+	t.Run("Unsupported", func(t *testing.T) {
+		_, err := ToOData(Or(Having(Sum("amount", "total"), GreaterThan, 100), Where("status", Equal, "new")))
+		require.Error(t, err)
+		_, err = ToOData(Count("id", "count"))
+		require.Error(t, err)
+	})
+}
+
+func TestMarshalExpression(t *testing.T) {
+	q := url.Values{
+		defaultQueryNameWhere:  {"name eq 'John' and (age ge 18 or tags/any(t: t eq 'x')) and id in (1,2)"},
+		defaultQueryNameOrder:  {"name desc"},
+		defaultQueryNameSelect: {"id, user.name"},
+		defaultQueryNameLimit:  {"10"},
+		defaultQueryNameOffset: {"20"},
+	}
+	e, err := ODataExpression(&q)
+	require.NoError(t, err)
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		expected := &combiner{expressions: []Expression{e, GroupBy("name"), Relation("Author"), Where("id", NotEqual, int64(7)), JoinOn("users", "author", "id", "books/author_id", LeftJoin)}}
+
+		b, err := MarshalExpression(expected)
+		require.NoError(t, err)
+
+		actually, err := UnmarshalExpression(b)
+		require.NoError(t, err)
+		require.Equal(t, flatten([]Expression{expected}), actually.(*combiner).expressions)
+	})
+	t.Run("Schema", func(t *testing.T) {
+		b, err := MarshalExpression(&combiner{expressions: []Expression{Where("id", Equal, 1), Limit(5)}})
+		require.NoError(t, err)
+		require.JSONEq(t, `{"version":1,"expressions":[{"type":"where","column":"id","operator":"eq","value":{"type":"int","value":1}},{"type":"limit","number":5}]}`, string(b))
+
+		actually, err := UnmarshalExpression([]byte(`{"version":1,"comment":"unknown fields are ignored","expressions":[{"type":"limit","number":5,"extra":true}]}`))
+		require.NoError(t, err)
+		require.Equal(t, []Expression{Limit(5)}, actually.(*combiner).expressions)
+	})
+	t.Run("Version", func(t *testing.T) {
+		_, err := UnmarshalExpression([]byte(`{"version":2,"expressions":[]}`))
+		require.Error(t, err)
+		_, err = UnmarshalExpression([]byte(`{"expressions":[]}`))
+		require.Error(t, err)
+		_, err = UnmarshalExpression([]byte(`{"version":1,"expressions":[null]}`))
+		require.Error(t, err)
+	})
+	t.Run("Identifiers", func(t *testing.T) {
+		_, err := MarshalExpression(Join("users", "author", `"author"."id" = "books"."author_id"`, LeftJoin))
+		require.Error(t, err)
+		_, err = UnmarshalExpression([]byte(`{"version":1,"expressions":[{"type":"where","column":"id\" = 1 OR 1=1 --","operator":"isnull"}]}`))
+		require.Error(t, err)
+	})
+}
+
+func TestToSQL(t *testing.T) {
+	q := url.Values{
+		defaultQueryNameWhere:  {"name eq 'John' and (age ge 18 or year(created_at) eq 2024) and id in (1,2)"},
+		defaultQueryNameOrder:  {"name desc"},
+		defaultQueryNameSelect: {"id, name"},
+		defaultQueryNameLimit:  {"10"},
+		defaultQueryNameOffset: {"20"},
+	}
+	e, err := ODataExpression(&q)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		dialect  dialect
+		expected string
+	}{
+		{
+			name:     "PostgreSQL",
+			dialect:  DialectPostgreSQL,
+			expected: `SELECT "id", "name" FROM "users" WHERE "name" = $1 AND ("age" >= $2 OR EXTRACT(YEAR FROM "created_at") = $3) AND "id" IN ($4,$5) ORDER BY "name" DESC LIMIT 10 OFFSET 20;`,
+		},
+		{
+			name:     "MySQL",
+			dialect:  DialectMySQL,
+			expected: "SELECT `id`, `name` FROM `users` WHERE `name` = ? AND (`age` >= ? OR YEAR(`created_at`) = ?) AND `id` IN (?,?) ORDER BY `name` DESC LIMIT 10 OFFSET 20;",
+		},
+		{
+			name:     "SQLite",
+			dialect:  DialectSQLite3,
+			expected: `SELECT "id", "name" FROM "users" WHERE "name" = ? AND ("age" >= ? OR CAST(STRFTIME('%Y', "created_at") AS INTEGER) = ?) AND "id" IN (?,?) ORDER BY "name" DESC LIMIT 10 OFFSET 20;`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actually, args, err := ToSQL(tt.dialect, "users", e)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, actually)
+			require.Equal(t, []any{"John", 18, 2024, 1, 2}, args)
+		})
+	}
+
+	t.Run("Builders", func(t *testing.T) {
+		actually, args, err := ToSQL(DialectPostgreSQL, "books", Join("authors", "author", `"author"."id" = "books"."author_id"`, LeftJoin), Or(Where("title", Contains, "War"), Where("author/name", Equal, "Leo")), OrderBy("author/name", Ascending), Relation("Author"))
+		require.NoError(t, err)
+		require.Equal(t, `SELECT "books".* FROM "books" LEFT JOIN "authors" AS "author" ON "author"."id" = "books"."author_id" WHERE ("title" LIKE $1 ESCAPE '\' OR "author"."name" = $2) ORDER BY "author"."name" ASC;`, actually)
+		require.Equal(t, []any{"%War%", "Leo"}, args)
+	})
+	t.Run("JoinHeuristic", func(t *testing.T) {
+		SetJoinHeuristic(true)
+		defer SetJoinHeuristic(false)
+		actually, args, err := ToSQL(DialectPostgreSQL, "books", Or(Where("title", Contains, "War"), Where("author/name", Equal, "Leo")), Relation("Author"))
+		require.NoError(t, err)
+		require.Equal(t, `SELECT "books".* FROM "books" INNER JOIN "authors" ON "authors"."id" = "author_id" WHERE ("title" LIKE $1 ESCAPE '\' OR "authors"."name" = $2);`, actually)
+		require.Equal(t, []any{"%War%", "Leo"}, args)
+	})
+	t.Run("Unjoined", func(t *testing.T) {
+		_, _, err := ToSQL(DialectPostgreSQL, "books", Where("author/name", Equal, "Leo"))
+		require.EqualError(t, err, "column author/name refers to the table author, which is not joined (see Join and SetJoinHeuristic)")
+		_, _, err = ToSQL(DialectPostgreSQL, "books", OrderBy("author/name", Ascending))
+		require.Error(t, err)
+		_, _, err = ToSQL(DialectPostgreSQL, "books", Select("author.name"))
+		require.Error(t, err)
+		_, _, err = ToSQL(DialectPostgreSQL, "books", Where("book/title", Equal, "War and Peace"))
+		require.EqualError(t, err, "column book/title refers to the table book, which is not joined (see Join and SetJoinHeuristic)")
+
+		q := url.Values{defaultQueryNameWhere: {"items/any(i: i/qty gt 1)"}}
+		e, err := ODataExpressionWithOptions(&q, ODataOptions{Table: "orders"})
+		require.NoError(t, err)
+		_, _, err = ToSQL(DialectPostgreSQL, "orders", e)
+		require.EqualError(t, err, "lambda over the relation items guesses its table and foreign key, which requires the join heuristic (see SetJoinHeuristic)")
+
+		SetJoinHeuristic(true)
+		t.Cleanup(func() { SetJoinHeuristic(false) })
+		_, _, err = ToSQL(DialectPostgreSQL, "orders", e)
+		require.NoError(t, err)
+		SetJoinHeuristic(false)
+
+		_, _, err = ToSQL(DialectPostgreSQL, "books", Where("books/title", Equal, "War and Peace"), JoinOn("authors", "", "id", "books/author_id", LeftJoin), Where("authors/name", Equal, "Leo"))
+		require.NoError(t, err)
+	})
+	t.Run("UnsupportedDialect", func(t *testing.T) {
+		_, _, err := ToSQL("oracle", "users")
+		require.Error(t, err)
+	})
+}
+
+func TestCombinators(t *testing.T) {
+	q := url.Values{defaultQueryNameWhere: {"age ge 18 and age lt 65"}, defaultQueryNameOrder: {"name"}}
+	e, err := ODataExpression(&q)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		expression Expression
+		expected   string
+		args       []any
+	}{
+		{
+			name:       "Or of Where",
+			expression: Or(Where("a", Equal, 1), Where("b", Equal, 2)),
+			expected:   `WHERE ("a" = $1 OR "b" = $2)`,
+			args:       []any{1, 2},
+		},
+		{
+			name:       "Or of And and nested Or",
+			expression: Or(And(Where("a", Equal, 1), Where("b", Equal, 2)), Or(Where("c", Equal, 3), Where("d", Equal, 4))),
+			expected:   `WHERE (("a" = $1 AND "b" = $2) OR ("c" = $3 OR "d" = $4))`,
+			args:       []any{1, 2, 3, 4},
+		},
+		{
+			name:       "Or of OData expression",
+			expression: Or(e, Where("vip", Equal, true)),
+			expected:   `WHERE (("age" >= $1 AND "age" < $2) OR "vip" = $3) ORDER BY "name"`,
+			args:       []any{18, 65, true},
+		},
+		{
+			name:       "Not of Or",
+			expression: Not(Or(Where("a", Equal, 1), Where("b", IsNull))),
+			expected:   `WHERE ("a" <> $1 AND "b" IS NOT NULL)`,
+			args:       []any{1},
+		},
+		{
+			name:       "And of Not and Limit",
+			expression: And(Not(Where("a", Equal, 1)), Where("b", Equal, 2), Limit(5)),
+			expected:   `WHERE ("a" <> $1 AND "b" = $2) LIMIT 5`,
+			args:       []any{1, 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actually, args, err := ToSQL(DialectPostgreSQL, "users", tt.expression)
+			require.NoError(t, err)
+			require.Contains(t, actually, tt.expected)
+			require.Equal(t, tt.args, args)
+		})
+	}
+}
+
+func TestAggregates(t *testing.T) {
+	expressions := []Expression{Select("status"), Count("*", "count"), Avg("amount", "avg"), Min("amount", "low"), Max("amount", "high"), GroupBy("status"), Having(Sum("amount", "total"), GreaterThanOrEqual, 100)}
+	expected := map[dialect]string{
+		DialectPostgreSQL: `SELECT "status", COUNT(*) AS "count", AVG("amount") AS "avg", MIN("amount") AS "low", MAX("amount") AS "high" FROM "orders" GROUP BY status HAVING SUM("amount") >= $1;`,
+		DialectMySQL:      "SELECT `status`, COUNT(*) AS `count`, AVG(`amount`) AS `avg`, MIN(`amount`) AS `low`, MAX(`amount`) AS `high` FROM `orders` GROUP BY status HAVING SUM(`amount`) >= ?;",
+		DialectSQLite3:    `SELECT "status", COUNT(*) AS "count", AVG("amount") AS "avg", MIN("amount") AS "low", MAX("amount") AS "high" FROM "orders" GROUP BY status HAVING SUM("amount") >= ?;`,
+	}
+
+	for d, e := range expected {
+		t.Run(string(d), func(t *testing.T) {
+			actually, args, err := ToSQL(d, "orders", expressions...)
+			require.NoError(t, err)
+			require.Equal(t, e, actually)
+			require.Equal(t, []any{100}, args)
+		})
+	}
+
+	total := Sum("amount", "total")
+	t.Run("Not", func(t *testing.T) {
+		actually, args, err := ToSQL(DialectPostgreSQL, "orders", Select("status"), GroupBy("status"), Not(Having(total, GreaterThan, 100)))
+		require.NoError(t, err)
+		require.Equal(t, `SELECT "status" FROM "orders" GROUP BY status HAVING (NOT (SUM("amount") > $1));`, actually)
+		require.Equal(t, []any{100}, args)
+	})
+	t.Run("Or", func(t *testing.T) {
+		actually, args, err := ToSQL(DialectPostgreSQL, "orders", Select("status"), GroupBy("status"), Where("amount", GreaterThan, 0), Or(Where("status", Equal, "paid"), Having(total, GreaterThan, 100)))
+		require.NoError(t, err)
+		require.Equal(t, `SELECT "status" FROM "orders" WHERE ("amount" > $1) GROUP BY status HAVING ("status" = $2 OR SUM("amount") > $3);`, actually)
+		require.Equal(t, []any{0, "paid", 100}, args)
+	})
+	t.Run("And", func(t *testing.T) {
+		actually, args, err := ToSQL(DialectPostgreSQL, "orders", Select("status"), GroupBy("status"), And(Where("amount", GreaterThan, 0), Having(total, GreaterThan, 100)))
+		require.NoError(t, err)
+		require.Equal(t, `SELECT "status" FROM "orders" WHERE ("amount" > $1) GROUP BY status HAVING SUM("amount") > $2;`, actually)
+		require.Equal(t, []any{0, 100}, args)
+	})
+}
+
+func TestOperators(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression Expression
+		expected   map[dialect]string
+		args       []any
+	}{
+		{
+			name:       "Between",
+			expression: Where("age", Between, 18, 65),
+			expected: map[dialect]string{
+				DialectPostgreSQL: `WHERE ("age" BETWEEN $1 AND $2);`,
+				DialectMySQL:      "WHERE (`age` BETWEEN ? AND ?);",
+				DialectSQLite3:    `WHERE ("age" BETWEEN ? AND ?);`,
+			},
+			args: []any{18, 65},
+		},
+		{
+			name:       "Between without pair of values",
+			expression: Where("age", Between, 18),
+			expected: map[dialect]string{
+				DialectPostgreSQL: `WHERE (1 = 0);`,
+				DialectMySQL:      "WHERE (1 = 0);",
+				DialectSQLite3:    `WHERE (1 = 0);`,
+			},
+		},
+		{
+			name:       "Not of unknown operator",
+			expression: Not(Where("age", operator("approximately"), 18)),
+			expected: map[dialect]string{
+				DialectPostgreSQL: `WHERE (1 = 0);`,
+			},
+		},
+		{
+			name:       "Not Between",
+			expression: Not(Where("age", Between, 18, 65)),
+			expected: map[dialect]string{
+				DialectPostgreSQL: `WHERE ("age" NOT BETWEEN $1 AND $2);`,
+				DialectMySQL:      "WHERE (`age` NOT BETWEEN ? AND ?);",
+				DialectSQLite3:    `WHERE ("age" NOT BETWEEN ? AND ?);`,
+			},
+			args: []any{18, 65},
+		},
+		{
+			name:       "IContains",
+			expression: Where("name", IContains, "jo"),
+			expected: map[dialect]string{
+				DialectPostgreSQL: `WHERE ("name" ILIKE $1 ESCAPE '\');`,
+				DialectMySQL:      "WHERE (LOWER(`name`) LIKE LOWER(?) ESCAPE '\\\\');",
+				DialectSQLite3:    `WHERE (LOWER("name") LIKE LOWER(?) ESCAPE '\');`,
+			},
+			args: []any{"%jo%"},
+		},
+		{
+			name:       "Not IContains",
+			expression: Not(Where("name", IContains, "jo")),
+			expected: map[dialect]string{
+				DialectPostgreSQL: `WHERE ("name" NOT ILIKE $1 ESCAPE '\');`,
+				DialectMySQL:      "WHERE (LOWER(`name`) NOT LIKE LOWER(?) ESCAPE '\\\\');",
+				DialectSQLite3:    `WHERE (LOWER("name") NOT LIKE LOWER(?) ESCAPE '\');`,
+			},
+			args: []any{"%jo%"},
+		},
+		{
+			name:       "NotContains",
+			expression: Where("name", NotContains, "jo"),
+			expected: map[dialect]string{
+				DialectPostgreSQL: `WHERE ("name" NOT LIKE $1 ESCAPE '\');`,
+				DialectMySQL:      "WHERE (`name` NOT LIKE ? ESCAPE '\\\\');",
+				DialectSQLite3:    `WHERE ("name" NOT LIKE ? ESCAPE '\');`,
+			},
+			args: []any{"%jo%"},
+		},
+		{
+			name:       "StartsWith of wildcards",
+			expression: Where("code", StartsWith, `50%_off\`),
+			expected: map[dialect]string{
+				DialectPostgreSQL: `WHERE ("code" LIKE $1 ESCAPE '\');`,
+				DialectMySQL:      "WHERE (`code` LIKE ? ESCAPE '\\\\');",
+				DialectSQLite3:    `WHERE ("code" LIKE ? ESCAPE '\');`,
+			},
+			args: []any{`50\%\_off\\%`},
+		},
+		{
+			name:       "Matches",
+			expression: Where("name", Matches, "^J.*n$"),
+			expected: map[dialect]string{
+				DialectPostgreSQL: `WHERE ("name" ~ $1);`,
+				DialectMySQL:      "WHERE (`name` REGEXP ?);",
+				DialectSQLite3:    `WHERE ("name" REGEXP ?);`,
+			},
+			args: []any{"^J.*n$"},
+		},
+		{
+			name:       "Not Matches",
+			expression: Not(Where("name", Matches, "^J.*n$")),
+			expected: map[dialect]string{
+				DialectPostgreSQL: `WHERE ("name" !~ $1);`,
+				DialectMySQL:      "WHERE (`name` NOT REGEXP ?);",
+				DialectSQLite3:    `WHERE ("name" NOT REGEXP ?);`,
+			},
+			args: []any{"^J.*n$"},
+		},
+		{
+			name:       "JSON member",
+			expression: WhereJSON("metadata", []string{"color"}, Equal, "red"),
+			expected: map[dialect]string{
+				DialectPostgreSQL: `WHERE ("metadata"->>'color' = $1);`,
+				DialectMySQL:      "WHERE (JSON_UNQUOTE(JSON_EXTRACT(`metadata`, '$.\"color\"')) = ?);",
+				DialectSQLite3:    `WHERE (json_extract("metadata", '$."color"') = ?);`,
+			},
+			args: []any{"red"},
+		},
+		{
+			name:       "JSON nested number",
+			expression: WhereJSON("metadata", []string{"sizes", "0", "width"}, GreaterThan, 10),
+			expected: map[dialect]string{
+				DialectPostgreSQL: `WHERE (("metadata"#>>'{"sizes","0","width"}')::numeric > $1);`,
+				DialectMySQL:      "WHERE (JSON_EXTRACT(`metadata`, '$.\"sizes\"[0].\"width\"') > ?);",
+				DialectSQLite3:    `WHERE (json_extract("metadata", '$."sizes"[0]."width"') > ?);`,
+			},
+			args: []any{10},
+		},
+		{
+			name:       "JSON member of quote",
+			expression: WhereJSON("metadata", []string{"it's"}, IContains, "x"),
+			expected: map[dialect]string{
+				DialectPostgreSQL: `WHERE ("metadata"->>'it''s' ILIKE $1 ESCAPE '\');`,
+				DialectMySQL:      "WHERE (LOWER(JSON_UNQUOTE(JSON_EXTRACT(`metadata`, '$.\"it''s\"'))) LIKE LOWER(?) ESCAPE '\\\\');",
+				DialectSQLite3:    `WHERE (LOWER(json_extract("metadata", '$."it''s"')) LIKE LOWER(?) ESCAPE '\');`,
+			},
+			args: []any{"%x%"},
+		},
+	}
+
+	for _, tt := range tests {
+		for d, expected := range tt.expected {
+			t.Run(tt.name+" "+string(d), func(t *testing.T) {
+				actually, args, err := ToSQL(d, "users", tt.expression)
+				require.NoError(t, err)
+				require.Contains(t, actually, expected)
+				require.Equal(t, tt.args, args)
+			})
+		}
+	}
+}
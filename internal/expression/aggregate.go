@@ -0,0 +1,301 @@
+package expression
+
+import (
+	"fmt"
+	"github.com/prorochestvo/sqlinjector/internal"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// AggregateMethod is the aggregation of rows of group, the names follow the methods of OData $apply, e.g. amount with sum as total
+type AggregateMethod string
+
+const (
+	AggregateCount         AggregateMethod = "count"
+	AggregateCountDistinct AggregateMethod = "countdistinct"
+	AggregateSum           AggregateMethod = "sum"
+	AggregateAverage       AggregateMethod = "average"
+	AggregateMin           AggregateMethod = "min"
+	AggregateMax           AggregateMethod = "max"
+)
+
+// NewAggregate makes the aggregate of column selected by the alias, e.g. NewAggregate(AggregateSum, "amount", "total").
+// The rows of group are counted by AggregateCount without column (or with "*").
+func NewAggregate(method AggregateMethod, column, alias string) *Aggregate {
+	t, c := "", ""
+	if column != "" && column != "*" {
+		t, c = extractTableNameAndColumn(column)
+	}
+	return &Aggregate{Method: method, Table: t, Column: c, Alias: alias}
+}
+
+// Aggregate selects the aggregate of column over the rows of group (or of all rows without GroupBy) as the alias.
+// The column of related table is qualified by its table, which is not joined by the aggregate.
+type Aggregate struct {
+	Method AggregateMethod
+	Table  string
+	Column string
+	Alias  string
+}
+
+func (a *Aggregate) QueryMod() []qm.QueryMod {
+	return a.QueryModOf(CurrentDialect())
+}
+
+func (a *Aggregate) QueryModOf(d internal.Dialect) []qm.QueryMod {
+	c, _ := a.function().sql(d)
+	return []qm.QueryMod{qm.Select(c + " AS \"" + a.Alias + "\"")}
+}
+
+// ToString returns the aggregate in the form of OData $apply, e.g. amount with sum as total or $count as count
+func (a *Aggregate) ToString() string {
+	if a.Column == "" {
+		return "$count as " + a.Alias
+	}
+	return (&Field{Table: a.Table, Column: a.Column}).ToString() + " with " + string(a.Method) + " as " + a.Alias
+}
+
+// function returns the aggregate function of SQL over the column
+func (a *Aggregate) function() *Function {
+	if a.Column == "" {
+		return &Function{Name: string(a.Method), Args: []interface{}{}}
+	}
+	return &Function{Name: string(a.Method), Args: []interface{}{&Field{Table: a.Table, Column: a.Column}}}
+}
+
+// NewHaving makes the condition over the aggregate of group, e.g. NewHaving(NewAggregate(AggregateSum, "amount", "total"), GreaterThan, 100).
+// The operators are the same as ones of Where, the conditions of several Having are joined by the and operator.
+func NewHaving(aggregate *Aggregate, operator Operator, values ...interface{}) *Having {
+	w := NewWhereWithTable(aggregate.Table, aggregate.Column, operator, values...)
+	return &Having{Aggregate: aggregate, Operator: operator, Value: w.Value}
+}
+
+// Having filters the groups by the aggregate, the aggregate is repeated instead of its alias, since PostgreSQL does not refer to aliases in HAVING.
+// It is the condition combined by And, Or and Not with the conditions over the grouped columns.
+type Having struct {
+	Aggregate *Aggregate
+	Operator  Operator
+	Value     interface{}
+}
+
+func (h *Having) QueryMod() []qm.QueryMod {
+	return h.QueryModOf(CurrentDialect())
+}
+
+func (h *Having) QueryModOf(d internal.Dialect) []qm.QueryMod {
+	c, args := h.predicate(d)
+	if c == "" {
+		return nil
+	}
+	return []qm.QueryMod{qm.Having(c, args...)}
+}
+
+// clause returns the condition over the aggregate as the where mod to be combined by And, Or and Not,
+// the combination of conditions with Having is rendered into HAVING (see Grouped)
+func (h *Having) clause(d internal.Dialect) ([]qm.QueryMod, qm.QueryMod) {
+	c, args := h.predicate(d)
+	if c == "" {
+		return nil, nil
+	}
+	return nil, qm.Where(c, args...)
+}
+
+// negate returns the clause of the opposite condition
+func (h *Having) negate(d internal.Dialect) ([]qm.QueryMod, qm.QueryMod) {
+	c, args := h.predicate(d)
+	if c == "" {
+		return nil, nil
+	}
+	return nil, qm.Where("NOT ("+c+")", args...)
+}
+
+// predicate returns SQL of the condition over the aggregate, the related tables are not joined, the same as by the aggregate
+func (h *Having) predicate(d internal.Dialect) (string, []interface{}) {
+	a := h.Aggregate
+	w := &Where{Table: a.Table, Column: a.Column, Operator: h.Operator, Value: h.Value, Function: a.function()}
+	var c string
+	var args []interface{}
+	w.queryModOf(d, func(clause string, values ...interface{}) qm.QueryMod {
+		c, args = clause, values
+		return nil
+	})
+	return c, args
+}
+
+// ToString returns the condition over the alias of aggregate, e.g. having total > 100
+func (h *Having) ToString() string {
+	return "having " + (&Where{Column: h.Aggregate.Alias, Operator: h.Operator, Value: h.Value}).ToString()
+}
+
+// NewApplyFrom parses the OData $apply option: the transformations groupby, aggregate and filter separated by "/",
+// e.g. filter(year gt 2020)/groupby((status),aggregate(amount with sum as total,$count as count))/filter(total gt 100).
+// The grouped columns are selected along with the aggregates, the filter before the aggregation restricts the rows by Where conditions,
+// the filter after the aggregation restricts the groups by Having over the aliases of aggregates joined by the and operator
+// or by Where over the grouped columns.
+func NewApplyFrom(expr string, options FilterOptions) ([]Modifier, error) {
+	transformations, err := splitApply(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	mods := make([]Modifier, 0)
+	aggregated := false
+	aggregates := make(map[string]*Aggregate)
+	grouped := make([]*Field, 0)
+	for _, t := range transformations {
+		name, args, ok := strings.Cut(t, "(")
+		if !ok || !strings.HasSuffix(args, ")") {
+			return nil, fmt.Errorf("invalid apply format: %q", t)
+		}
+		name, args = strings.TrimSpace(name), strings.TrimSpace(args[:len(args)-1])
+		if name != "filter" && aggregated {
+			return nil, fmt.Errorf("invalid apply format: %s after aggregation is not supported", name)
+		}
+
+		switch name {
+		case "filter":
+			conditions, err := NewWhereFromWithOptions(args, options)
+			if err != nil {
+				return nil, err
+			}
+			for _, c := range conditions {
+				if !aggregated {
+					mods = append(mods, c)
+					continue
+				}
+				m, err := groupCondition(c, aggregates, grouped)
+				if err != nil {
+					return nil, err
+				}
+				mods = append(mods, m)
+			}
+		case "groupby":
+			columns, rest, ok := strings.Cut(strings.TrimPrefix(args, "("), ")")
+			if !ok || !strings.HasPrefix(args, "(") {
+				return nil, fmt.Errorf("invalid apply format: groupby expects the columns in parentheses: %q", t)
+			}
+			s, err := NewSelectFrom(columns)
+			if err != nil {
+				return nil, err
+			}
+			mods = append(mods, s)
+			for _, c := range strings.Split(columns, ",") {
+				c = strings.ReplaceAll(strings.TrimSpace(c), ".", "/")
+				grouped = append(grouped, NewField(c))
+				mods = append(mods, NewGroupBy(c))
+			}
+			aggregated = true
+			if rest = strings.TrimSpace(rest); rest == "" {
+				continue
+			}
+			items, ok := strings.CutPrefix(strings.TrimSpace(strings.TrimPrefix(rest, ",")), "aggregate(")
+			if !ok || !strings.HasPrefix(rest, ",") || !strings.HasSuffix(items, ")") {
+				return nil, fmt.Errorf("invalid apply format: aggregate is expected in groupby: %q", t)
+			}
+			if mods, err = aggregatesFrom(items[:len(items)-1], aggregates, mods); err != nil {
+				return nil, err
+			}
+		case "aggregate":
+			aggregated = true
+			if mods, err = aggregatesFrom(args, aggregates, mods); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("unsupported transformation of apply: %s", name)
+		}
+	}
+
+	return mods, nil
+}
+
+// splitApply splits $apply into the transformations by "/" outside of parentheses and string literals
+func splitApply(expr string) ([]string, error) {
+	items := make([]string, 0)
+	depth, literal, start := 0, false, 0
+	for i, r := range expr {
+		switch {
+		case r == '\'':
+			literal = !literal
+		case literal:
+		case r == '(':
+			depth++
+		case r == ')':
+			if depth--; depth < 0 {
+				return nil, fmt.Errorf("invalid apply format: unexpected ) at position %d", i)
+			}
+		case r == '/' && depth == 0:
+			items = append(items, strings.TrimSpace(expr[start:i]))
+			start = i + 1
+		}
+	}
+	if depth != 0 || literal {
+		return nil, fmt.Errorf("invalid apply format: %q", expr)
+	}
+	items = append(items, strings.TrimSpace(expr[start:]))
+	for _, item := range items {
+		if item == "" {
+			return nil, fmt.Errorf("invalid apply format: empty transformation")
+		}
+	}
+	return items, nil
+}
+
+// aggregatesFrom parses the comma-separated aggregates, e.g. amount with sum as total, $count as count
+func aggregatesFrom(expr string, aggregates map[string]*Aggregate, mods []Modifier) ([]Modifier, error) {
+	for _, item := range strings.Split(expr, ",") {
+		m := aggregatePattern.FindStringSubmatch(strings.TrimSpace(item))
+		if m == nil {
+			return nil, fmt.Errorf("invalid aggregate format: %q", strings.TrimSpace(item))
+		}
+		var a *Aggregate
+		if m[1] == "$count" && m[2] != "" {
+			return nil, fmt.Errorf("invalid aggregate format: $count has no method: %q", strings.TrimSpace(item))
+		} else if m[1] == "$count" {
+			a = NewAggregate(AggregateCount, "", m[3])
+		} else {
+			method := AggregateMethod(m[2])
+			switch method {
+			case AggregateSum, AggregateAverage, AggregateMin, AggregateMax, AggregateCountDistinct:
+			default:
+				return nil, fmt.Errorf("unsupported aggregate method: %s", m[2])
+			}
+			a = NewAggregate(method, strings.ReplaceAll(m[1], ".", "/"), m[3])
+		}
+		if _, exists := aggregates[a.Alias]; exists {
+			return nil, fmt.Errorf("duplicate alias of aggregate: %s", a.Alias)
+		}
+		aggregates[a.Alias] = a
+		mods = append(mods, a)
+	}
+	return mods, nil
+}
+
+// groupCondition turns the condition of filter after the aggregation into Having, when it compares the alias of aggregate,
+// the condition over the grouped columns is kept as is, since it is the same for all rows of group
+func groupCondition(c Condition, aggregates map[string]*Aggregate, grouped []*Field) (Modifier, error) {
+	if w, ok := c.(*Where); ok && w.Function == nil && w.Table == "" && len(w.Path) == 0 {
+		if a, exists := aggregates[w.Column]; exists {
+			return &Having{Aggregate: a, Operator: w.Operator, Value: w.Value}, nil
+		}
+	}
+	leaves := Leaves(c)
+	if len(leaves) == 0 {
+		return nil, fmt.Errorf("invalid apply format: filter of groups supports the comparisons of aliases and grouped columns: %s", c.ToString())
+	}
+	for _, w := range leaves {
+		fields := []*Field{{Table: w.Table, Column: w.Column}}
+		if w.Function != nil {
+			fields = w.Function.Fields()
+		}
+		for _, f := range fields {
+			if !slices.ContainsFunc(grouped, func(g *Field) bool { return g.Table == f.Table && g.Column == f.Column }) {
+				return nil, fmt.Errorf("invalid apply format: %s is neither the alias of aggregate nor the grouped column", (&Field{Table: f.Table, Column: f.Column}).ToString())
+			}
+		}
+	}
+	return c, nil
+}
+
+var aggregatePattern = regexp.MustCompile(`^(\$count|[A-Za-z_][A-Za-z0-9_]*(?:[./][A-Za-z_][A-Za-z0-9_]*)?)(?:\s+with\s+([a-z]+))?\s+as\s+([A-Za-z_][A-Za-z0-9_]*)$`)
@@ -0,0 +1,182 @@
+package expression
+
+import (
+	"github.com/prorochestvo/sqlinjector/internal"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/sqlboiler/v4/drivers"
+	"github.com/volatiletech/sqlboiler/v4/queries"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+	"testing"
+)
+
+var _ expression = &Aggregate{}
+var _ expression = &Having{}
+var _ Condition = &Having{}
+
+func TestAggregate_QueryModOf(t *testing.T) {
+	total := NewAggregate(AggregateSum, "amount", "total")
+
+	tests := []struct {
+		name     string
+		mods     []DialectExpression
+		dialect  internal.Dialect
+		expected string
+		args     []interface{}
+	}{
+		{
+			name:     "sum",
+			mods:     []DialectExpression{total},
+			dialect:  internal.DialectPostgreSQL,
+			expected: `SELECT SUM("amount") AS "total" FROM "orders";`,
+		},
+		{
+			name:     "count of rows and column",
+			mods:     []DialectExpression{NewAggregate(AggregateCount, "*", "count"), NewAggregate(AggregateCount, "customer/email", "emails")},
+			dialect:  internal.DialectPostgreSQL,
+			expected: `SELECT COUNT(*) AS "count", COUNT("customer"."email") AS "emails" FROM "orders";`,
+		},
+		{
+			name:     "count distinct, average, min and max",
+			mods:     []DialectExpression{NewAggregate(AggregateCountDistinct, "status", "statuses"), NewAggregate(AggregateAverage, "amount", "avg"), NewAggregate(AggregateMin, "amount", "low"), NewAggregate(AggregateMax, "amount", "high")},
+			dialect:  internal.DialectSQLite3,
+			expected: `SELECT COUNT(DISTINCT "status") AS "statuses", AVG("amount") AS "avg", MIN("amount") AS "low", MAX("amount") AS "high" FROM "orders";`,
+		},
+		{
+			name:     "having",
+			mods:     []DialectExpression{total, NewHaving(total, GreaterThan, 100), NewHaving(NewAggregate(AggregateCount, "", "count"), Between, 2, 5)},
+			dialect:  internal.DialectPostgreSQL,
+			expected: `SELECT SUM("amount") AS "total" FROM "orders" HAVING SUM("amount") > $1 AND COUNT(*) BETWEEN $2 AND $3;`,
+			args:     []interface{}{100, 2, 5},
+		},
+		{
+			name:     "having of in",
+			mods:     []DialectExpression{NewHaving(NewAggregate(AggregateCount, "", "count"), In, 1, 2)},
+			dialect:  internal.DialectMySQL,
+			expected: `SELECT * FROM "orders" HAVING COUNT(*) IN ($1, $2);`,
+			args:     []interface{}{1, 2},
+		},
+		{
+			name:     "having of like",
+			mods:     []DialectExpression{NewHaving(NewAggregate(AggregateMax, "status", "last"), IStartsWith, "pa")},
+			dialect:  internal.DialectSQLite3,
+			expected: `SELECT * FROM "orders" HAVING LOWER(MAX("status")) LIKE LOWER($1) ESCAPE '\';`,
+			args:     []interface{}{"pa%"},
+		},
+		{
+			name:     "negated having",
+			mods:     []DialectExpression{NewCombinerNOT(NewHaving(total, GreaterThan, 100))},
+			dialect:  internal.DialectPostgreSQL,
+			expected: `SELECT * FROM "orders" HAVING (NOT (SUM("amount") > $1));`,
+			args:     []interface{}{100},
+		},
+		{
+			name:     "having of or",
+			mods:     []DialectExpression{NewWhere("id", GreaterThan, 1), NewCombinerOR(NewWhere("status", In, "paid", "sent"), NewHaving(total, GreaterThan, 100))},
+			dialect:  internal.DialectPostgreSQL,
+			expected: `SELECT * FROM "orders" WHERE ("id" > $1) HAVING ("status" IN ($2,$3) OR SUM("amount") > $4);`,
+			args:     []interface{}{1, "paid", "sent", 100},
+		},
+		{
+			name:     "negated or of having",
+			mods:     []DialectExpression{NewCombinerNOT(NewCombinerOR(NewHaving(total, LessThan, 10), NewHaving(NewAggregate(AggregateCount, "", "count"), Between, 2, 5)))},
+			dialect:  internal.DialectSQLite3,
+			expected: `SELECT * FROM "orders" HAVING (NOT (SUM("amount") < $1) AND NOT (COUNT(*) BETWEEN $2 AND $3));`,
+			args:     []interface{}{10, 2, 5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := &queries.Query{}
+			queries.SetDialect(q, &drivers.Dialect{LQ: '"', RQ: '"', UseIndexPlaceholders: true})
+			queries.SetFrom(q, `"orders"`)
+			for _, m := range tt.mods {
+				qm.Apply(q, m.QueryModOf(tt.dialect)...)
+			}
+			actually, args := queries.BuildQuery(q)
+			require.Equal(t, tt.expected, actually)
+			require.Equal(t, tt.args, args)
+		})
+	}
+}
+
+func TestAggregate_ToString(t *testing.T) {
+	total := NewAggregate(AggregateSum, "amount", "total")
+	require.Equal(t, "amount with sum as total", total.ToString())
+	require.Equal(t, "$count as count", NewAggregate(AggregateCount, "", "count").ToString())
+	require.Equal(t, "having "+NewWhere("total", GreaterThan, 100).ToString(), NewHaving(total, GreaterThan, 100).ToString())
+}
+
+func TestAggregate_NewApplyFrom(t *testing.T) {
+	options := FilterOptions{}
+	total := NewAggregate(AggregateSum, "amount", "total")
+	count := NewAggregate(AggregateCount, "", "count")
+
+	tests := []struct {
+		name     string
+		apply    string
+		expected []Modifier
+	}{
+		{
+			name:     "groupby",
+			apply:    "groupby((status, customer/country))",
+			expected: []Modifier{&Select{columns: []string{"status", "customer.country"}}, NewGroupBy("status"), NewGroupBy("customer/country")},
+		},
+		{
+			name:     "groupby with aggregate",
+			apply:    "groupby((status),aggregate(amount with sum as total, $count as count))",
+			expected: []Modifier{&Select{columns: []string{"status"}}, NewGroupBy("status"), total, count},
+		},
+		{
+			name:  "filter, groupby and having",
+			apply: "filter(year(created_at) eq 2024)/groupby((status),aggregate(amount with sum as total,$count as count))/filter(total gt 100 and count ge 2 and status ne 'new')",
+			expected: []Modifier{
+				&Where{Table: "", Column: "created_at", Operator: Equal, Value: 2024, Function: &Function{Name: "year", Args: []interface{}{&Field{Column: "created_at"}}}},
+				&Select{columns: []string{"status"}}, NewGroupBy("status"), total, count,
+				&Having{Aggregate: total, Operator: GreaterThan, Value: 100},
+				&Having{Aggregate: count, Operator: GreaterThanOrEqual, Value: 2},
+				&Where{Column: "status", Operator: NotEqual, Value: "new"},
+			},
+		},
+		{
+			name:     "aggregate of all rows",
+			apply:    "aggregate(amount with average as avg, amount with max as high, customer_id with countdistinct as customers)",
+			expected: []Modifier{NewAggregate(AggregateAverage, "amount", "avg"), NewAggregate(AggregateMax, "amount", "high"), NewAggregate(AggregateCountDistinct, "customer_id", "customers")},
+		},
+		{
+			name:     "slash inside of literal",
+			apply:    "filter(name eq 'a/b')/aggregate($count as count)",
+			expected: []Modifier{&Where{Column: "name", Operator: Equal, Value: "a/b"}, count},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actually, err := NewApplyFrom(tt.apply, options)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, actually)
+		})
+	}
+
+	for _, apply := range []string{
+		"",
+		"groupby(status)",
+		"groupby((status),sum(amount))",
+		"aggregate(amount with median as m)",
+		"aggregate(amount as total)",
+		"aggregate($count with sum as c)",
+		"aggregate(amount with sum as total, amount with max as total)",
+		"aggregate($count as count)/groupby((status))",
+		"groupby((status),aggregate($count as count))/filter(total gt 1)",
+		"groupby((status),aggregate($count as count))/filter(count gt 1 or count lt 0)",
+		"groupby((status),aggregate($count as count))/filter(amount gt 1)",
+		"compute(amount mul 2 as double)",
+		"filter(a eq 1)//aggregate($count as count)",
+		"filter((a eq 1)",
+	} {
+		t.Run(apply, func(t *testing.T) {
+			_, err := NewApplyFrom(apply, options)
+			require.Error(t, err)
+		})
+	}
+}
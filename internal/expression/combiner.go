@@ -1,54 +1,248 @@
 package expression
 
 import (
+	"github.com/prorochestvo/sqlinjector/internal"
+	"github.com/volatiletech/sqlboiler/v4/drivers"
+	"github.com/volatiletech/sqlboiler/v4/queries"
 	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+	"slices"
 	"strings"
 )
 
-func NewCombinerOR(l *Where, r *Where, e ...*Where) *Or {
-	items := make([]*Where, 0, len(e)+2)
+// Condition is a node of the boolean expression tree, it is one of Where, Lambda, Search, Having, And, Or and Not.
+type Condition interface {
+	QueryMod() []qm.QueryMod
+	QueryModOf(d internal.Dialect) []qm.QueryMod
+	ToString() string
+	// clause returns the join mods and the single where mod of the condition
+	clause(d internal.Dialect) ([]qm.QueryMod, qm.QueryMod)
+}
+
+func NewCombinerOR(l Condition, r Condition, e ...Condition) *Or {
+	items := make([]Condition, 0, len(e)+2)
 	items = append(items, l, r)
 	items = append(items, e...)
 	return &Or{items: items}
 }
 
+func NewCombinerAND(l Condition, r Condition, e ...Condition) *And {
+	items := make([]Condition, 0, len(e)+2)
+	items = append(items, l, r)
+	items = append(items, e...)
+	return &And{items: items}
+}
+
+func NewCombinerNOT(c Condition) *Not {
+	return &Not{item: c}
+}
+
 type Or struct {
-	items []*Where
+	items []Condition
 }
 
-func (o *Or) Or() []*Where {
+func (o *Or) Or() []Condition {
 	return o.items
 }
 
 func (o *Or) QueryMod() []qm.QueryMod {
-	mods := make([]qm.QueryMod, 0, len(o.items))
+	return o.QueryModOf(CurrentDialect())
+}
+
+func (o *Or) QueryModOf(d internal.Dialect) []qm.QueryMod {
+	joins, where := o.clause(d)
+	if Grouped(o) {
+		return having(joins, where)
+	}
+	if where == nil {
+		return joins
+	}
+	return append(joins, where)
+}
+
+func (o *Or) ToString() string {
+	return joinToString(o.items, "Or")
+}
+
+func (o *Or) clause(d internal.Dialect) ([]qm.QueryMod, qm.QueryMod) {
+	joins := make([]qm.QueryMod, 0, len(o.items))
 	orMods := make([]qm.QueryMod, 0, len(o.items))
-	for i, e := range o.items {
-		mod := e.QueryMod()
-		l := len(mod)
-		if l == 0 {
+	for _, e := range o.items {
+		j, mod := e.clause(d)
+		joins = append(joins, j...)
+		if mod == nil {
 			continue
 		}
-		l--
-		for m := 0; m < l; m++ {
-			mods = append(mods, mod[m])
-		}
-		if i == 0 {
-			orMods = append(orMods, mod[l])
+		if len(orMods) == 0 {
+			orMods = append(orMods, mod)
 		} else {
-			orMods = append(orMods, qm.Or2(mod[l]))
+			orMods = append(orMods, qm.Or2(mod))
 		}
 	}
-	mods = append(mods, qm.Expr(orMods...))
-	return mods
+	if len(orMods) == 0 {
+		return joins, nil
+	}
+	return joins, qm.Expr(orMods...)
 }
 
-func (o *Or) ToString() string {
-	w := make([]string, len(o.items))
-	for i, item := range o.items {
+type And struct {
+	items []Condition
+}
+
+func (a *And) And() []Condition {
+	return a.items
+}
+
+func (a *And) QueryMod() []qm.QueryMod {
+	return a.QueryModOf(CurrentDialect())
+}
+
+func (a *And) QueryModOf(d internal.Dialect) []qm.QueryMod {
+	joins, where := a.clause(d)
+	if Grouped(a) {
+		return having(joins, where)
+	}
+	if where == nil {
+		return joins
+	}
+	return append(joins, where)
+}
+
+func (a *And) ToString() string {
+	return joinToString(a.items, "And")
+}
+
+func (a *And) clause(d internal.Dialect) ([]qm.QueryMod, qm.QueryMod) {
+	joins := make([]qm.QueryMod, 0, len(a.items))
+	andMods := make([]qm.QueryMod, 0, len(a.items))
+	for _, e := range a.items {
+		j, mod := e.clause(d)
+		joins = append(joins, j...)
+		if mod != nil {
+			andMods = append(andMods, mod)
+		}
+	}
+	if len(andMods) == 0 {
+		return joins, nil
+	}
+	return joins, qm.Expr(andMods...)
+}
+
+type Not struct {
+	item Condition
+}
+
+func (n *Not) Not() Condition {
+	return n.item
+}
+
+func (n *Not) QueryMod() []qm.QueryMod {
+	return n.QueryModOf(CurrentDialect())
+}
+
+func (n *Not) QueryModOf(d internal.Dialect) []qm.QueryMod {
+	joins, where := n.clause(d)
+	if Grouped(n) {
+		return having(joins, where)
+	}
+	if where == nil {
+		return joins
+	}
+	return append(joins, where)
+}
+
+func (n *Not) ToString() string {
+	return "Not (" + n.item.ToString() + ")"
+}
+
+// clause pushes the negation down to the leaves by De Morgan's laws, since sqlboiler has no NOT query mod
+func (n *Not) clause(d internal.Dialect) ([]qm.QueryMod, qm.QueryMod) {
+	switch c := n.item.(type) {
+	case *Where:
+		return c.negate(d)
+	case *Lambda:
+		return c.negate(d)
+	case *Search:
+		return c.negate(d)
+	case *Having:
+		return c.negate(d)
+	case *Not:
+		return c.item.clause(d)
+	case *And:
+		items := make([]Condition, len(c.items))
+		for i, item := range c.items {
+			items[i] = NewCombinerNOT(item)
+		}
+		return (&Or{items: items}).clause(d)
+	case *Or:
+		items := make([]Condition, len(c.items))
+		for i, item := range c.items {
+			items[i] = NewCombinerNOT(item)
+		}
+		return (&And{items: items}).clause(d)
+	}
+	return nil, nil
+}
+
+// Grouped reports whether the condition filters the groups, i.e. it has Having, such condition is rendered into HAVING as a whole
+func Grouped(c Condition) bool {
+	switch v := c.(type) {
+	case *Having:
+		return true
+	case *Not:
+		return Grouped(v.item)
+	case *And:
+		return slices.ContainsFunc(v.items, Grouped)
+	case *Or:
+		return slices.ContainsFunc(v.items, Grouped)
+	}
+	return false
+}
+
+// having moves the where mod into HAVING, since sqlboiler combines the where mods only (qm.Expr and qm.Or2):
+// the where mod is rendered with the placeholders "?" and its SQL is placed into HAVING.
+func having(joins []qm.QueryMod, where qm.QueryMod) []qm.QueryMod {
+	if where == nil {
+		return joins
+	}
+	q := &queries.Query{}
+	queries.SetDialect(q, &drivers.Dialect{LQ: '"', RQ: '"'})
+	queries.SetFrom(q, "having")
+	qm.Apply(q, where)
+	c, args := queries.BuildQuery(q)
+	_, c, _ = strings.Cut(strings.TrimSuffix(c, ";"), " WHERE ")
+	return append(joins, qm.Having(c, args...))
+}
+
+// Leaves returns all Where conditions of the tree, the conditions of Lambda are not included
+func Leaves(c Condition) []*Where {
+	switch v := c.(type) {
+	case *Where:
+		return []*Where{v}
+	case *Not:
+		return Leaves(v.item)
+	case *And:
+		return leavesOf(v.items)
+	case *Or:
+		return leavesOf(v.items)
+	}
+	return nil
+}
+
+func leavesOf(items []Condition) []*Where {
+	res := make([]*Where, 0, len(items))
+	for _, item := range items {
+		res = append(res, Leaves(item)...)
+	}
+	return res
+}
+
+// joinToString joins string representation of the conditions by the logical operator
+func joinToString(items []Condition, operator string) string {
+	w := make([]string, len(items))
+	for i, item := range items {
 		w[i] = item.ToString()
 	}
-	s := strings.Join(w, ") Or (")
+	s := strings.Join(w, ") "+operator+" (")
 	if len(w) > 1 {
 		s = "(" + s + ")"
 	}
@@ -3,10 +3,15 @@ package expression
 import (
 	"fmt"
 	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/sqlboiler/v4/drivers"
+	"github.com/volatiletech/sqlboiler/v4/queries"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
 	"testing"
 )
 
 var _ expression = &Or{}
+var _ expression = &And{}
+var _ expression = &Not{}
 
 func TestCombiner_NewCombinerOR(t *testing.T) {
 	w1 := NewWhere("age", ">", "28")
@@ -15,10 +20,10 @@ func TestCombiner_NewCombinerOR(t *testing.T) {
 	w4 := NewWhere("city", "==", "New York")
 
 	or1 := NewCombinerOR(w1, w2)
-	require.Equal(t, []*Where{w1, w2}, or1.items)
+	require.Equal(t, []Condition{w1, w2}, or1.items)
 
 	or2 := NewCombinerOR(w1, w2, w3, w4)
-	require.Equal(t, []*Where{w1, w2, w3, w4}, or2.items)
+	require.Equal(t, []Condition{w1, w2, w3, w4}, or2.items)
 }
 
 func TestCombiner_Or(t *testing.T) {
@@ -29,12 +34,90 @@ func TestCombiner_Or(t *testing.T) {
 
 	or := NewCombinerOR(w1, w2, w3, w4)
 	actually := or.Or()
-	expected := []*Where{w1, w2, w3, w4}
+	expected := []Condition{w1, w2, w3, w4}
 	require.Equal(t, expected, actually)
 }
 
 func TestCombiner_QueryMod(t *testing.T) {
-	t.Skip("ignore at the moment")
+	w1 := NewWhere("age", GreaterThan, 28)
+	w2 := NewWhere("name", Equal, "Tom")
+	w3 := NewWhere("city", Contains, "York")
+
+	tests := []struct {
+		name      string
+		condition Condition
+		expected  string
+		args      []interface{}
+	}{
+		{
+			name:      "Or",
+			condition: NewCombinerOR(w1, w2),
+			expected:  `WHERE ("age" > $1 OR "name" = $2)`,
+			args:      []interface{}{28, "Tom"},
+		},
+		{
+			name:      "And inside of Or",
+			condition: NewCombinerOR(w1, NewCombinerAND(w2, w3)),
+			expected:  `WHERE ("age" > $1 OR ("name" = $2 AND "city" LIKE $3 ESCAPE '\'))`,
+			args:      []interface{}{28, "Tom", "%York%"},
+		},
+		{
+			name:      "Not of Or",
+			condition: NewCombinerNOT(NewCombinerOR(w1, w3)),
+			expected:  `WHERE ("age" <= $1 AND "city" NOT LIKE $2 ESCAPE '\')`,
+			args:      []interface{}{28, "%York%"},
+		},
+		{
+			name:      "Not of In inside of Or",
+			condition: NewCombinerOR(w2, NewCombinerNOT(NewWhere("id", In, 1, 2, 3))),
+			expected:  `WHERE ("name" = $1 OR "id" NOT IN ($2,$3,$4))`,
+			args:      []interface{}{"Tom", 1, 2, 3},
+		},
+		{
+			name:      "Double Not",
+			condition: NewCombinerNOT(NewCombinerNOT(w2)),
+			expected:  `WHERE ("name" = $1)`,
+			args:      []interface{}{"Tom"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := &queries.Query{}
+			queries.SetDialect(q, &drivers.Dialect{LQ: '"', RQ: '"', UseIndexPlaceholders: true})
+			queries.SetFrom(q, `"users"`)
+			qm.Apply(q, tt.condition.QueryMod()...)
+			actually, args := queries.BuildQuery(q)
+			require.Contains(t, actually, tt.expected)
+			require.Equal(t, tt.args, args)
+		})
+	}
+}
+
+func TestCombiner_NewCombinerAND(t *testing.T) {
+	w1 := NewWhere("age", ">", "28")
+	w2 := NewWhere("name", "==", "Tom")
+	w3 := NewWhere("salary", ">", "5000")
+
+	and := NewCombinerAND(w1, w2, w3)
+	require.Equal(t, []Condition{w1, w2, w3}, and.And())
+	require.Equal(t, fmt.Sprintf("(%s) And (%s) And (%s)", w1.ToString(), w2.ToString(), w3.ToString()), and.ToString())
+}
+
+func TestCombiner_NewCombinerNOT(t *testing.T) {
+	w1 := NewWhere("age", ">", "28")
+
+	not := NewCombinerNOT(w1)
+	require.Equal(t, w1, not.Not())
+	require.Equal(t, fmt.Sprintf("Not (%s)", w1.ToString()), not.ToString())
+}
+
+func TestCombiner_Leaves(t *testing.T) {
+	w1 := NewWhere("age", ">", "28")
+	w2 := NewWhere("name", "==", "Tom")
+	w3 := NewWhere("salary", ">", "5000")
+
+	require.Equal(t, []*Where{w1, w2, w3}, Leaves(NewCombinerOR(w1, NewCombinerNOT(NewCombinerAND(w2, w3)))))
 }
 
 func TestCombiner_ToString(t *testing.T) {
@@ -0,0 +1,42 @@
+package expression
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// ComplexityError is returned when the request exceeds the limit of complexity, it means 400 Bad Request.
+type ComplexityError struct {
+	Measure Measure
+	Max     int
+}
+
+func (e *ComplexityError) Error() string {
+	return fmt.Sprintf("%s exceeds the maximum %d", e.Measure, e.Max)
+}
+
+// StatusCode returns the HTTP status of the error
+func (e *ComplexityError) StatusCode() int {
+	return http.StatusBadRequest
+}
+
+// Measure is the measure of request complexity, which is limited
+type Measure string
+
+const (
+	MeasureFilterLength Measure = "length of filter"
+	MeasurePredicates   Measure = "number of predicates"
+	MeasureOrBranches   Measure = "number of or branches"
+	MeasureDepth        Measure = "nesting depth of filter"
+	MeasureInLength     Measure = "number of in values"
+	MeasureLimit        Measure = "limit"
+	MeasureJoins        Measure = "number of joined tables"
+)
+
+// exceeds returns the error when the value exceeds the maximum, 0 means no limit
+func exceeds(measure Measure, value, max int) error {
+	if max > 0 && value > max {
+		return &ComplexityError{Measure: measure, Max: max}
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package expression
+
+import (
+	"fmt"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+	"strconv"
+	"strings"
+)
+
+// NewCountFrom parses the OData $count option, the total count is requested by "true" only.
+func NewCountFrom(expr string) ([]*Count, error) {
+	c := make([]*Count, 0, 1)
+	if expr != "" {
+		v, err := strconv.ParseBool(strings.ToLower(expr))
+		if err != nil {
+			return nil, fmt.Errorf("incorrect value: %s", expr)
+		}
+		if v {
+			c = append(c, NewCount())
+		}
+	}
+	return c, nil
+}
+
+func NewCount() *Count {
+	return &Count{}
+}
+
+// Count requests the total count of entities, which is obtained along with the page, it does not modify the query.
+type Count struct{}
+
+func (c *Count) QueryMod() []qm.QueryMod {
+	return nil
+}
+
+func (c *Count) ToString() string {
+	return "Count"
+}
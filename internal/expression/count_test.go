@@ -0,0 +1,31 @@
+package expression
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+var _ expression = &Count{}
+
+func TestNewCountFrom(t *testing.T) {
+	t.Run("EmptyString", func(t *testing.T) {
+		result, err := NewCountFrom("")
+		require.NoError(t, err)
+		require.Len(t, result, 0)
+	})
+	t.Run("True", func(t *testing.T) {
+		result, err := NewCountFrom("True")
+		require.NoError(t, err)
+		require.Len(t, result, 1)
+		require.Nil(t, result[0].QueryMod())
+	})
+	t.Run("False", func(t *testing.T) {
+		result, err := NewCountFrom("false")
+		require.NoError(t, err)
+		require.Len(t, result, 0)
+	})
+	t.Run("UnexpectedSymbols", func(t *testing.T) {
+		_, err := NewCountFrom("yes")
+		require.Error(t, err)
+	})
+}
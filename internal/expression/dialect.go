@@ -0,0 +1,27 @@
+package expression
+
+import (
+	"github.com/prorochestvo/sqlinjector/internal"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+	"sync/atomic"
+)
+
+// DialectExpression is an expression, which SQL depends on the dialect of database
+type DialectExpression interface {
+	QueryModOf(d internal.Dialect) []qm.QueryMod
+}
+
+// SetDialect sets the dialect used by QueryMod of expressions, PostgreSQL is used by default
+func SetDialect(d internal.Dialect) {
+	dialect.Store(d)
+}
+
+// CurrentDialect returns the dialect used by QueryMod of expressions
+func CurrentDialect() internal.Dialect {
+	if d, ok := dialect.Load().(internal.Dialect); ok {
+		return d
+	}
+	return internal.DialectPostgreSQL
+}
+
+var dialect atomic.Value
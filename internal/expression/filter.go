@@ -0,0 +1,568 @@
+package expression
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// parseFilter parses an OData filter string into the tree of conditions.
+// The operators are applied by precedence: not, and, or; the parentheses change the order.
+func parseFilter(filter string, options FilterOptions) (Condition, error) {
+	maxLength := options.MaxLength
+	if maxLength == 0 {
+		maxLength = DefaultMaxLength
+	}
+	if err := exceeds(MeasureFilterLength, len([]rune(filter)), maxLength); err != nil {
+		return nil, err
+	}
+
+	tokens, err := tokenizeFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &filterParser{tokens: tokens, options: options}
+
+	c, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.kind != tokenEnd {
+		return nil, p.unexpected(t)
+	}
+
+	return c, nil
+}
+
+type filterParser struct {
+	tokens  []filterToken
+	pos     int
+	options FilterOptions
+	// lambda is the scope of lambda operator being parsed, it is nil outside of lambda
+	lambda *lambdaScope
+	// depth, predicates and branches are measured to limit the complexity of filter
+	depth      int
+	predicates int
+	branches   int
+}
+
+// lambdaScope keeps the variable of lambda operator and how it is used: as the element of array or as the row of relation
+type lambdaScope struct {
+	variable string
+	element  bool
+	row      bool
+}
+
+// parseOr parses: and ('or' and)*
+func (p *filterParser) parseOr() (Condition, error) {
+	c, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	items := []Condition{c}
+	for p.keyword("or") {
+		p.next()
+		if c, err = p.parseAnd(); err != nil {
+			return nil, err
+		}
+		items = append(items, c)
+	}
+	if len(items) == 1 {
+		return items[0], nil
+	}
+	p.branches += len(items)
+	if err = exceeds(MeasureOrBranches, p.branches, p.options.MaxOrBranches); err != nil {
+		return nil, err
+	}
+	return &Or{items: items}, nil
+}
+
+// parseAnd parses: not ('and' not)*
+func (p *filterParser) parseAnd() (Condition, error) {
+	c, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	items := []Condition{c}
+	for p.keyword("and") {
+		p.next()
+		if c, err = p.parseNot(); err != nil {
+			return nil, err
+		}
+		items = append(items, c)
+	}
+	if len(items) == 1 {
+		return items[0], nil
+	}
+	return &And{items: items}, nil
+}
+
+// parseNot parses: 'not' not | primary
+func (p *filterParser) parseNot() (Condition, error) {
+	if !p.keyword("not") {
+		return p.parsePrimary()
+	}
+	p.next()
+	if err := p.nest(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+	c, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	return NewCombinerNOT(c), nil
+}
+
+// parsePrimary parses: '(' or ')' | function '(' operand ',' operand ')' | operand operator operand
+func (p *filterParser) parsePrimary() (Condition, error) {
+	t := p.peek()
+	switch {
+	case t.kind == tokenOpen:
+		p.next()
+		if err := p.nest(); err != nil {
+			return nil, err
+		}
+		defer p.leave()
+		c, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err = p.expect(tokenClose); err != nil {
+			return nil, err
+		}
+		return c, nil
+	case t.kind == tokenIdentifier && p.tokens[p.pos+1].kind == tokenOpen:
+		if operator, ok := filterFunctions[strings.ToLower(t.text)]; ok {
+			return p.parseFunction(operator)
+		}
+		if i := strings.LastIndex(t.text, "/"); i > 0 {
+			if kind := LambdaKind(strings.ToLower(t.text[i+1:])); kind == Any || kind == All {
+				return p.parseLambda(kind, t.text[:i])
+			}
+		}
+	}
+
+	l, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	o := p.next()
+	if o.kind != tokenIdentifier {
+		return nil, p.unexpected(o)
+	}
+	if strings.EqualFold(o.text, "in") {
+		values, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		return p.where(t, l, In, values)
+	}
+	operator, ok := filterOperators[strings.ToLower(o.text)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported Operator: %s at position %d", o.text, o.pos)
+	}
+
+	r, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	return p.where(t, l, operator, r)
+}
+
+// parseList parses the collection of literals: '(' literal (',' literal)* ')'
+func (p *filterParser) parseList() ([]interface{}, error) {
+	if err := p.expect(tokenOpen); err != nil {
+		return nil, err
+	}
+	values := make([]interface{}, 0)
+	for {
+		t := p.next()
+		if (t.kind != tokenString && t.kind != tokenLiteral) || t.value == nil {
+			return nil, p.unexpected(t)
+		}
+		values = append(values, t.value)
+		if err := exceeds(MeasureInLength, len(values), p.options.MaxInLength); err != nil {
+			return nil, err
+		}
+		if p.peek().kind != tokenComma {
+			break
+		}
+		p.next()
+	}
+	if err := p.expect(tokenClose); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// parseLambda parses the lambda operator: collection '/' ('any' | 'all') '(' [variable ':' or] ')'
+func (p *filterParser) parseLambda(kind LambdaKind, collection string) (Condition, error) {
+	t := p.next()
+	p.next()
+
+	if p.lambda != nil {
+		return nil, fmt.Errorf("invalid filter format: nested lambda operator at position %d", t.pos)
+	}
+	if err := p.nest(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+	if strings.ContainsAny(collection, "./") {
+		return nil, fmt.Errorf("invalid filter format: lambda operator over %s is not supported at position %d", collection, t.pos)
+	}
+
+	if p.peek().kind == tokenClose && kind == Any {
+		p.next()
+		if err := p.predicate(); err != nil {
+			return nil, err
+		}
+		return NewLambda(kind, collection, nil), nil
+	}
+
+	v := p.next()
+	if v.kind != tokenIdentifier || p.isKeyword(v) || strings.ContainsAny(v.text, "./") {
+		return nil, p.unexpected(v)
+	}
+	if err := p.expect(tokenColon); err != nil {
+		return nil, err
+	}
+
+	p.lambda = &lambdaScope{variable: v.text}
+	defer func() { p.lambda = nil }()
+
+	c, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if err = p.expect(tokenClose); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case p.lambda.element && p.lambda.row:
+		return nil, fmt.Errorf("invalid filter format: %s is used as the element and as the row at position %d", v.text, v.pos)
+	case p.lambda.row:
+		if p.options.Table == "" {
+			return nil, fmt.Errorf("invalid filter format: lambda operator over relation %s requires the table of entity at position %d", collection, t.pos)
+		}
+		return NewLambdaOfRelation(kind, collection, p.options.Table, c), nil
+	}
+	return NewLambda(kind, collection, c), nil
+}
+
+// parseFunction parses the function call of boolean result, e.g. contains(name,'John')
+func (p *filterParser) parseFunction(operator Operator) (Condition, error) {
+	name := p.next()
+	p.next()
+
+	l, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	if err = p.expect(tokenComma); err != nil {
+		return nil, err
+	}
+	r, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	if err = p.expect(tokenClose); err != nil {
+		return nil, err
+	}
+
+	return p.where(name, l, operator, r)
+}
+
+// parseOperand parses: literal | field | function '(' [operand (',' operand)*] ')'
+func (p *filterParser) parseOperand() (interface{}, error) {
+	t := p.next()
+	switch {
+	case t.kind == tokenString || t.kind == tokenLiteral:
+		return t.value, nil
+	case t.kind != tokenIdentifier || p.isKeyword(t):
+		return nil, p.unexpected(t)
+	case p.peek().kind != tokenOpen:
+		switch t.text {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		if p.lambda != nil {
+			return p.lambdaField(t)
+		}
+		return p.field(t.text), nil
+	}
+
+	p.next()
+	args := make([]interface{}, 0)
+	for p.peek().kind != tokenClose {
+		if len(args) > 0 {
+			if err := p.expect(tokenComma); err != nil {
+				return nil, err
+			}
+		}
+		a, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, a)
+	}
+	p.next()
+
+	f, err := NewFunction(t.text, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%w at position %d", err, t.pos)
+	}
+	return f, nil
+}
+
+// where makes the condition, the left operand should be a field or a function over fields
+func (p *filterParser) where(t filterToken, l interface{}, operator Operator, r interface{}) (Condition, error) {
+	if err := p.predicate(); err != nil {
+		return nil, err
+	}
+	if _, ok := r.(*Field); ok {
+		return nil, fmt.Errorf("invalid filter format: comparison of columns is not supported at position %d", t.pos)
+	}
+	if r == nil {
+		// the comparison with null literal is turned into IS NULL or IS NOT NULL
+		switch operator {
+		case Equal:
+			operator = IsNull
+		case NotEqual:
+			operator = IsNotNull
+		default:
+			return nil, fmt.Errorf("invalid filter format: null is not supported by %s at position %d", operator, t.pos)
+		}
+	}
+	if values, ok := r.([]interface{}); ok {
+		switch v := l.(type) {
+		case *Field:
+			return &Where{Table: v.Table, Column: v.Column, Operator: operator, Value: values, Path: v.Path}, nil
+		case *Function:
+			if len(v.Fields()) > 0 {
+				return NewWhereWithFunction(v, operator, values...), nil
+			}
+		}
+		return nil, fmt.Errorf("invalid filter format: column is expected at position %d", t.pos)
+	}
+	if _, ok := r.(*Function); ok && operator != Equal && operator != NotEqual && operator != GreaterThan && operator != GreaterThanOrEqual && operator != LessThan && operator != LessThanOrEqual {
+		return nil, fmt.Errorf("invalid filter format: function is not supported by %s at position %d", operator, t.pos)
+	}
+	switch v := l.(type) {
+	case *Field:
+		return &Where{Table: v.Table, Column: v.Column, Operator: operator, Value: r, Path: v.Path}, nil
+	case *Function:
+		if len(v.Fields()) == 0 {
+			return nil, fmt.Errorf("invalid filter format: function without columns at position %d", t.pos)
+		}
+		return NewWhereWithFunction(v, operator, r), nil
+	}
+	return nil, fmt.Errorf("invalid filter format: column is expected at position %d", t.pos)
+}
+
+// field splits the identifier into the table and the column, the rest of identifier after JSON column is the path of its member
+func (p *filterParser) field(identifier string) *Field {
+	for _, c := range p.options.JSONColumns {
+		if member, ok := strings.CutPrefix(identifier, c+"/"); ok && member != "" {
+			f := p.field(c)
+			f.Path = strings.Split(member, "/")
+			return f
+		}
+	}
+	if i := strings.IndexAny(identifier, "./"); i >= 0 {
+		return &Field{Table: identifier[:i], Column: identifier[i+1:]}
+	}
+	return &Field{Column: identifier}
+}
+
+// lambdaField resolves the variable of lambda: the variable is the element of array, the variable/column is the column of related row
+func (p *filterParser) lambdaField(t filterToken) (*Field, error) {
+	if t.text == p.lambda.variable {
+		p.lambda.element = true
+		return &Field{Column: LambdaValue}, nil
+	}
+	if c, ok := strings.CutPrefix(t.text, p.lambda.variable+"/"); ok && c != "" && !strings.ContainsAny(c, "./") {
+		p.lambda.row = true
+		return &Field{Column: c}, nil
+	}
+	return nil, fmt.Errorf("invalid filter format: only the variable %s is allowed inside of lambda at position %d", p.lambda.variable, t.pos)
+}
+
+// nest enters the nested level of filter, e.g. parentheses, not or lambda operator
+func (p *filterParser) nest() error {
+	p.depth++
+	return exceeds(MeasureDepth, p.depth, p.options.MaxDepth)
+}
+
+func (p *filterParser) leave() {
+	p.depth--
+}
+
+// predicate counts the comparison or the function of boolean result
+func (p *filterParser) predicate() error {
+	p.predicates++
+	return exceeds(MeasurePredicates, p.predicates, p.options.MaxPredicates)
+}
+
+func (p *filterParser) peek() filterToken {
+	return p.tokens[p.pos]
+}
+
+func (p *filterParser) next() filterToken {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEnd {
+		p.pos++
+	}
+	return t
+}
+
+func (p *filterParser) expect(kind filterTokenKind) error {
+	if t := p.next(); t.kind != kind {
+		return p.unexpected(t)
+	}
+	return nil
+}
+
+// keyword reports whether the next token is the logical operator
+func (p *filterParser) keyword(k string) bool {
+	t := p.peek()
+	return t.kind == tokenIdentifier && strings.EqualFold(t.text, k)
+}
+
+func (p *filterParser) isKeyword(t filterToken) bool {
+	switch strings.ToLower(t.text) {
+	case "and", "or", "not":
+		return true
+	}
+	return false
+}
+
+func (p *filterParser) unexpected(t filterToken) error {
+	if t.kind == tokenEnd {
+		return fmt.Errorf("invalid filter format: unexpected end of filter")
+	}
+	return fmt.Errorf("invalid filter format: unexpected %q at position %d", t.text, t.pos)
+}
+
+type filterTokenKind int
+
+const (
+	tokenEnd filterTokenKind = iota
+	tokenIdentifier
+	tokenString
+	tokenLiteral
+	tokenOpen
+	tokenClose
+	tokenComma
+	tokenColon
+)
+
+type filterToken struct {
+	kind  filterTokenKind
+	text  string
+	value interface{}
+	pos   int
+}
+
+// tokenizeFilter splits the filter string into tokens, the last one is always tokenEnd
+func tokenizeFilter(filter string) ([]filterToken, error) {
+	tokens := make([]filterToken, 0)
+	r := []rune(filter)
+	for i := 0; i < len(r); {
+		c := r[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(':
+			tokens = append(tokens, filterToken{kind: tokenOpen, text: "(", pos: i})
+			i++
+		case c == ')':
+			tokens = append(tokens, filterToken{kind: tokenClose, text: ")", pos: i})
+			i++
+		case c == ',':
+			tokens = append(tokens, filterToken{kind: tokenComma, text: ",", pos: i})
+			i++
+		case c == ':':
+			tokens = append(tokens, filterToken{kind: tokenColon, text: ":", pos: i})
+			i++
+		case c == '\'':
+			start := i
+			text, n, err := scanQuoted(r[i:])
+			if err != nil {
+				return nil, fmt.Errorf("invalid filter format: %w at position %d", err, start)
+			}
+			i += n
+			tokens = append(tokens, filterToken{kind: tokenString, text: text, value: text, pos: start})
+		case c == '-' || unicode.IsDigit(c) || (unicode.Is(unicode.ASCII_Hex_Digit, c) && guidPattern.MatchString(string(r[i:min(i+guidLength, len(r))]))):
+			text, value, err := scanLiteral(string(r[i:literalEnd(r, i)]))
+			if err != nil {
+				return nil, fmt.Errorf("invalid filter format: %w at position %d", err, i)
+			}
+			tokens = append(tokens, filterToken{kind: tokenLiteral, text: text, value: value, pos: i})
+			i += len(text)
+		case unicode.IsLetter(c) || c == '_' || c == '$':
+			start := i
+			for i < len(r) && (unicode.IsLetter(r[i]) || unicode.IsDigit(r[i]) || r[i] == '_' || r[i] == '$' || r[i] == '.' || r[i] == '/') {
+				i++
+			}
+			text := string(r[start:i])
+			if strings.EqualFold(text, "duration") && i < len(r) && r[i] == '\'' {
+				// the typed literal, e.g. duration'P1DT2H'
+				quoted, n, err := scanQuoted(r[i:])
+				if err != nil {
+					return nil, fmt.Errorf("invalid filter format: %w at position %d", err, i)
+				}
+				d, err := parseDuration(quoted)
+				if err != nil {
+					return nil, fmt.Errorf("invalid filter format: %w at position %d", err, start)
+				}
+				i += n
+				tokens = append(tokens, filterToken{kind: tokenLiteral, text: string(r[start:i]), value: d, pos: start})
+				continue
+			}
+			tokens = append(tokens, filterToken{kind: tokenIdentifier, text: text, pos: start})
+		default:
+			return nil, fmt.Errorf("invalid filter format: unexpected %q at position %d", c, i)
+		}
+	}
+	tokens = append(tokens, filterToken{kind: tokenEnd, pos: len(r)})
+	return tokens, nil
+}
+
+// literalEnd returns the end of run of runes, which could form the literal starting at the position,
+// the literal is scanned within the run instead of the rest of filter to keep the tokenizing linear
+func literalEnd(r []rune, start int) int {
+	end := start + 1
+	for end < len(r) && (unicode.IsLetter(r[end]) || unicode.IsDigit(r[end]) || strings.ContainsRune("-+:.", r[end])) {
+		end++
+	}
+	return end
+}
+
+var filterOperators = map[string]Operator{
+	"eq":         Equal,
+	"ne":         NotEqual,
+	"gt":         GreaterThan,
+	"ge":         GreaterThanOrEqual,
+	"lt":         LessThan,
+	"le":         LessThanOrEqual,
+	"contains":   Contains,
+	"startswith": StartsWith,
+	"endswith":   EndsWith,
+}
+
+var filterFunctions = map[string]Operator{
+	"contains":       Contains,
+	"startswith":     StartsWith,
+	"endswith":       EndsWith,
+	"matchespattern": Matches,
+}
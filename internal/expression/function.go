@@ -0,0 +1,239 @@
+package expression
+
+import (
+	"fmt"
+	"github.com/prorochestvo/sqlinjector/internal"
+	"strconv"
+	"strings"
+)
+
+// NewFunction makes the call of OData canonical function, e.g. tolower(name) or year(created_at).
+// The arguments are *Field, *Function or plain values.
+func NewFunction(name string, args ...interface{}) (*Function, error) {
+	name = strings.ToLower(name)
+	arity, ok := functionArity[name]
+	if !ok {
+		return nil, fmt.Errorf("unsupported function: %s", name)
+	}
+	if l := len(args); l < arity[0] || (arity[1] >= 0 && l > arity[1]) {
+		return nil, fmt.Errorf("incorrect number of arguments of %s: %d", name, l)
+	}
+	return &Function{Name: name, Args: args}, nil
+}
+
+// NewField makes the reference to the column, the table is separated by "/", e.g. "user/name"
+func NewField(column string) *Field {
+	t, c := extractTableNameAndColumn(column)
+	return &Field{Table: t, Column: c}
+}
+
+// Field is the reference to the column of table, it is used as an argument of Function
+type Field struct {
+	Table  string
+	Column string
+	// Path is the path of member of JSON column, e.g. ["address", "city"]
+	Path []string
+}
+
+func (f *Field) ToString() string {
+	c := f.Column
+	if len(f.Path) > 0 {
+		c += "/" + strings.Join(f.Path, "/")
+	}
+	if f.Table == "" {
+		return c
+	}
+	return TableName(f.Table) + "." + c
+}
+
+type Function struct {
+	Name string
+	Args []interface{}
+}
+
+// Fields returns all fields referenced by the function and its nested functions
+func (f *Function) Fields() []*Field {
+	fields := make([]*Field, 0, len(f.Args))
+	for _, a := range f.Args {
+		switch v := a.(type) {
+		case *Field:
+			fields = append(fields, v)
+		case *Function:
+			fields = append(fields, v.Fields()...)
+		}
+	}
+	return fields
+}
+
+func (f *Function) ToString() string {
+	args := make([]string, len(f.Args))
+	for i, a := range f.Args {
+		switch v := a.(type) {
+		case *Field:
+			args[i] = v.ToString()
+		case *Function:
+			args[i] = v.ToString()
+		default:
+			args[i] = fmt.Sprintf("%v", v)
+		}
+	}
+	return f.Name + "(" + strings.Join(args, ",") + ")"
+}
+
+// sql renders the function by the dialect, values of the arguments are passed as placeholders
+func (f *Function) sql(d internal.Dialect) (string, []interface{}) {
+	parts := make([]string, len(f.Args))
+	values := make([][]interface{}, len(f.Args))
+	for i, a := range f.Args {
+		switch v := a.(type) {
+		case *Field:
+			parts[i] = jsonPath(d, joinTableNameAndColumn(v.Table, v.Column, nil), v.Path, nil)
+		case *Function:
+			parts[i], values[i] = v.sql(d)
+		default:
+			parts[i], values[i] = "?", []interface{}{v}
+		}
+	}
+
+	// the template refers to the arguments by {index}, the values follow the order of references
+	template := functionTemplate(f.Name, d, len(f.Args))
+	var b strings.Builder
+	args := make([]interface{}, 0, len(f.Args))
+	for i := 0; i < len(template); i++ {
+		if template[i] == '{' {
+			if j := strings.IndexByte(template[i:], '}'); j > 0 {
+				if n, err := strconv.Atoi(template[i+1 : i+j]); err == nil && n < len(parts) {
+					b.WriteString(parts[n])
+					args = append(args, values[n]...)
+					i += j
+					continue
+				}
+			}
+		}
+		b.WriteByte(template[i])
+	}
+
+	return b.String(), args
+}
+
+// functionTemplate returns SQL of the function for the dialect
+func functionTemplate(name string, d internal.Dialect, argc int) string {
+	switch name {
+	case "tolower":
+		return "LOWER({0})"
+	case "toupper":
+		return "UPPER({0})"
+	case "trim":
+		return "TRIM({0})"
+	case "length":
+		if d == internal.DialectMySQL {
+			return "CHAR_LENGTH({0})"
+		}
+		return "LENGTH({0})"
+	case "indexof":
+		// OData counts positions from zero, SQL does from one
+		switch d {
+		case internal.DialectPostgreSQL:
+			return "(STRPOS({0}, {1}) - 1)"
+		case internal.DialectMySQL:
+			return "(LOCATE({1}, {0}) - 1)"
+		}
+		return "(INSTR({0}, {1}) - 1)"
+	case "substring":
+		if argc > 2 {
+			return "SUBSTR({0}, {1} + 1, {2})"
+		}
+		return "SUBSTR({0}, {1} + 1)"
+	case "concat":
+		items := make([]string, argc)
+		for i := range items {
+			items[i] = "{" + strconv.Itoa(i) + "}"
+		}
+		if d == internal.DialectMySQL {
+			return "CONCAT(" + strings.Join(items, ", ") + ")"
+		}
+		return "(" + strings.Join(items, " || ") + ")"
+	case "year", "month", "day", "hour":
+		switch d {
+		case internal.DialectPostgreSQL:
+			return "EXTRACT(" + strings.ToUpper(name) + " FROM {0})"
+		case internal.DialectMySQL:
+			return strings.ToUpper(name) + "({0})"
+		}
+		return "CAST(STRFTIME('" + sqliteTimeFormat[name] + "', {0}) AS INTEGER)"
+	case "date":
+		if d == internal.DialectPostgreSQL {
+			return "CAST({0} AS DATE)"
+		}
+		return "DATE({0})"
+	case "now":
+		if d == internal.DialectSQLite3 {
+			return "DATETIME('now')"
+		}
+		return "NOW()"
+	case "round":
+		return "ROUND({0})"
+	case "floor":
+		// SQLite has no math functions without the compile option
+		if d == internal.DialectSQLite3 {
+			return "(CAST({0} AS INTEGER) - ({0} < CAST({0} AS INTEGER)))"
+		}
+		return "FLOOR({0})"
+	case "ceiling":
+		if d == internal.DialectSQLite3 {
+			return "(CAST({0} AS INTEGER) + ({0} > CAST({0} AS INTEGER)))"
+		}
+		return "CEILING({0})"
+	case string(AggregateCount):
+		if argc == 0 {
+			return "COUNT(*)"
+		}
+		return "COUNT({0})"
+	case string(AggregateCountDistinct):
+		return "COUNT(DISTINCT {0})"
+	case string(AggregateSum), string(AggregateMin), string(AggregateMax):
+		return strings.ToUpper(name) + "({0})"
+	case string(AggregateAverage):
+		return "AVG({0})"
+	}
+	return strings.ToUpper(name) + "()"
+}
+
+// aggregate reports whether the function is the aggregate of Having, the aggregates are not the canonical functions of filter
+func (f *Function) aggregate() bool {
+	if f == nil {
+		return false
+	}
+	switch AggregateMethod(f.Name) {
+	case AggregateCount, AggregateCountDistinct, AggregateSum, AggregateAverage, AggregateMin, AggregateMax:
+		return true
+	}
+	return false
+}
+
+// functionArity keeps the minimal and maximal number of arguments, -1 means unlimited
+var functionArity = map[string][2]int{
+	"tolower":   {1, 1},
+	"toupper":   {1, 1},
+	"trim":      {1, 1},
+	"length":    {1, 1},
+	"indexof":   {2, 2},
+	"substring": {2, 3},
+	"concat":    {2, -1},
+	"year":      {1, 1},
+	"month":     {1, 1},
+	"day":       {1, 1},
+	"hour":      {1, 1},
+	"date":      {1, 1},
+	"now":       {0, 0},
+	"round":     {1, 1},
+	"floor":     {1, 1},
+	"ceiling":   {1, 1},
+}
+
+var sqliteTimeFormat = map[string]string{
+	"year":  "%Y",
+	"month": "%m",
+	"day":   "%d",
+	"hour":  "%H",
+}
@@ -0,0 +1,174 @@
+package expression
+
+import (
+	"github.com/prorochestvo/sqlinjector/internal"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/sqlboiler/v4/drivers"
+	"github.com/volatiletech/sqlboiler/v4/queries"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+	"testing"
+)
+
+func TestFunction_NewFunction(t *testing.T) {
+	f, err := NewFunction("ToLower", NewField("user/name"))
+	require.NoError(t, err)
+	require.Equal(t, &Function{Name: "tolower", Args: []interface{}{&Field{Table: "user", Column: "name"}}}, f)
+
+	_, err = NewFunction("tolower")
+	require.Error(t, err)
+
+	_, err = NewFunction("substring", NewField("name"), 1, 2, 3)
+	require.Error(t, err)
+
+	_, err = NewFunction("unknown", NewField("name"))
+	require.Error(t, err)
+}
+
+func TestFunction_Fields(t *testing.T) {
+	f, err := NewFunction("concat", NewField("user/name"), " ", &Function{Name: "trim", Args: []interface{}{NewField("surname")}})
+	require.NoError(t, err)
+	require.Equal(t, []*Field{{Table: "user", Column: "name"}, {Column: "surname"}}, f.Fields())
+	require.Equal(t, "concat(users.name, ,trim(surname))", f.ToString())
+}
+
+func TestFunction_QueryModOf(t *testing.T) {
+	name := NewField("name")
+	createdAt := NewField("created_at")
+
+	tests := []struct {
+		name     string
+		function *Function
+		value    interface{}
+		dialect  internal.Dialect
+		expected string
+		args     []interface{}
+	}{
+		{
+			name:     "tolower",
+			function: &Function{Name: "tolower", Args: []interface{}{name}},
+			value:    "john",
+			dialect:  internal.DialectPostgreSQL,
+			expected: `WHERE (LOWER("name") = $1)`,
+			args:     []interface{}{"john"},
+		},
+		{
+			name:     "length of MySQL",
+			function: &Function{Name: "length", Args: []interface{}{name}},
+			value:    3,
+			dialect:  internal.DialectMySQL,
+			expected: `WHERE (CHAR_LENGTH("name") = $1)`,
+			args:     []interface{}{3},
+		},
+		{
+			name:     "indexof of PostgreSQL",
+			function: &Function{Name: "indexof", Args: []interface{}{name, "o"}},
+			value:    1,
+			dialect:  internal.DialectPostgreSQL,
+			expected: `WHERE ((STRPOS("name", $1) - 1) = $2)`,
+			args:     []interface{}{"o", 1},
+		},
+		{
+			name:     "indexof of MySQL",
+			function: &Function{Name: "indexof", Args: []interface{}{name, "o"}},
+			value:    1,
+			dialect:  internal.DialectMySQL,
+			expected: `WHERE ((LOCATE($1, "name") - 1) = $2)`,
+			args:     []interface{}{"o", 1},
+		},
+		{
+			name:     "substring",
+			function: &Function{Name: "substring", Args: []interface{}{name, 1, 2}},
+			value:    "oh",
+			dialect:  internal.DialectSQLite3,
+			expected: `WHERE (SUBSTR("name", $1 + 1, $2) = $3)`,
+			args:     []interface{}{1, 2, "oh"},
+		},
+		{
+			name:     "concat of SQLite",
+			function: &Function{Name: "concat", Args: []interface{}{name, "!"}},
+			value:    "John!",
+			dialect:  internal.DialectSQLite3,
+			expected: `WHERE (("name" || $1) = $2)`,
+			args:     []interface{}{"!", "John!"},
+		},
+		{
+			name:     "year of PostgreSQL",
+			function: &Function{Name: "year", Args: []interface{}{createdAt}},
+			value:    2024,
+			dialect:  internal.DialectPostgreSQL,
+			expected: `WHERE (EXTRACT(YEAR FROM "created_at") = $1)`,
+			args:     []interface{}{2024},
+		},
+		{
+			name:     "month of MySQL",
+			function: &Function{Name: "month", Args: []interface{}{createdAt}},
+			value:    12,
+			dialect:  internal.DialectMySQL,
+			expected: `WHERE (MONTH("created_at") = $1)`,
+			args:     []interface{}{12},
+		},
+		{
+			name:     "hour of SQLite",
+			function: &Function{Name: "hour", Args: []interface{}{createdAt}},
+			value:    10,
+			dialect:  internal.DialectSQLite3,
+			expected: `WHERE (CAST(STRFTIME('%H', "created_at") AS INTEGER) = $1)`,
+			args:     []interface{}{10},
+		},
+		{
+			name:     "date compared with now",
+			function: &Function{Name: "date", Args: []interface{}{createdAt}},
+			value:    &Function{Name: "now"},
+			dialect:  internal.DialectPostgreSQL,
+			expected: `WHERE (CAST("created_at" AS DATE) = NOW())`,
+			args:     nil,
+		},
+		{
+			name:     "floor of SQLite",
+			function: &Function{Name: "floor", Args: []interface{}{NewField("price")}},
+			value:    10,
+			dialect:  internal.DialectSQLite3,
+			expected: `WHERE ((CAST("price" AS INTEGER) - ("price" < CAST("price" AS INTEGER))) = $1)`,
+			args:     []interface{}{10},
+		},
+		{
+			name:     "tolower of JSON member of SQLite",
+			function: &Function{Name: "tolower", Args: []interface{}{&Field{Column: "metadata", Path: []string{"color"}}}},
+			value:    "red",
+			dialect:  internal.DialectSQLite3,
+			expected: `WHERE (LOWER(json_extract("metadata", '$."color"')) = $1)`,
+			args:     []interface{}{"red"},
+		},
+		{
+			name:     "length of JSON member of PostgreSQL",
+			function: &Function{Name: "length", Args: []interface{}{&Field{Column: "metadata", Path: []string{"tags", "0"}}}},
+			value:    3,
+			dialect:  internal.DialectPostgreSQL,
+			expected: `WHERE (LENGTH("metadata"#>>'{"tags","0"}') = $1)`,
+			args:     []interface{}{3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := &queries.Query{}
+			queries.SetDialect(q, &drivers.Dialect{LQ: '"', RQ: '"', UseIndexPlaceholders: true})
+			queries.SetFrom(q, `"users"`)
+			qm.Apply(q, NewWhereWithFunction(tt.function, Equal, tt.value).QueryModOf(tt.dialect)...)
+			actually, args := queries.BuildQuery(q)
+			require.Contains(t, actually, tt.expected)
+			require.Equal(t, tt.args, args)
+		})
+	}
+}
+
+func TestFunction_CurrentDialect(t *testing.T) {
+	require.Equal(t, internal.DialectPostgreSQL, CurrentDialect())
+
+	SetDialect(internal.DialectMySQL)
+	defer SetDialect(internal.DialectPostgreSQL)
+
+	require.Equal(t, internal.DialectMySQL, CurrentDialect())
+	w := NewWhereWithFunction(&Function{Name: "length", Args: []interface{}{NewField("name")}}, Equal, 3)
+	require.Equal(t, `CHAR_LENGTH("name")`, w.Where())
+}
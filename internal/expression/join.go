@@ -0,0 +1,105 @@
+package expression
+
+import (
+	"fmt"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+	"strings"
+	"sync/atomic"
+)
+
+// JoinKind is the kind of Join
+type JoinKind string
+
+const (
+	InnerJoin JoinKind = "inner"
+	LeftJoin  JoinKind = "left"
+	RightJoin JoinKind = "right"
+)
+
+// NewJoin makes the join of table by the condition, the alias (the table by default) is referred by the columns of Where, OrderBy and Select,
+// e.g. NewJoin("users", "author", `"author"."id" = "books"."author_id"`, LeftJoin) and NewWhere("author/name", Equal, "Leo").
+// The condition is SQL, which identifiers are quoted by double quotes the same as ones of the rest of expressions.
+func NewJoin(table, alias, on string, kind JoinKind) *Join {
+	return &Join{Table: table, Alias: alias, On: on, Kind: kind}
+}
+
+// NewJoinOf makes the join of table by the equality of its column and the column of other table (the table of query, when it has no table),
+// e.g. NewJoinOf("users", "author", "id", "books/author_id", LeftJoin) is the join of "users" AS "author" ON "author"."id" = "books"."author_id".
+func NewJoinOf(table, alias, column, foreign string, kind JoinKind) *Join {
+	return &Join{Table: table, Alias: alias, Column: column, Foreign: NewField(foreign), Kind: kind}
+}
+
+// Join joins the table by the raw condition On or by the equality of Column of joined table and the Foreign column
+type Join struct {
+	Table   string
+	Alias   string
+	On      string
+	Column  string
+	Foreign *Field
+	Kind    JoinKind
+}
+
+// Reference returns the name, which the columns of joined table are qualified by
+func (j *Join) Reference() string {
+	if j.Alias != "" {
+		return j.Alias
+	}
+	return j.Table
+}
+
+func (j *Join) QueryMod() []qm.QueryMod {
+	clause := "\"" + j.Table + "\""
+	if j.Alias != "" && j.Alias != j.Table {
+		clause += " AS \"" + j.Alias + "\""
+	}
+	clause += " ON " + j.condition()
+
+	switch j.Kind {
+	case LeftJoin:
+		return []qm.QueryMod{qm.LeftOuterJoin(clause)}
+	case RightJoin:
+		return []qm.QueryMod{qm.RightOuterJoin(clause)}
+	}
+	return []qm.QueryMod{qm.InnerJoin(clause)}
+}
+
+func (j *Join) ToString() string {
+	kind := j.Kind
+	if kind == "" {
+		kind = InnerJoin
+	}
+	s := fmt.Sprintf("%s join %s", kind, j.Table)
+	if j.Alias != "" && j.Alias != j.Table {
+		s += " as " + j.Alias
+	}
+	if j.On == "" && j.Foreign != nil {
+		return s + " on " + j.Reference() + "." + j.Column + " eq " + strings.TrimPrefix(j.Foreign.Table+"."+j.Foreign.Column, ".")
+	}
+	return s + " on " + strings.TrimSpace(j.On)
+}
+
+// condition returns SQL of the condition of join, the raw condition takes precedence over the columns
+func (j *Join) condition() string {
+	if j.On != "" || j.Foreign == nil {
+		return j.On
+	}
+	foreign := "\"" + j.Foreign.Column + "\""
+	if j.Foreign.Table != "" {
+		foreign = "\"" + j.Foreign.Table + "\"." + foreign
+	}
+	return "\"" + j.Reference() + "\".\"" + j.Column + "\" = " + foreign
+}
+
+// SetJoinHeuristic enables the heuristic of related tables (disabled by default): the columns of related table are qualified by its plural snake case,
+// which is joined by the foreign key of singular table, e.g. the column "user/name" is turned into "users"."name" joined by "users"."id" = "user_id".
+// The tables are referred as is without the heuristic, so they are joined explicitly by Join.
+func SetJoinHeuristic(enabled bool) {
+	joinHeuristic.Store(enabled)
+}
+
+// JoinHeuristic reports whether the heuristic of related tables is enabled
+func JoinHeuristic() bool {
+	return joinHeuristic.Load()
+}
+
+var joinHeuristic atomic.Bool
@@ -0,0 +1,83 @@
+package expression
+
+import (
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/sqlboiler/v4/drivers"
+	"github.com/volatiletech/sqlboiler/v4/queries"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+	"testing"
+)
+
+var _ expression = &Join{}
+
+func TestJoin_QueryMod(t *testing.T) {
+	tests := []struct {
+		name     string
+		mods     []Modifier
+		expected string
+		args     []interface{}
+	}{
+		{
+			name:     "inner join of alias",
+			mods:     []Modifier{NewJoin("users", "author", `"author"."id" = "books"."author_id"`, InnerJoin), NewWhere("author/name", Equal, "Leo"), NewOrderBy("author/name", Descending)},
+			expected: `SELECT "books".* FROM "books" INNER JOIN "users" AS "author" ON "author"."id" = "books"."author_id" WHERE ("author"."name" = $1) ORDER BY "author"."name" DESC;`,
+			args:     []interface{}{"Leo"},
+		},
+		{
+			name:     "left join of table",
+			mods:     []Modifier{NewJoin("publishers", "", `"publishers"."id" = "books"."publisher_id"`, LeftJoin), NewWhere("publishers/name", IsNull)},
+			expected: `SELECT "books".* FROM "books" LEFT JOIN "publishers" ON "publishers"."id" = "books"."publisher_id" WHERE ("publishers"."name" IS NULL);`,
+		},
+		{
+			name:     "right join",
+			mods:     []Modifier{NewJoin("users", "reader", `"reader"."book_id" = "books"."id"`, RightJoin), NewSelect("reader.name")},
+			expected: `SELECT "reader"."name" as "reader.name" FROM "books" RIGHT JOIN "users" AS "reader" ON "reader"."book_id" = "books"."id";`,
+		},
+		{
+			name:     "join by columns",
+			mods:     []Modifier{NewJoinOf("users", "author", "id", "books/author_id", LeftJoin), NewWhere("author/name", Equal, "Leo")},
+			expected: `SELECT "books".* FROM "books" LEFT JOIN "users" AS "author" ON "author"."id" = "books"."author_id" WHERE ("author"."name" = $1);`,
+			args:     []interface{}{"Leo"},
+		},
+		{
+			name:     "inner join by default",
+			mods:     []Modifier{NewJoin("users", "users", `"users"."id" = "books"."user_id"`, "")},
+			expected: `SELECT "books".* FROM "books" INNER JOIN "users" ON "users"."id" = "books"."user_id";`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := &queries.Query{}
+			queries.SetDialect(q, &drivers.Dialect{LQ: '"', RQ: '"', UseIndexPlaceholders: true})
+			queries.SetFrom(q, `"books"`)
+			for _, m := range tt.mods {
+				qm.Apply(q, m.QueryMod()...)
+			}
+			actually, args := queries.BuildQuery(q)
+			require.Equal(t, tt.expected, actually)
+			require.Equal(t, tt.args, args)
+		})
+	}
+}
+
+func TestJoin_ToString(t *testing.T) {
+	require.Equal(t, `left join users as author on "author"."id" = "books"."author_id"`, NewJoin("users", "author", `"author"."id" = "books"."author_id"`, LeftJoin).ToString())
+	require.Equal(t, `inner join users on "users"."id" = "user_id"`, NewJoin("users", "", `"users"."id" = "user_id"`, "").ToString())
+	require.Equal(t, "left join users as author on author.id eq books.author_id", NewJoinOf("users", "author", "id", "books/author_id", LeftJoin).ToString())
+	require.Equal(t, "inner join users on users.id eq user_id", NewJoinOf("users", "", "id", "user_id", "").ToString())
+	require.Equal(t, "author", NewJoin("users", "author", "", InnerJoin).Reference())
+	require.Equal(t, "users", NewJoin("users", "", "", InnerJoin).Reference())
+}
+
+func TestJoin_SetJoinHeuristic(t *testing.T) {
+	require.False(t, JoinHeuristic())
+
+	SetJoinHeuristic(true)
+	defer SetJoinHeuristic(false)
+	require.True(t, JoinHeuristic())
+
+	mods := NewWhere("author/name", Equal, "Leo").QueryMod()
+	require.Len(t, mods, 2)
+	require.Equal(t, qm.InnerJoin(`"authors" ON "authors"."id" = "author_id"`), mods[0])
+}
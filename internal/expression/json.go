@@ -0,0 +1,451 @@
+package expression
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"time"
+)
+
+// Node is the JSON form of expression, the Type defines which fields are filled.
+type Node struct {
+	Type string `json:"type"`
+	// Table and Column are the column of where, lambda, order by and group by, the Path is the member of JSON column of where
+	Table     string    `json:"table,omitempty"`
+	Column    string    `json:"column,omitempty"`
+	Path      []string  `json:"path,omitempty"`
+	Operator  Operator  `json:"operator,omitempty"`
+	Value     *Value    `json:"value,omitempty"`
+	Function  *Value    `json:"function,omitempty"`
+	Direction Direction `json:"direction,omitempty"`
+	// Items are the conditions of and, or and not, or the modifiers of relation
+	Items []*Node `json:"items,omitempty"`
+	// Kind, Relation, Parent, ForeignKey and Condition describe the lambda operator
+	Kind       LambdaKind `json:"kind,omitempty"`
+	Relation   bool       `json:"relation,omitempty"`
+	Parent     string     `json:"parent,omitempty"`
+	ForeignKey string     `json:"foreign_key,omitempty"`
+	Condition  *Node      `json:"condition,omitempty"`
+	// Method and Alias describe the aggregate of aggregate and having, the Alias and Join describe the join of Table,
+	// which Column equals the column ForeignKey of table Parent
+	Method AggregateMethod `json:"method,omitempty"`
+	Alias  string          `json:"alias,omitempty"`
+	Join   JoinKind        `json:"join,omitempty"`
+	// Columns of select and search, Tables of relation and the Number of limit or offset
+	Columns []string `json:"columns,omitempty"`
+	Tables  []string `json:"tables,omitempty"`
+	Number  int      `json:"number,omitempty"`
+}
+
+// Value is the JSON form of typed value, the Type is one of null, string, bool, int*, uint*, float*, datetime, duration, list, field and function.
+type Value struct {
+	Type   string          `json:"type"`
+	Value  json.RawMessage `json:"value,omitempty"`
+	Items  []*Value        `json:"items,omitempty"`
+	Table  string          `json:"table,omitempty"`
+	Column string          `json:"column,omitempty"`
+	Path   []string        `json:"path,omitempty"`
+	Name   string          `json:"name,omitempty"`
+	Args   []*Value        `json:"args,omitempty"`
+}
+
+// NodeOf converts the expression into its JSON form
+func NodeOf(m Modifier) (*Node, error) {
+	switch e := m.(type) {
+	case *Where:
+		v, err := valueOf(e.Value)
+		if err != nil {
+			return nil, err
+		}
+		n := &Node{Type: "where", Table: e.Table, Column: e.Column, Path: e.Path, Operator: e.Operator, Value: v}
+		if e.Function != nil {
+			if n.Function, err = valueOf(e.Function); err != nil {
+				return nil, err
+			}
+		}
+		return n, nil
+	case *And:
+		return nodeOfItems("and", e.items)
+	case *Or:
+		return nodeOfItems("or", e.items)
+	case *Not:
+		return nodeOfItems("not", []Condition{e.item})
+	case *Lambda:
+		n := &Node{Type: "lambda", Kind: e.Kind, Column: e.Column, Relation: e.Relation, Parent: e.Parent, ForeignKey: e.ForeignKey}
+		if e.Condition != nil {
+			c, err := NodeOf(e.Condition)
+			if err != nil {
+				return nil, err
+			}
+			n.Condition = c
+		}
+		return n, nil
+	case *Search:
+		return nodeOfSearch("search", e)
+	case *SearchRank:
+		return nodeOfSearch("searchrank", e.Search)
+	case *OrderBy:
+		return &Node{Type: "orderby", Table: e.Table, Column: e.Column, Direction: e.Direction}, nil
+	case *GroupBy:
+		return &Node{Type: "groupby", Table: e.Table, Column: e.Column}, nil
+	case *Join:
+		if e.On != "" || e.Foreign == nil {
+			return nil, fmt.Errorf("join by the raw condition is not supported, the join by columns is expected (see NewJoinOf)")
+		}
+		return &Node{Type: "join", Table: e.Table, Alias: e.Alias, Column: e.Column, Parent: e.Foreign.Table, ForeignKey: e.Foreign.Column, Join: e.Kind}, nil
+	case *Aggregate:
+		return &Node{Type: "aggregate", Table: e.Table, Column: e.Column, Method: e.Method, Alias: e.Alias}, nil
+	case *Having:
+		v, err := valueOf(e.Value)
+		if err != nil {
+			return nil, err
+		}
+		a := e.Aggregate
+		return &Node{Type: "having", Table: a.Table, Column: a.Column, Method: a.Method, Alias: a.Alias, Operator: e.Operator, Value: v}, nil
+	case *Limit:
+		return &Node{Type: "limit", Number: e.limit}, nil
+	case *Offset:
+		return &Node{Type: "offset", Number: e.offset}, nil
+	case *Select:
+		return &Node{Type: "select", Columns: e.columns}, nil
+	case *Count:
+		return &Node{Type: "count"}, nil
+	case *Relation:
+		n := &Node{Type: "relation", Tables: e.tables}
+		for _, mod := range e.mods {
+			item, err := NodeOf(mod)
+			if err != nil {
+				return nil, err
+			}
+			n.Items = append(n.Items, item)
+		}
+		return n, nil
+	}
+	return nil, fmt.Errorf("unsupported expression: %T", m)
+}
+
+// Expression converts the JSON form back into the expression, the names of tables, columns and aliases are checked,
+// since they are embedded into SQL as is
+func (n *Node) Expression() (Modifier, error) {
+	if err := identifiers(append([]string{n.Table, n.Column, n.Alias, n.Parent, n.ForeignKey}, n.Tables...)...); err != nil {
+		return nil, err
+	}
+	for _, c := range n.Columns {
+		if (n.Type == "select" && !selectPattern.MatchString(c)) || (n.Type != "select" && identifiers(c) != nil) {
+			return nil, fmt.Errorf("invalid identifier: %q", c)
+		}
+	}
+
+	switch n.Type {
+	case "where":
+		if !isOperator(n.Operator) {
+			return nil, fmt.Errorf("unsupported Operator: %s", n.Operator)
+		}
+		v, err := n.Value.value()
+		if err != nil {
+			return nil, err
+		}
+		w := &Where{Table: n.Table, Column: n.Column, Operator: n.Operator, Value: v, Path: n.Path}
+		if n.Function != nil {
+			if n.Function.Type != "function" {
+				return nil, fmt.Errorf("function is expected instead of %s", n.Function.Type)
+			}
+			f, err := n.Function.value()
+			if err != nil {
+				return nil, err
+			}
+			w.Function = f.(*Function)
+		}
+		return w, nil
+	case "and", "or", "not":
+		items, err := n.conditions(n.Items)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case n.Type == "not" && len(items) == 1:
+			return &Not{item: items[0]}, nil
+		case n.Type == "and" && len(items) > 1:
+			return &And{items: items}, nil
+		case n.Type == "or" && len(items) > 1:
+			return &Or{items: items}, nil
+		}
+		return nil, fmt.Errorf("incorrect number of conditions of %s: %d", n.Type, len(items))
+	case "lambda":
+		if n.Kind != Any && n.Kind != All {
+			return nil, fmt.Errorf("unsupported lambda operator: %s", n.Kind)
+		}
+		l := &Lambda{Kind: n.Kind, Column: n.Column, Relation: n.Relation, Parent: n.Parent, ForeignKey: n.ForeignKey}
+		if n.Condition != nil {
+			c, err := n.conditions([]*Node{n.Condition})
+			if err != nil {
+				return nil, err
+			}
+			l.Condition = c[0]
+		}
+		return l, nil
+	case "search", "searchrank":
+		query, err := n.Value.value()
+		if err != nil {
+			return nil, err
+		}
+		q, ok := query.(string)
+		if !ok || len(n.Columns) == 0 {
+			return nil, fmt.Errorf("%s requires the columns and the query of string", n.Type)
+		}
+		s := NewSearch(n.Table, n.Columns, q)
+		if n.Type == "searchrank" {
+			return NewSearchRank(s), nil
+		}
+		return s, nil
+	case "orderby":
+		if n.Direction != defaulting && n.Direction != Ascending && n.Direction != Descending {
+			return nil, fmt.Errorf("unsupported direction: %s", n.Direction)
+		}
+		return &OrderBy{Table: n.Table, Column: n.Column, Direction: n.Direction}, nil
+	case "groupby":
+		return &GroupBy{Table: n.Table, Column: n.Column}, nil
+	case "join":
+		if n.Join != "" && n.Join != InnerJoin && n.Join != LeftJoin && n.Join != RightJoin {
+			return nil, fmt.Errorf("unsupported join: %s", n.Join)
+		}
+		if n.Table == "" || n.Column == "" || n.ForeignKey == "" {
+			return nil, fmt.Errorf("join requires the table, the column and the foreign key")
+		}
+		return &Join{Table: n.Table, Alias: n.Alias, Column: n.Column, Foreign: &Field{Table: n.Parent, Column: n.ForeignKey}, Kind: n.Join}, nil
+	case "aggregate", "having":
+		a := &Aggregate{Method: n.Method, Table: n.Table, Column: n.Column, Alias: n.Alias}
+		if !(&Function{Name: string(a.Method)}).aggregate() {
+			return nil, fmt.Errorf("unsupported aggregate method: %s", n.Method)
+		}
+		if n.Type == "aggregate" {
+			return a, nil
+		}
+		if !isOperator(n.Operator) {
+			return nil, fmt.Errorf("unsupported Operator: %s", n.Operator)
+		}
+		v, err := n.Value.value()
+		if err != nil {
+			return nil, err
+		}
+		return &Having{Aggregate: a, Operator: n.Operator, Value: v}, nil
+	case "limit":
+		return NewLimit(n.Number), nil
+	case "offset":
+		return NewOffset(n.Number), nil
+	case "select":
+		if len(n.Columns) == 0 {
+			return nil, fmt.Errorf("select without columns")
+		}
+		return &Select{columns: n.Columns}, nil
+	case "count":
+		return NewCount(), nil
+	case "relation":
+		if len(n.Tables) == 0 {
+			return nil, fmt.Errorf("relation without tables")
+		}
+		var mods []Modifier
+		for _, item := range n.Items {
+			m, err := item.Expression()
+			if err != nil {
+				return nil, err
+			}
+			mods = append(mods, m)
+		}
+		return &Relation{tables: n.Tables, mods: mods}, nil
+	}
+	return nil, fmt.Errorf("unsupported expression type: %q", n.Type)
+}
+
+func (n *Node) conditions(nodes []*Node) ([]Condition, error) {
+	items := make([]Condition, 0, len(nodes))
+	for _, node := range nodes {
+		m, err := node.Expression()
+		if err != nil {
+			return nil, err
+		}
+		c, ok := m.(Condition)
+		if !ok {
+			return nil, fmt.Errorf("condition is expected inside of %s instead of %s", n.Type, node.Type)
+		}
+		items = append(items, c)
+	}
+	return items, nil
+}
+
+func nodeOfSearch(t string, s *Search) (*Node, error) {
+	v, err := valueOf(s.Query)
+	if err != nil {
+		return nil, err
+	}
+	return &Node{Type: t, Table: s.Table, Columns: s.Columns, Value: v}, nil
+}
+
+func nodeOfItems(t string, conditions []Condition) (*Node, error) {
+	n := &Node{Type: t, Items: make([]*Node, 0, len(conditions))}
+	for _, c := range conditions {
+		item, err := NodeOf(c)
+		if err != nil {
+			return nil, err
+		}
+		n.Items = append(n.Items, item)
+	}
+	return n, nil
+}
+
+// valueOf converts the value of where into its JSON form, the type of value is kept
+func valueOf(value interface{}) (*Value, error) {
+	switch v := value.(type) {
+	case nil:
+		return &Value{Type: "null"}, nil
+	case time.Time:
+		return rawValueOf("datetime", v.Format(time.RFC3339Nano))
+	case time.Duration:
+		return rawValueOf("duration", int64(v))
+	case *Field:
+		return &Value{Type: "field", Table: v.Table, Column: v.Column, Path: v.Path}, nil
+	case *Function:
+		f := &Value{Type: "function", Name: v.Name, Args: make([]*Value, 0, len(v.Args))}
+		for _, a := range v.Args {
+			arg, err := valueOf(a)
+			if err != nil {
+				return nil, err
+			}
+			f.Args = append(f.Args, arg)
+		}
+		return f, nil
+	case []interface{}:
+		l := &Value{Type: "list", Items: make([]*Value, 0, len(v))}
+		for _, item := range v {
+			i, err := valueOf(item)
+			if err != nil {
+				return nil, err
+			}
+			l.Items = append(l.Items, i)
+		}
+		return l, nil
+	}
+
+	switch k := reflect.TypeOf(value).Kind(); k {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		if reflect.TypeOf(value) != valueTypes[k.String()] {
+			return nil, fmt.Errorf("unsupported value: %T", value)
+		}
+		return rawValueOf(k.String(), value)
+	}
+
+	return nil, fmt.Errorf("unsupported value: %T", value)
+}
+
+func rawValueOf(t string, value interface{}) (*Value, error) {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return &Value{Type: t, Value: b}, nil
+}
+
+// value converts the JSON form back into the value of its type
+func (v *Value) value() (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	switch v.Type {
+	case "null":
+		return nil, nil
+	case "datetime":
+		var s string
+		if err := json.Unmarshal(v.Value, &s); err != nil {
+			return nil, fmt.Errorf("incorrect datetime: %w", err)
+		}
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return nil, fmt.Errorf("incorrect datetime: %w", err)
+		}
+		return t, nil
+	case "duration":
+		var d int64
+		if err := json.Unmarshal(v.Value, &d); err != nil {
+			return nil, fmt.Errorf("incorrect duration: %w", err)
+		}
+		return time.Duration(d), nil
+	case "field":
+		if err := identifiers(v.Table, v.Column); err != nil {
+			return nil, err
+		}
+		return &Field{Table: v.Table, Column: v.Column, Path: v.Path}, nil
+	case "function":
+		args := make([]interface{}, 0, len(v.Args))
+		for _, a := range v.Args {
+			arg, err := a.value()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+		}
+		return NewFunction(v.Name, args...)
+	case "list":
+		items := make([]interface{}, 0, len(v.Items))
+		for _, i := range v.Items {
+			item, err := i.value()
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	}
+
+	t, ok := valueTypes[v.Type]
+	if !ok {
+		return nil, fmt.Errorf("unsupported value type: %q", v.Type)
+	}
+	p := reflect.New(t)
+	if err := json.Unmarshal(v.Value, p.Interface()); err != nil {
+		return nil, fmt.Errorf("incorrect %s value: %w", v.Type, err)
+	}
+	return p.Elem().Interface(), nil
+}
+
+func isOperator(o Operator) bool {
+	switch o {
+	case Equal, NotEqual, GreaterThan, GreaterThanOrEqual, LessThan, LessThanOrEqual, In, NotIn, IsNull, IsNotNull, Contains, StartsWith, EndsWith,
+		Between, NotBetween, IContains, IStartsWith, IEndsWith, NotContains, Matches:
+		return true
+	}
+	return false
+}
+
+// valueTypes are the plain types of values kept by the JSON form, the named types (e.g. enums) are not supported
+var valueTypes = map[string]reflect.Type{
+	"string":  reflect.TypeOf(""),
+	"bool":    reflect.TypeOf(false),
+	"int":     reflect.TypeOf(int(0)),
+	"int8":    reflect.TypeOf(int8(0)),
+	"int16":   reflect.TypeOf(int16(0)),
+	"int32":   reflect.TypeOf(int32(0)),
+	"int64":   reflect.TypeOf(int64(0)),
+	"uint":    reflect.TypeOf(uint(0)),
+	"uint8":   reflect.TypeOf(uint8(0)),
+	"uint16":  reflect.TypeOf(uint16(0)),
+	"uint32":  reflect.TypeOf(uint32(0)),
+	"uint64":  reflect.TypeOf(uint64(0)),
+	"float32": reflect.TypeOf(float32(0)),
+	"float64": reflect.TypeOf(float64(0)),
+}
+
+// identifiers checks the names of tables, columns and aliases by the pattern of identifiers of OData, the empty names are skipped
+func identifiers(names ...string) error {
+	for _, name := range names {
+		if name != "" && !identifierPattern.MatchString(name) {
+			return fmt.Errorf("invalid identifier: %q", name)
+		}
+	}
+	return nil
+}
+
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
@@ -0,0 +1,107 @@
+package expression
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestJSON_NodeOf(t *testing.T) {
+	lower, err := NewFunction("tolower", NewField("user/name"))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		expression Modifier
+	}{
+		{name: "where of string", expression: NewWhere("user/name", Equal, "John")},
+		{name: "where of int64", expression: NewWhere("id", GreaterThan, int64(10))},
+		{name: "where of uint8", expression: NewWhere("level", LessThan, uint8(3))},
+		{name: "where of float32", expression: NewWhere("price", LessThanOrEqual, float32(9.5))},
+		{name: "where of bool", expression: NewWhere("enabled", Equal, true)},
+		{name: "where of null", expression: NewWhere("deleted_at", IsNull)},
+		{name: "where of datetime", expression: NewWhere("created_at", GreaterThan, time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC))},
+		{name: "where of duration", expression: NewWhere("timeout", Equal, 90*time.Second)},
+		{name: "where of list", expression: NewWhere("id", In, 1, "2", 3.5)},
+		{name: "where of between", expression: NewWhere("age", Between, 18, 65)},
+		{name: "where of matches", expression: NewWhere("name", Matches, "^J")},
+		{name: "where of json member", expression: NewWhereOfJSON("metadata", []string{"sizes", "0"}, Equal, "XL")},
+		{name: "where of function over json member", expression: NewWhereWithFunction(&Function{Name: "tolower", Args: []interface{}{&Field{Column: "metadata", Path: []string{"color"}}}}, Equal, "red")},
+		{name: "where of function", expression: NewWhereWithFunction(lower, Equal, &Function{Name: "now", Args: []interface{}{}})},
+		{name: "and, or, not", expression: NewCombinerOR(NewWhere("a", Equal, 1), NewCombinerAND(NewWhere("b", Equal, 2), NewCombinerNOT(NewWhere("c", Equal, 3))))},
+		{name: "lambda", expression: NewLambdaOfRelation(All, "items", "orders", NewWhere("qty", GreaterThan, 0))},
+		{name: "lambda without condition", expression: NewLambda(Any, "tags", nil)},
+		{name: "search", expression: NewSearch("articles", []string{"title", "body"}, "go generics")},
+		{name: "search rank", expression: NewSearchRank(NewSearch("articles", []string{"title"}, "go"))},
+		{name: "join", expression: NewJoinOf("users", "author", "id", "books/author_id", LeftJoin)},
+		{name: "join of column", expression: NewJoinOf("users", "", "id", "user_id", "")},
+		{name: "aggregate", expression: NewAggregate(AggregateSum, "order/amount", "total")},
+		{name: "aggregate of count", expression: NewAggregate(AggregateCount, "", "count")},
+		{name: "having", expression: NewHaving(NewAggregate(AggregateAverage, "amount", "avg"), Between, 1.5, 10.0)},
+		{name: "order by", expression: NewOrderBy("user/name", Descending)},
+		{name: "group by", expression: NewGroupBy("user/name")},
+		{name: "limit", expression: NewLimit(10)},
+		{name: "offset", expression: NewOffset(0)},
+		{name: "select", expression: NewSelect("id", "users.name")},
+		{name: "count", expression: NewCount()},
+		{name: "relation", expression: NewRelationWithMods([]string{"Comments", "Author"}, NewWhere("approved", Equal, true), NewOrderBy("id", Ascending), NewLimit(5))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := NodeOf(tt.expression)
+			require.NoError(t, err)
+
+			b, err := json.Marshal(n)
+			require.NoError(t, err)
+
+			var decoded *Node
+			require.NoError(t, json.Unmarshal(b, &decoded))
+
+			actually, err := decoded.Expression()
+			require.NoError(t, err)
+			require.Equal(t, tt.expression, actually)
+		})
+	}
+
+	t.Run("Schema", func(t *testing.T) {
+		n, err := NodeOf(NewWhere("id", In, int64(1), "2"))
+		require.NoError(t, err)
+		b, err := json.Marshal(n)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"type":"where","column":"id","operator":"in","value":{"type":"list","items":[{"type":"int64","value":1},{"type":"string","value":"2"}]}}`, string(b))
+	})
+	t.Run("Unsupported", func(t *testing.T) {
+		type status string
+		_, err := NodeOf(NewWhere("status", Equal, status("new")))
+		require.Error(t, err)
+		_, err = NodeOf(NewJoin("users", "author", `"author"."id" = "books"."author_id"`, LeftJoin))
+		require.Error(t, err)
+
+		for _, s := range []string{
+			`{"type":"unknown"}`,
+			`{"type":"where","column":"id","operator":"like","value":{"type":"int","value":1}}`,
+			`{"type":"where","column":"id","operator":"eq","value":{"type":"complex","value":1}}`,
+			`{"type":"where","column":"id","operator":"eq","value":{"type":"int","value":"1"}}`,
+			`{"type":"where","column":"id","operator":"eq","function":{"type":"function","name":"unknown"}}`,
+			`{"type":"and","items":[{"type":"where","column":"id","operator":"eq"}]}`,
+			`{"type":"not","items":[{"type":"limit","number":1}]}`,
+			`{"type":"orderby","column":"id","direction":"sideways"}`,
+			`{"type":"where","column":"id\" OR 1=1 --","operator":"eq","value":{"type":"int","value":1}}`,
+			`{"type":"where","table":"users\".\"id","column":"id","operator":"isnull"}`,
+			`{"type":"where","function":{"type":"function","name":"tolower","args":[{"type":"field","column":"name\" --"}]},"operator":"eq","value":{"type":"string","value":"a"}}`,
+			`{"type":"orderby","column":"1; DROP TABLE users","direction":"asc"}`,
+			`{"type":"select","columns":["id\" FROM users --"]}`,
+			`{"type":"search","table":"articles","columns":["title) OR (1=1"],"value":{"type":"string","value":"go"}}`,
+			`{"type":"relation","tables":["Comments\""]}`,
+			`{"type":"join","table":"users","on":"1=1"}`,
+			`{"type":"join","table":"users","column":"id","parent":"books","foreign_key":"author_id\" OR 1=1"}`,
+		} {
+			var n *Node
+			require.NoError(t, json.Unmarshal([]byte(s), &n))
+			_, err = n.Expression()
+			require.Error(t, err, s)
+		}
+	})
+}
@@ -0,0 +1,90 @@
+package expression
+
+import (
+	"github.com/prorochestvo/sqlinjector/internal"
+	"reflect"
+	"strings"
+)
+
+// jsonPath returns the member of JSON column by the path in the syntax of dialect, the column is returned as is without the path:
+// ->> and #>> of PostgreSQL, JSON_EXTRACT of MySQL and json_extract of SQLite.
+// The value compared with the member defines its type, since PostgreSQL and MySQL extract it as a text and a JSON respectively.
+func jsonPath(d internal.Dialect, column string, path []string, value interface{}) string {
+	if len(path) == 0 {
+		return column
+	}
+	if values, ok := value.([]interface{}); ok && len(values) > 0 {
+		value = values[0]
+	}
+
+	switch d {
+	case internal.DialectMySQL:
+		c := "JSON_EXTRACT(" + column + ", '" + jsonPathOf(path) + "')"
+		if jsonKind(value) == "" {
+			c = "JSON_UNQUOTE(" + c + ")"
+		}
+		return c
+	case internal.DialectSQLite3:
+		return "json_extract(" + column + ", '" + jsonPathOf(path) + "')"
+	}
+
+	var c string
+	if len(path) == 1 && !isJSONIndex(path[0]) {
+		c = column + "->>'" + strings.ReplaceAll(path[0], "'", "''") + "'"
+	} else {
+		items := make([]string, len(path))
+		for i, p := range path {
+			items[i] = "\"" + jsonPathEscaper.Replace(p) + "\""
+		}
+		c = column + "#>>'{" + strings.ReplaceAll(strings.Join(items, ","), "'", "''") + "}'"
+	}
+	if k := jsonKind(value); k != "" {
+		c = "(" + c + ")::" + k
+	}
+	return c
+}
+
+// jsonPathOf returns the path of MySQL and SQLite, e.g. $."address"."lines"[0], it is escaped to be placed into single quotes
+func jsonPathOf(path []string) string {
+	var b strings.Builder
+	b.WriteString("$")
+	for _, p := range path {
+		if isJSONIndex(p) {
+			b.WriteString("[" + p + "]")
+			continue
+		}
+		b.WriteString(".\"" + jsonPathEscaper.Replace(p) + "\"")
+	}
+	return strings.ReplaceAll(b.String(), "'", "''")
+}
+
+// jsonKind returns the type of PostgreSQL, which the member is cast to for the comparison with the value, the text is an empty string
+func jsonKind(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	switch reflect.Indirect(reflect.ValueOf(value)).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "numeric"
+	case reflect.Bool:
+		return "boolean"
+	}
+	return ""
+}
+
+// isJSONIndex reports whether the segment of path is the index of array
+func isJSONIndex(segment string) bool {
+	if segment == "" {
+		return false
+	}
+	for _, r := range segment {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+var jsonPathEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
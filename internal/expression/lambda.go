@@ -0,0 +1,146 @@
+package expression
+
+import (
+	"fmt"
+	"github.com/prorochestvo/sqlinjector/internal"
+	"github.com/volatiletech/sqlboiler/v4/drivers"
+	"github.com/volatiletech/sqlboiler/v4/queries"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+	"strings"
+)
+
+// NewLambda makes the lambda operator over the array column, the condition refers to the element as the column "value",
+// e.g. NewLambda(Any, "tags", NewWhere("value", Equal, "x")) is tags/any(t: t eq 'x').
+func NewLambda(kind LambdaKind, column string, condition Condition) *Lambda {
+	return &Lambda{Kind: kind, Column: column, Condition: condition}
+}
+
+// NewLambdaOfRelation makes the lambda operator over the one-to-many relation, the condition refers to the columns of related table,
+// e.g. NewLambdaOfRelation(All, "items", "orders", NewWhere("qty", GreaterThan, 0)) is items/all(i: i/qty gt 0).
+// The related table refers to the parent by the column <singular parent>_id, the tables are named by the naming strategy,
+// so the lambda guesses the relation the same way as the join heuristic and it is rendered by ToSQL only when the heuristic is enabled.
+func NewLambdaOfRelation(kind LambdaKind, relation string, parent string, condition Condition) *Lambda {
+	return &Lambda{Kind: kind, Column: relation, Condition: condition, Relation: true, Parent: parent, ForeignKey: internal.ForeignKey(parent)}
+}
+
+// Lambda is the lambda operator any or all, which is satisfied by any or all elements of the collection.
+// The condition is nil for any() without predicate, it is satisfied by the non-empty collection.
+type Lambda struct {
+	Kind      LambdaKind
+	Column    string
+	Condition Condition
+	// Relation is true when the collection is the one-to-many relation, the array column is used otherwise
+	Relation bool
+	// Parent is the table of entity, which is referred by the ForeignKey column of related table
+	Parent     string
+	ForeignKey string
+}
+
+func (l *Lambda) QueryMod() []qm.QueryMod {
+	return l.QueryModOf(CurrentDialect())
+}
+
+func (l *Lambda) QueryModOf(d internal.Dialect) []qm.QueryMod {
+	_, where := l.clause(d)
+	return []qm.QueryMod{where}
+}
+
+func (l *Lambda) ToString() string {
+	if l.Condition == nil {
+		return fmt.Sprintf("%s/%s()", l.Column, l.Kind)
+	}
+	return fmt.Sprintf("%s/%s(%s)", l.Column, l.Kind, l.Condition.ToString())
+}
+
+func (l *Lambda) clause(d internal.Dialect) ([]qm.QueryMod, qm.QueryMod) {
+	c, args := l.sql(d)
+	return nil, qm.Where(c, args...)
+}
+
+// negate returns the clause of the opposite condition
+func (l *Lambda) negate(d internal.Dialect) ([]qm.QueryMod, qm.QueryMod) {
+	c, args := l.sql(d)
+	return nil, qm.Where("NOT ("+c+")", args...)
+}
+
+// sql renders the lambda by the dialect as EXISTS subquery over the elements, any(t: t eq ?) of PostgreSQL array is rendered as ? = ANY(column)
+func (l *Lambda) sql(d internal.Dialect) (string, []interface{}) {
+	column := joinTableNameAndColumn("", l.Column, nil)
+
+	var from string
+	switch {
+	case l.Relation:
+		related := "\"" + internal.TableName(l.Column) + "\""
+		parent := "\"" + internal.TableName(l.Parent) + "\""
+		from = related + " WHERE " + related + ".\"" + internal.Naming().SnakeCase(l.ForeignKey) + "\" = " + parent + ".\"id\""
+	case d == internal.DialectPostgreSQL:
+		if w, ok := l.Condition.(*Where); ok && l.Kind == Any && w.Operator == Equal && w.Function == nil && w.Column == LambdaValue {
+			if _, isFunction := w.Value.(*Function); !isFunction {
+				return "? = ANY(" + column + ")", []interface{}{w.Value}
+			}
+		}
+		from = "UNNEST(" + column + ") AS \"lambda\"(\"" + LambdaValue + "\") WHERE TRUE"
+	case d == internal.DialectMySQL:
+		from = "JSON_TABLE(" + column + ", '$[*]' COLUMNS (\"" + LambdaValue + "\" LONGTEXT PATH '$')) AS \"lambda\" WHERE TRUE"
+	default:
+		from = "json_each(" + column + ") WHERE TRUE"
+	}
+
+	if l.Condition == nil {
+		return "EXISTS (SELECT 1 FROM " + from + ")", nil
+	}
+
+	c, args := conditionSQL(l.Condition, d)
+	if l.Kind == All {
+		return "NOT EXISTS (SELECT 1 FROM " + from + " AND NOT " + c + ")", args
+	}
+	return "EXISTS (SELECT 1 FROM " + from + " AND " + c + ")", args
+}
+
+// conditionSQL renders the condition without joins into the parenthesized SQL of where clause with "?" placeholders
+func conditionSQL(c Condition, d internal.Dialect) (string, []interface{}) {
+	_, where := c.clause(d)
+	if where == nil {
+		return "(TRUE)", nil
+	}
+	q := &queries.Query{}
+	queries.SetDialect(q, &drivers.Dialect{LQ: '"', RQ: '"'})
+	queries.SetFrom(q, "\"lambda\"")
+	qm.Apply(q, where)
+	s, args := queries.BuildQuery(q)
+	s = s[strings.Index(s, " WHERE ")+len(" WHERE "):]
+	return strings.TrimSuffix(s, ";"), args
+}
+
+// Lambdas returns all lambda operators of the tree
+func Lambdas(c Condition) []*Lambda {
+	switch v := c.(type) {
+	case *Lambda:
+		return []*Lambda{v}
+	case *Not:
+		return Lambdas(v.item)
+	case *And:
+		return lambdasOf(v.items)
+	case *Or:
+		return lambdasOf(v.items)
+	}
+	return nil
+}
+
+func lambdasOf(items []Condition) []*Lambda {
+	res := make([]*Lambda, 0)
+	for _, item := range items {
+		res = append(res, Lambdas(item)...)
+	}
+	return res
+}
+
+type LambdaKind string
+
+const (
+	Any LambdaKind = "any"
+	All LambdaKind = "all"
+)
+
+// LambdaValue is the column of the element of array column inside of the condition of Lambda
+const LambdaValue = "value"
@@ -0,0 +1,112 @@
+package expression
+
+import (
+	"github.com/prorochestvo/sqlinjector/internal"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/sqlboiler/v4/drivers"
+	"github.com/volatiletech/sqlboiler/v4/queries"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+	"testing"
+)
+
+var _ expression = &Lambda{}
+
+func TestLambda_NewWhereFrom(t *testing.T) {
+	c, err := NewWhereFromWithOptions("tags/any(t: t eq 'x' or contains(t,'y')) and items/all(i: i/qty gt 0) and tags/any()", FilterOptions{Table: "orders"})
+	require.NoError(t, err)
+	require.Equal(t, []Condition{
+		&Lambda{Kind: Any, Column: "tags", Condition: &Or{items: []Condition{
+			&Where{Column: LambdaValue, Operator: Equal, Value: "x"},
+			&Where{Column: LambdaValue, Operator: Contains, Value: "y"},
+		}}},
+		&Lambda{Kind: All, Column: "items", Condition: &Where{Column: "qty", Operator: GreaterThan, Value: 0}, Relation: true, Parent: "orders", ForeignKey: "order_id"},
+		&Lambda{Kind: Any, Column: "tags"},
+	}, c)
+
+	for _, filter := range []string{
+		"items/all(i: i/qty gt 0)",
+		"tags/any(t: t eq name)",
+		"tags/any(t: t eq 'x' or t/name eq 'y')",
+		"tags/any(t: tags/any(s: s eq 'x'))",
+		"tags/all()",
+		"tags/any(t t eq 'x')",
+		"order/tags/any(t: t eq 'x')",
+	} {
+		t.Run(filter, func(t *testing.T) {
+			_, err := NewWhereFrom(filter)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestLambda_QueryModOf(t *testing.T) {
+	tests := []struct {
+		name      string
+		condition Condition
+		dialect   internal.Dialect
+		expected  string
+		args      []interface{}
+	}{
+		{
+			name:      "any of PostgreSQL array",
+			condition: NewLambda(Any, "tags", NewWhere(LambdaValue, Equal, "x")),
+			dialect:   internal.DialectPostgreSQL,
+			expected:  `WHERE ($1 = ANY("tags"))`,
+			args:      []interface{}{"x"},
+		},
+		{
+			name:      "all of PostgreSQL array",
+			condition: NewLambda(All, "scores", NewWhere(LambdaValue, GreaterThan, 5)),
+			dialect:   internal.DialectPostgreSQL,
+			expected:  `WHERE (NOT EXISTS (SELECT 1 FROM UNNEST("scores") AS "lambda"("value") WHERE TRUE AND NOT ("value" > $1)))`,
+			args:      []interface{}{5},
+		},
+		{
+			name:      "any of SQLite array",
+			condition: NewLambda(Any, "tags", NewCombinerOR(NewWhere(LambdaValue, In, "x", "y"), NewWhere(LambdaValue, StartsWith, "z"))),
+			dialect:   internal.DialectSQLite3,
+			expected:  `WHERE (EXISTS (SELECT 1 FROM json_each("tags") WHERE TRUE AND ("value" IN ($1,$2) OR "value" LIKE $3 ESCAPE '\')))`,
+			args:      []interface{}{"x", "y", "z%"},
+		},
+		{
+			name:      "any of MySQL array without condition",
+			condition: NewLambda(Any, "tags", nil),
+			dialect:   internal.DialectMySQL,
+			expected:  `WHERE (EXISTS (SELECT 1 FROM JSON_TABLE("tags", '$[*]' COLUMNS ("value" LONGTEXT PATH '$')) AS "lambda" WHERE TRUE))`,
+		},
+		{
+			name:      "all of relation",
+			condition: NewLambdaOfRelation(All, "items", "orders", NewWhere("qty", GreaterThan, 0)),
+			dialect:   internal.DialectPostgreSQL,
+			expected:  `WHERE (NOT EXISTS (SELECT 1 FROM "items" WHERE "items"."order_id" = "orders"."id" AND NOT ("qty" > $1)))`,
+			args:      []interface{}{0},
+		},
+		{
+			name:      "not any of relation",
+			condition: NewCombinerNOT(NewLambdaOfRelation(Any, "Categories", "entries", nil)),
+			dialect:   internal.DialectSQLite3,
+			expected:  `WHERE (NOT (EXISTS (SELECT 1 FROM "categories" WHERE "categories"."entry_id" = "entries"."id")))`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := &queries.Query{}
+			queries.SetDialect(q, &drivers.Dialect{LQ: '"', RQ: '"', UseIndexPlaceholders: true})
+			queries.SetFrom(q, `"orders"`)
+			qm.Apply(q, tt.condition.QueryModOf(tt.dialect)...)
+			actually, args := queries.BuildQuery(q)
+			require.Contains(t, actually, tt.expected)
+			require.Equal(t, tt.args, args)
+		})
+	}
+}
+
+func TestLambda_NamingStrategy(t *testing.T) {
+	internal.SetNaming(internal.BoilNaming{Prefix: "app_"})
+	t.Cleanup(func() { internal.SetNaming(nil) })
+
+	c, args := NewLambdaOfRelation(Any, "OrderItems", "orders", NewWhere("qty", GreaterThan, 0)).sql(internal.DialectPostgreSQL)
+	require.Equal(t, `EXISTS (SELECT 1 FROM "app_order_items" WHERE "app_order_items"."order_id" = "app_orders"."id" AND ("qty" > ?))`, c)
+	require.Equal(t, []interface{}{0}, args)
+}
@@ -0,0 +1,102 @@
+package expression
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// scanQuoted reads the quoted string, which starts by the quote, the quote is escaped by doubling.
+// It returns the unquoted string and the number of read runes.
+func scanQuoted(r []rune) (string, int, error) {
+	var b strings.Builder
+	for i := 1; i < len(r); i++ {
+		if r[i] != '\'' {
+			b.WriteRune(r[i])
+			continue
+		}
+		if i+1 < len(r) && r[i+1] == '\'' {
+			b.WriteRune('\'')
+			i++
+			continue
+		}
+		return b.String(), i + 1, nil
+	}
+	return "", 0, fmt.Errorf("unterminated string")
+}
+
+// scanLiteral reads the literal of OData from the beginning of the string: GUID, datetime, date or number.
+// It returns the text of literal and its value: string of GUID, time.Time, int or float64.
+func scanLiteral(s string) (string, interface{}, error) {
+	if text := guidPattern.FindString(s); text != "" {
+		return text, strings.ToLower(text), nil
+	}
+	if text := dateTimePattern.FindString(s); text != "" {
+		v, err := time.Parse(time.RFC3339Nano, text)
+		if err != nil {
+			v, err = time.Parse("2006-01-02T15:04Z07:00", text)
+		}
+		if err != nil {
+			return "", nil, fmt.Errorf("incorrect datetime %s", text)
+		}
+		return text, v, nil
+	}
+	if text := datePattern.FindString(s); text != "" {
+		v, err := time.Parse(time.DateOnly, text)
+		if err != nil {
+			return "", nil, fmt.Errorf("incorrect date %s", text)
+		}
+		return text, v, nil
+	}
+	if text := numberPattern.FindString(s); text != "" {
+		if !strings.ContainsAny(text, ".eE") {
+			if v, err := strconv.ParseInt(text, 10, 0); err == nil {
+				return text, int(v), nil
+			}
+		}
+		v, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return "", nil, fmt.Errorf("incorrect number %s", text)
+		}
+		return text, v, nil
+	}
+	return "", nil, fmt.Errorf("unexpected %q", []rune(s)[0])
+}
+
+// parseDuration parses the duration of ISO 8601 supported by OData, e.g. P1DT2H30M or -PT0.5S
+func parseDuration(s string) (time.Duration, error) {
+	m := durationPattern.FindStringSubmatch(s)
+	if m == nil || s == "P" || s == "-P" || strings.HasSuffix(s, "T") {
+		return 0, fmt.Errorf("incorrect duration %s", s)
+	}
+
+	var d time.Duration
+	for i, unit := range []time.Duration{24 * time.Hour, time.Hour, time.Minute, time.Second} {
+		if m[i+2] == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(m[i+2], 64)
+		if err != nil {
+			return 0, fmt.Errorf("incorrect duration %s", s)
+		}
+		d += time.Duration(v * float64(unit))
+	}
+	if m[1] == "-" {
+		d = -d
+	}
+
+	return d, nil
+}
+
+// guidLength is the length of GUID literal, e.g. 01234567-89ab-cdef-0123-456789abcdef
+const guidLength = 36
+
+var (
+	guidPattern     = regexp.MustCompile(`^[0-9A-Fa-f]{8}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{12}`)
+	dateTimePattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}(:\d{2}(\.\d+)?)?(Z|[+-]\d{2}:\d{2})`)
+	datePattern     = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}`)
+	numberPattern   = regexp.MustCompile(`^-?\d+(\.\d+)?([eE][+-]?\d+)?`)
+	durationPattern = regexp.MustCompile(`^(-)?P(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+(?:\.\d+)?)S)?)?$`)
+)
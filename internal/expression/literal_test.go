@@ -0,0 +1,54 @@
+package expression
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestLiteral_NewWhereFrom(t *testing.T) {
+	tests := []struct {
+		filter   string
+		operator Operator
+		expected interface{}
+	}{
+		{filter: "price gt 9.99", operator: GreaterThan, expected: 9.99},
+		{filter: "age gt -1", operator: GreaterThan, expected: -1},
+		{filter: "score le 1.5e3", operator: LessThanOrEqual, expected: 1500.0},
+		{filter: "active eq true", operator: Equal, expected: true},
+		{filter: "active ne false", operator: NotEqual, expected: false},
+		{filter: "deleted eq null", operator: IsNull, expected: nil},
+		{filter: "deleted ne null", operator: IsNotNull, expected: nil},
+		{filter: "created gt 2024-01-01T00:00:00Z", operator: GreaterThan, expected: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{filter: "created lt 2024-01-01T10:30+02:00", operator: LessThan, expected: time.Date(2024, 1, 1, 8, 30, 0, 0, time.UTC)},
+		{filter: "birthday eq 2000-02-29", operator: Equal, expected: time.Date(2000, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{filter: "ttl ge duration'P1DT2H30M'", operator: GreaterThanOrEqual, expected: 26*time.Hour + 30*time.Minute},
+		{filter: "ttl lt duration'-PT0.5S'", operator: LessThan, expected: -500 * time.Millisecond},
+		{filter: "id eq 0195F1C2-AB34-4cde-8f00-123456789abc", operator: Equal, expected: "0195f1c2-ab34-4cde-8f00-123456789abc"},
+		{filter: "id eq deadbeef-0000-4000-8000-000000000000", operator: Equal, expected: "deadbeef-0000-4000-8000-000000000000"},
+		{filter: "name eq 'O''Brien'", operator: Equal, expected: "O'Brien"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			c, err := NewWhereFrom(tt.filter)
+			require.NoError(t, err)
+			require.Len(t, c, 1)
+			w, ok := c[0].(*Where)
+			require.True(t, ok)
+			require.Equal(t, tt.operator, w.Operator)
+			if e, ok := tt.expected.(time.Time); ok {
+				require.True(t, e.Equal(w.Value.(time.Time)), w.Value)
+				return
+			}
+			require.Equal(t, tt.expected, w.Value)
+		})
+	}
+
+	for _, filter := range []string{"deleted gt null", "ttl eq duration'P'", "ttl eq duration'1D'", "created eq 2024-13-01", "age gt - 1", "name eq 'unterminated"} {
+		t.Run(filter, func(t *testing.T) {
+			_, err := NewWhereFrom(filter)
+			require.Error(t, err)
+		})
+	}
+}
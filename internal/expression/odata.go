@@ -0,0 +1,422 @@
+package expression
+
+import (
+	"fmt"
+	"github.com/prorochestvo/sqlinjector/internal"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ToFilter renders the conditions back to the OData $filter string joined by the "and" operator, it is the reverse of NewWhereFrom.
+func ToFilter(conditions ...Condition) (string, error) {
+	items := make([]string, 0, len(conditions))
+	for _, c := range conditions {
+		s, err := filterOf(c, nil, len(conditions) == 1)
+		if err != nil {
+			return "", err
+		}
+		items = append(items, s)
+	}
+	return strings.Join(items, " and "), nil
+}
+
+// ToOrderBy renders the orders back to the OData $orderby string, it is the reverse of NewOrderByFrom.
+func ToOrderBy(orderBy ...*OrderBy) string {
+	items := make([]string, 0, len(orderBy))
+	for _, o := range orderBy {
+		s := o.Column
+		if o.Table != "" {
+			s = o.Table + "." + s
+		}
+		if o.Direction != defaulting {
+			s += " " + strings.ToLower(string(o.Direction))
+		}
+		items = append(items, s)
+	}
+	return strings.Join(items, ", ")
+}
+
+// ToSelect renders the columns back to the OData $select string, it is the reverse of NewSelectFrom.
+// The tables named by the join heuristic are turned back into the singular names of relations.
+func ToSelect(selects ...*Select) string {
+	items := make([]string, 0)
+	for _, s := range selects {
+		for _, c := range s.columns {
+			if t, column, ok := strings.Cut(c, "."); ok && JoinHeuristic() {
+				c = internal.SingularName(t) + "." + column
+			}
+			items = append(items, c)
+		}
+	}
+	return strings.Join(items, ", ")
+}
+
+// ToApply renders the groups, the aggregates and the filter of groups back to the OData $apply string, it is the reverse of NewApplyFrom,
+// e.g. groupby((status),aggregate(amount with sum as total))/filter(total gt 100). The filter of rows is rendered by ToFilter.
+func ToApply(groupBy []*GroupBy, aggregates []*Aggregate, having []*Having) (string, error) {
+	items := make([]string, 0, len(aggregates))
+	for _, a := range aggregates {
+		switch {
+		case a.Method == AggregateCount && a.Column == "":
+			items = append(items, "$count as "+a.Alias)
+		case a.Method == AggregateCount:
+			return "", fmt.Errorf("count of column %s is not supported by OData, countdistinct or $count is expected", a.Column)
+		default:
+			items = append(items, strings.TrimPrefix(a.Table+"/"+a.Column, "/")+" with "+string(a.Method)+" as "+a.Alias)
+		}
+	}
+
+	var s string
+	switch {
+	case len(groupBy) > 0:
+		columns := make([]string, 0, len(groupBy))
+		for _, g := range groupBy {
+			columns = append(columns, strings.TrimPrefix(g.Table+"."+g.Column, "."))
+		}
+		s = "groupby((" + strings.Join(columns, ",") + ")"
+		if len(items) > 0 {
+			s += ",aggregate(" + strings.Join(items, ",") + ")"
+		}
+		s += ")"
+	case len(items) > 0:
+		s = "aggregate(" + strings.Join(items, ",") + ")"
+	case len(having) > 0:
+		return "", fmt.Errorf("filter of groups without aggregation is not supported by OData")
+	}
+
+	if len(having) > 0 {
+		conditions := make([]Condition, 0, len(having))
+		for _, h := range having {
+			conditions = append(conditions, &Where{Column: h.Aggregate.Alias, Operator: h.Operator, Value: h.Value})
+		}
+		filter, err := ToFilter(conditions...)
+		if err != nil {
+			return "", err
+		}
+		s += "/filter(" + filter + ")"
+	}
+	return s, nil
+}
+
+// ToExpand renders the relations back to the OData $expand string, it is the reverse of NewRelationFrom.
+func ToExpand(relations ...*Relation) (string, error) {
+	items := make([]string, 0, len(relations))
+	for _, r := range relations {
+		options, err := expandOptions(r.mods)
+		if err != nil {
+			return "", err
+		}
+		s := strings.Join(r.tables, "/")
+		if len(options) > 0 {
+			s += "(" + strings.Join(options, ";") + ")"
+		}
+		items = append(items, s)
+	}
+	return strings.Join(items, ","), nil
+}
+
+// expandOptions renders the nested options of relation, the consecutive conditions and orders are merged into one option
+func expandOptions(mods []Modifier) ([]string, error) {
+	options := make([]string, 0, len(mods))
+	for i := 0; i < len(mods); i++ {
+		switch m := mods[i].(type) {
+		case Condition:
+			conditions := []Condition{m}
+			for ; i+1 < len(mods); i++ {
+				c, ok := mods[i+1].(Condition)
+				if !ok {
+					break
+				}
+				conditions = append(conditions, c)
+			}
+			s, err := ToFilter(conditions...)
+			if err != nil {
+				return nil, err
+			}
+			options = append(options, "$filter="+s)
+		case *OrderBy:
+			orderBy := []*OrderBy{m}
+			for ; i+1 < len(mods); i++ {
+				o, ok := mods[i+1].(*OrderBy)
+				if !ok {
+					break
+				}
+				orderBy = append(orderBy, o)
+			}
+			options = append(options, "$orderby="+ToOrderBy(orderBy...))
+		case *Select:
+			options = append(options, "$select="+ToSelect(m))
+		case *Limit:
+			options = append(options, "$top="+strconv.Itoa(m.limit))
+		case *Offset:
+			options = append(options, "$skip="+strconv.Itoa(m.offset))
+		default:
+			return nil, fmt.Errorf("unsupported expand option: %T", m)
+		}
+	}
+	return options, nil
+}
+
+// lambdaVariable is the variable of lambda operator being rendered
+type lambdaVariable struct {
+	name     string
+	relation bool
+}
+
+// filterOf renders the condition, the nested And and Or are enclosed by parentheses unless it is the top level
+func filterOf(c Condition, v *lambdaVariable, top bool) (string, error) {
+	switch c := c.(type) {
+	case *Where:
+		return whereOf(c, v)
+	case *Lambda:
+		return lambdaOf(c, v)
+	case *Not:
+		s, err := filterOf(c.item, v, true)
+		if err != nil {
+			return "", err
+		}
+		return "not (" + s + ")", nil
+	case *And, *Or:
+		var items []Condition
+		var separator string
+		if a, ok := c.(*And); ok {
+			items, separator = a.items, " and "
+		} else {
+			items, separator = c.(*Or).items, " or "
+		}
+		parts := make([]string, 0, len(items))
+		for _, item := range items {
+			s, err := filterOf(item, v, false)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, s)
+		}
+		s := strings.Join(parts, separator)
+		if !top {
+			s = "(" + s + ")"
+		}
+		return s, nil
+	}
+	return "", fmt.Errorf("unsupported condition: %T", c)
+}
+
+func whereOf(w *Where, v *lambdaVariable) (string, error) {
+	var l string
+	var err error
+	if w.Function != nil {
+		if l, err = operandOf(w.Function, v); err != nil {
+			return "", err
+		}
+	} else {
+		l = fieldOf(&Field{Table: w.Table, Column: w.Column, Path: w.Path}, v)
+	}
+
+	switch w.Operator {
+	case IsNull:
+		return l + " eq null", nil
+	case IsNotNull:
+		return l + " ne null", nil
+	case In, NotIn:
+		values, ok := w.Value.([]interface{})
+		if !ok {
+			values = []interface{}{w.Value}
+		}
+		items := make([]string, 0, len(values))
+		for _, value := range values {
+			s, err := literalOf(value)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, s)
+		}
+		s := l + " in (" + strings.Join(items, ",") + ")"
+		if w.Operator == NotIn {
+			s = "not (" + s + ")"
+		}
+		return s, nil
+	case Between, NotBetween:
+		values, ok := w.Value.([]interface{})
+		if !ok || len(values) != 2 {
+			return "", fmt.Errorf("%s expects the pair of values", w.Operator)
+		}
+		lower, err := operandOf(values[0], v)
+		if err != nil {
+			return "", err
+		}
+		upper, err := operandOf(values[1], v)
+		if err != nil {
+			return "", err
+		}
+		s := "(" + l + " ge " + lower + " and " + l + " le " + upper + ")"
+		if w.Operator == NotBetween {
+			s = "not " + s
+		}
+		return s, nil
+	case IContains, IStartsWith, IEndsWith:
+		value := w.Value
+		if s, ok := value.(string); ok {
+			value = strings.ToLower(s)
+		}
+		var operand interface{} = &Field{Table: w.Table, Column: w.Column, Path: w.Path}
+		if w.Function != nil {
+			operand = w.Function
+		}
+		return whereOf(&Where{Operator: caseSensitive[w.Operator], Value: value, Function: &Function{Name: "tolower", Args: []interface{}{operand}}}, v)
+	case NotContains:
+		s, err := whereOf(&Where{Table: w.Table, Column: w.Column, Operator: Contains, Value: w.Value, Function: w.Function, Path: w.Path}, v)
+		if err != nil {
+			return "", err
+		}
+		return "not " + s, nil
+	}
+
+	r, err := operandOf(w.Value, v)
+	if err != nil {
+		return "", err
+	}
+	for name, operator := range filterFunctions {
+		if operator == w.Operator {
+			return name + "(" + l + "," + r + ")", nil
+		}
+	}
+	for name, operator := range filterOperators {
+		if operator == w.Operator {
+			return l + " " + name + " " + r, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported Operator: %s", w.Operator)
+}
+
+func lambdaOf(l *Lambda, v *lambdaVariable) (string, error) {
+	if v != nil {
+		return "", fmt.Errorf("nested lambda operator over %s is not supported", l.Column)
+	}
+	s := l.Column + "/" + string(l.Kind)
+	if l.Condition == nil {
+		return s + "()", nil
+	}
+	v = &lambdaVariable{name: "x", relation: l.Relation}
+	if r := []rune(l.Column)[0]; unicode.IsLetter(r) {
+		v.name = string(unicode.ToLower(r))
+	}
+	c, err := filterOf(l.Condition, v, true)
+	if err != nil {
+		return "", err
+	}
+	return s + "(" + v.name + ": " + c + ")", nil
+}
+
+// operandOf renders the field, the function or the literal
+func operandOf(operand interface{}, v *lambdaVariable) (string, error) {
+	switch o := operand.(type) {
+	case *Field:
+		return fieldOf(o, v), nil
+	case *Function:
+		args := make([]string, 0, len(o.Args))
+		for _, a := range o.Args {
+			s, err := operandOf(a, v)
+			if err != nil {
+				return "", err
+			}
+			args = append(args, s)
+		}
+		return o.Name + "(" + strings.Join(args, ",") + ")", nil
+	}
+	return literalOf(operand)
+}
+
+// fieldOf renders the path of column, the column of lambda is prefixed by its variable
+func fieldOf(f *Field, v *lambdaVariable) string {
+	switch {
+	case v != nil && !v.relation && f.Table == "" && f.Column == LambdaValue:
+		return v.name
+	case v != nil && v.relation && f.Table == "":
+		return v.name + "/" + f.Column
+	case f.Table != "":
+		return pathOf(f.Table+"/"+f.Column, f.Path)
+	}
+	return pathOf(f.Column, f.Path)
+}
+
+// pathOf appends the path of member of JSON column to the column
+func pathOf(column string, path []string) string {
+	if len(path) == 0 {
+		return column
+	}
+	return column + "/" + strings.Join(path, "/")
+}
+
+// literalOf renders the value as the OData literal, the floats keep the decimal point to be parsed back as floats
+func literalOf(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "null", nil
+	case string:
+		return "'" + strings.ReplaceAll(v, "'", "''") + "'", nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	case time.Duration:
+		return "duration'" + durationOf(v) + "'", nil
+	}
+
+	rv := reflect.ValueOf(value)
+	switch {
+	case rv.CanInt():
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case rv.CanUint():
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	case rv.CanFloat():
+		f := rv.Float()
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			return "", fmt.Errorf("unsupported value: %v", f)
+		}
+		s := strconv.FormatFloat(f, 'g', -1, 64)
+		if !strings.ContainsAny(s, ".eE") {
+			s += ".0"
+		}
+		return s, nil
+	}
+
+	return "", fmt.Errorf("unsupported value: %T", value)
+}
+
+// durationOf renders the duration of ISO 8601, e.g. P1DT2H30M or -PT0.5S
+func durationOf(d time.Duration) string {
+	var sb strings.Builder
+	if d < 0 {
+		sb.WriteString("-")
+		d = -d
+	}
+	sb.WriteString("P")
+	days := d / (24 * time.Hour)
+	if d -= days * 24 * time.Hour; days > 0 {
+		sb.WriteString(strconv.FormatInt(int64(days), 10) + "D")
+		if d == 0 {
+			return sb.String()
+		}
+	}
+	sb.WriteString("T")
+	if h := d / time.Hour; h > 0 {
+		sb.WriteString(strconv.FormatInt(int64(h), 10) + "H")
+		d -= h * time.Hour
+	}
+	if m := d / time.Minute; m > 0 {
+		sb.WriteString(strconv.FormatInt(int64(m), 10) + "M")
+		d -= m * time.Minute
+	}
+	if d > 0 || strings.HasSuffix(sb.String(), "PT") {
+		s := strconv.FormatInt(int64(d/time.Second), 10)
+		if ns := d % time.Second; ns > 0 {
+			s += "." + strings.TrimRight(fmt.Sprintf("%09d", int64(ns)), "0")
+		}
+		sb.WriteString(s + "S")
+	}
+	return sb.String()
+}
@@ -0,0 +1,170 @@
+package expression
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOData_ToFilter(t *testing.T) {
+	tests := []struct {
+		name       string
+		conditions []Condition
+		expected   string
+	}{
+		{name: "comparison", conditions: []Condition{NewWhere("age", GreaterThanOrEqual, 18)}, expected: "age ge 18"},
+		{name: "quoted string", conditions: []Condition{NewWhere("user/name", Equal, "O'Neil")}, expected: "user/name eq 'O''Neil'"},
+		{name: "float", conditions: []Condition{NewWhere("price", LessThan, 10.0)}, expected: "price lt 10.0"},
+		{name: "null", conditions: []Condition{NewWhere("deleted_at", IsNull), NewWhere("note", IsNotNull)}, expected: "deleted_at eq null and note ne null"},
+		{name: "function", conditions: []Condition{NewWhere("name", StartsWith, "Jo")}, expected: "startswith(name,'Jo')"},
+		{name: "in", conditions: []Condition{NewWhere("id", In, 1, 2), NewWhere("status", NotIn, "new")}, expected: "id in (1,2) and not (status in ('new'))"},
+		{name: "datetime", conditions: []Condition{NewWhere("created_at", GreaterThan, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))}, expected: "created_at gt 2024-01-02T03:04:05Z"},
+		{name: "between", conditions: []Condition{NewWhere("age", Between, 18, 65), NewWhere("age", NotBetween, 1, 2)}, expected: "(age ge 18 and age le 65) and not (age ge 1 and age le 2)"},
+		{name: "case-insensitive", conditions: []Condition{NewWhere("name", IStartsWith, "Jo")}, expected: "startswith(tolower(name),'jo')"},
+		{name: "not contains", conditions: []Condition{NewWhere("name", NotContains, "Jo")}, expected: "not contains(name,'Jo')"},
+		{name: "matches", conditions: []Condition{NewWhere("name", Matches, "^J")}, expected: "matchespattern(name,'^J')"},
+		{name: "duration", conditions: []Condition{NewWhere("timeout", Equal, 26*time.Hour+90*time.Second)}, expected: "timeout eq duration'P1DT2H1M30S'"},
+		{
+			name:       "combiners",
+			conditions: []Condition{NewCombinerOR(NewWhere("a", Equal, 1), NewCombinerAND(NewWhere("b", Equal, 2), NewCombinerNOT(NewWhere("c", Equal, 3))))},
+			expected:   "a eq 1 or (b eq 2 and not (c eq 3))",
+		},
+		{
+			name:       "lambda",
+			conditions: []Condition{NewLambda(Any, "tags", NewWhere(LambdaValue, Equal, "x")), NewLambdaOfRelation(All, "items", "orders", NewWhere("qty", GreaterThan, 0))},
+			expected:   "tags/any(t: t eq 'x') and items/all(i: i/qty gt 0)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actually, err := ToFilter(tt.conditions...)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, actually)
+		})
+	}
+
+	_, err := ToFilter(NewWhere("id", Equal, 1, 2))
+	require.Error(t, err)
+	_, err = ToFilter(NewWhere("id", Equal, struct{}{}))
+	require.Error(t, err)
+}
+
+func TestOData_RoundTrip(t *testing.T) {
+	options := FilterOptions{Table: "orders"}
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		filter := randomFilter(r, 3)
+		t.Run(filter, func(t *testing.T) {
+			expected, err := NewWhereFromWithOptions(filter, options)
+			require.NoError(t, err)
+
+			s, err := ToFilter(expected...)
+			require.NoError(t, err)
+
+			actually, err := NewWhereFromWithOptions(s, options)
+			require.NoError(t, err)
+			require.Equal(t, expected, actually)
+
+			again, err := ToFilter(actually...)
+			require.NoError(t, err)
+			require.Equal(t, s, again)
+		})
+	}
+
+	t.Run("OrderBy", func(t *testing.T) {
+		expected, err := NewOrderByFrom("name desc, user.created_at asc, id")
+		require.NoError(t, err)
+		actually, err := NewOrderByFrom(ToOrderBy(expected...))
+		require.NoError(t, err)
+		require.Equal(t, expected, actually)
+	})
+	t.Run("Select", func(t *testing.T) {
+		expected, err := NewSelectFrom("id, user.name, userGroup/title, author.*")
+		require.NoError(t, err)
+		actually, err := NewSelectFrom(ToSelect(expected))
+		require.NoError(t, err)
+		require.Equal(t, expected, actually)
+	})
+	t.Run("Expand", func(t *testing.T) {
+		expected, err := NewRelationFrom("Author,Comments($filter=approved eq true and (id gt 1 or id lt -1);$orderby=id desc;$top=5;$skip=1;$select=id,title;$expand=Author)", 2, "Author", "Comments", "Comments.Author")
+		require.NoError(t, err)
+		s, err := ToExpand(expected...)
+		require.NoError(t, err)
+		require.Equal(t, "Author,Comments($filter=approved eq true and (id gt 1 or id lt -1);$orderby=id desc;$top=5;$skip=1;$select=id, title),Comments/Author", s)
+		actually, err := NewRelationFrom(s, 2, "Author", "Comments", "Comments.Author")
+		require.NoError(t, err)
+		require.Equal(t, expected, actually)
+	})
+}
+
+// randomFilter generates the random filter string of the given depth of combiners
+func randomFilter(r *rand.Rand, depth int) string {
+	if depth > 0 {
+		switch r.Intn(6) {
+		case 0:
+			return randomFilter(r, depth-1) + " and " + randomFilter(r, depth-1)
+		case 1:
+			return randomFilter(r, depth-1) + " or " + randomFilter(r, depth-1)
+		case 2:
+			return "not " + randomFilter(r, depth-1)
+		case 3:
+			return "(" + randomFilter(r, depth-1) + ")"
+		}
+	}
+
+	fields := []string{"name", "age", "author/name", "price", "created_at"}
+	operators := []string{"eq", "ne", "gt", "ge", "lt", "le"}
+	field := fields[r.Intn(len(fields))]
+	switch r.Intn(7) {
+	case 0:
+		return fmt.Sprintf("%s(%s,%s)", []string{"contains", "startswith", "endswith"}[r.Intn(3)], field, randomString(r))
+	case 1:
+		values := make([]string, 1+r.Intn(3))
+		for i := range values {
+			values[i] = randomLiteral(r)
+		}
+		return fmt.Sprintf("%s in (%s)", field, strings.Join(values, ","))
+	case 2:
+		return fmt.Sprintf("%s %s null", field, operators[r.Intn(2)])
+	case 3:
+		return fmt.Sprintf("%s %s %s", []string{"tolower(name)", "length(trim(name))", "year(created_at)", "substring(name,1,2)"}[r.Intn(4)], operators[r.Intn(len(operators))], randomLiteral(r))
+	case 4:
+		return fmt.Sprintf("tags/%s(t: t %s %s or startswith(t,%s))", []string{"any", "all"}[r.Intn(2)], operators[r.Intn(len(operators))], randomLiteral(r), randomString(r))
+	case 5:
+		return fmt.Sprintf("items/%s(i: i/qty %s %s and i/deleted_at eq null)", []string{"any", "all"}[r.Intn(2)], operators[r.Intn(len(operators))], randomLiteral(r))
+	}
+	return fmt.Sprintf("%s %s %s", field, operators[r.Intn(len(operators))], randomLiteral(r))
+}
+
+func randomLiteral(r *rand.Rand) string {
+	switch r.Intn(8) {
+	case 0:
+		return fmt.Sprintf("%d", r.Intn(2000)-1000)
+	case 1:
+		return fmt.Sprintf("%.2f", r.Float64()*100)
+	case 2:
+		return fmt.Sprintf("%de%d", r.Intn(9)+1, r.Intn(30))
+	case 3:
+		return time.Unix(r.Int63n(2e9), r.Int63n(1e9)).UTC().Format(time.RFC3339Nano)
+	case 4:
+		return time.Unix(r.Int63n(2e9), 0).In(time.FixedZone("", 3*3600)).Format("2006-01-02T15:04Z07:00")
+	case 5:
+		return time.Unix(r.Int63n(2e9), 0).UTC().Format(time.DateOnly)
+	case 6:
+		return fmt.Sprintf("duration'P%dDT%dH%dM%d.%dS'", r.Intn(3), r.Intn(24), r.Intn(60), r.Intn(60), r.Intn(1000))
+	}
+	return randomString(r)
+}
+
+func randomString(r *rand.Rand) string {
+	letters := []rune("ab c'Щ%_0-")
+	s := make([]rune, r.Intn(6))
+	for i := range s {
+		s[i] = letters[r.Intn(len(letters))]
+	}
+	return "'" + strings.ReplaceAll(string(s), "'", "''") + "'"
+}
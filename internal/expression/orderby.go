@@ -86,8 +86,7 @@ func (o *OrderBy) QueryMod() []qm.QueryMod {
 func (o *OrderBy) ToString() string {
 	c := o.Column
 	if o.Table != "" {
-		t := toSnakeCase(o.Table)
-		c = toPluralize(t) + "." + c
+		c = TableName(o.Table) + "." + c
 	}
 
 	var d string
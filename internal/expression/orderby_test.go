@@ -101,6 +101,8 @@ func TestOrderBy_OrderBy(t *testing.T) {
 }
 
 func TestOrderBy_QueryMod(t *testing.T) {
+	SetJoinHeuristic(true)
+	defer SetJoinHeuristic(false)
 
 	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
 		NamingStrategy: schema.NamingStrategy{
@@ -3,9 +3,33 @@ package expression
 import (
 	"fmt"
 	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+	"regexp"
+	"slices"
 	"strings"
 )
 
+// NewRelationFrom parses an OData $expand string into relations, e.g. "Author,Comments($filter=approved eq true;$top=5)".
+// The nested relations are separated by "/" or expanded by the nested $expand option, the nesting is limited by depth.
+// The relations are checked by the allowed paths (e.g. "Author", "Comments.Author"), no one is allowed when the list is empty.
+// The supported nested options are $filter, $orderby, $select, $top, $skip and $expand.
+func NewRelationFrom(expr string, depth int, allowed ...string) ([]*Relation, error) {
+	return newRelationFrom(expr, nil, depth, allowed, ExpandOptions{})
+}
+
+// NewRelationFromWithOptions parses an OData $expand string the same way as NewRelationFrom, the options restrict the nested $filter and $top.
+func NewRelationFromWithOptions(expr string, depth int, options ExpandOptions, allowed ...string) ([]*Relation, error) {
+	return newRelationFrom(expr, nil, depth, allowed, options)
+}
+
+// ExpandOptions restricts the nested options of $expand
+type ExpandOptions struct {
+	FilterOptions
+	// MaxLimit rejects the nested $top, which exceeds it, 0 means no limit.
+	MaxLimit int
+	// MaxPageSize caps the nested $top, 0 means no cap.
+	MaxPageSize int
+}
+
 func NewRelation(table string, tables ...string) *Relation {
 	t := make([]string, 0, len(tables)+1)
 	t = append(t, table)
@@ -13,18 +37,192 @@ func NewRelation(table string, tables ...string) *Relation {
 	return &Relation{tables: t}
 }
 
+// NewRelationWithMods makes the relation, which loaded entities are restricted by the expressions (e.g. Where, OrderBy, Limit)
+func NewRelationWithMods(tables []string, mods ...Modifier) *Relation {
+	return &Relation{tables: tables, mods: mods}
+}
+
+// Modifier is an expression, which could restrict the loaded entities of relation
+type Modifier interface {
+	QueryMod() []qm.QueryMod
+}
+
 type Relation struct {
 	tables []string
+	mods   []Modifier
 }
 
 func (r *Relation) Relation() []string {
 	return r.tables
 }
 
+func (r *Relation) Mods() []Modifier {
+	return r.mods
+}
+
 func (r *Relation) QueryMod() []qm.QueryMod {
-	return []qm.QueryMod{qm.Load(qm.Rels(r.tables...))}
+	var mods []qm.QueryMod
+	for _, m := range r.mods {
+		mods = append(mods, m.QueryMod()...)
+	}
+	return []qm.QueryMod{qm.Load(qm.Rels(r.tables...), mods...)}
 }
 
 func (r *Relation) ToString() string {
-	return fmt.Sprintf("JOIN %s", strings.Join(r.tables, ", "))
+	s := fmt.Sprintf("JOIN %s", strings.Join(r.tables, ", "))
+	if len(r.mods) == 0 {
+		return s
+	}
+	mods := make([]string, 0, len(r.mods))
+	for _, m := range r.mods {
+		if v, ok := m.(interface{ ToString() string }); ok {
+			mods = append(mods, v.ToString())
+		}
+	}
+	return s + " (" + strings.Join(mods, "; ") + ")"
+}
+
+func newRelationFrom(expr string, parent []string, depth int, allowed []string, options ExpandOptions) ([]*Relation, error) {
+	items, err := splitTopLevel(expr, ',')
+	if err != nil {
+		return nil, err
+	}
+
+	relations := make([]*Relation, 0, len(items))
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		name, nestedOptions := item, ""
+		if i := strings.IndexByte(item, '('); i >= 0 {
+			if !strings.HasSuffix(item, ")") {
+				return nil, fmt.Errorf("invalid expand format: %q", item)
+			}
+			name, nestedOptions = strings.TrimSpace(item[:i]), item[i+1:len(item)-1]
+		}
+		if !relationPattern.MatchString(name) {
+			return nil, fmt.Errorf("invalid expand format: %q", item)
+		}
+
+		path := append(append(make([]string, 0, len(parent)+1), parent...), strings.Split(name, "/")...)
+		if len(path) > depth {
+			return nil, fmt.Errorf("expand depth of %s exceeds the maximum %d", strings.Join(path, "."), depth)
+		}
+		if !slices.Contains(allowed, strings.Join(path, ".")) {
+			return nil, fmt.Errorf("relation %s is not expandable", strings.Join(path, "."))
+		}
+
+		var mods []Modifier
+		var nested []*Relation
+		if nestedOptions != "" {
+			if mods, nested, err = relationOptions(nestedOptions, path, depth, allowed, options); err != nil {
+				return nil, err
+			}
+		}
+
+		relations = append(relations, NewRelationWithMods(path, mods...))
+		relations = append(relations, nested...)
+	}
+
+	return relations, nil
 }
+
+// relationOptions parses the nested options of $expand separated by ";"
+func relationOptions(nested string, path []string, depth int, allowed []string, options ExpandOptions) ([]Modifier, []*Relation, error) {
+	items, err := splitTopLevel(nested, ';')
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var mods []Modifier
+	var relations []*Relation
+	for _, item := range items {
+		k, v, ok := strings.Cut(strings.TrimSpace(item), "=")
+		if !ok {
+			return nil, nil, fmt.Errorf("invalid expand option: %q", item)
+		}
+		v = strings.TrimSpace(v)
+		switch strings.ToLower(strings.TrimSpace(k)) {
+		case "$filter":
+			var conditions []Condition
+			if conditions, err = NewWhereFromWithOptions(v, options.FilterOptions); err != nil {
+				return nil, nil, err
+			}
+			for _, c := range conditions {
+				mods = append(mods, c)
+			}
+		case "$orderby":
+			var orderBy []*OrderBy
+			if orderBy, err = NewOrderByFrom(v); err != nil {
+				return nil, nil, err
+			}
+			for _, o := range orderBy {
+				mods = append(mods, o)
+			}
+		case "$select":
+			var s *Select
+			if s, err = NewSelectFrom(v); err != nil {
+				return nil, nil, err
+			}
+			mods = append(mods, s)
+		case "$top":
+			var limit []*Limit
+			if limit, err = NewLimitFrom(v); err != nil {
+				return nil, nil, err
+			}
+			for _, l := range limit {
+				if options.MaxLimit > 0 && l.Limit() > options.MaxLimit {
+					return nil, nil, &ComplexityError{Measure: MeasureLimit, Max: options.MaxLimit}
+				}
+				if options.MaxPageSize > 0 && l.Limit() > options.MaxPageSize {
+					l = NewLimit(options.MaxPageSize)
+				}
+				mods = append(mods, l)
+			}
+		case "$skip":
+			var offset []*Offset
+			if offset, err = NewOffsetFrom(v); err != nil {
+				return nil, nil, err
+			}
+			for _, o := range offset {
+				mods = append(mods, o)
+			}
+		case "$expand":
+			var expanded []*Relation
+			if expanded, err = newRelationFrom(v, path, depth, allowed, options); err != nil {
+				return nil, nil, err
+			}
+			relations = append(relations, expanded...)
+		default:
+			return nil, nil, fmt.Errorf("unsupported expand option: %s", k)
+		}
+	}
+
+	return mods, relations, nil
+}
+
+// splitTopLevel splits the string by the separator, which is not enclosed by parentheses or quotes
+func splitTopLevel(s string, separator rune) ([]string, error) {
+	items := make([]string, 0)
+	level, quoted, start := 0, false, 0
+	for i, c := range s {
+		switch {
+		case c == '\'':
+			quoted = !quoted
+		case quoted:
+		case c == '(':
+			level++
+		case c == ')':
+			if level--; level < 0 {
+				return nil, fmt.Errorf("unbalanced parentheses at position %d", i)
+			}
+		case c == separator && level == 0:
+			items = append(items, s[start:i])
+			start = i + 1
+		}
+	}
+	if level != 0 || quoted {
+		return nil, fmt.Errorf("unbalanced parentheses or quotes: %q", s)
+	}
+	return append(items, s[start:]), nil
+}
+
+var relationPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(/[A-Za-z_][A-Za-z0-9_]*)*$`)
@@ -24,6 +24,68 @@ func TestRelation_NewRelation(t *testing.T) {
 
 }
 
+func TestRelation_NewRelationFrom(t *testing.T) {
+	allowed := []string{"Author", "Comments", "Comments.Author", "Comments.Replies", "Comments.Replies.Author"}
+
+	tests := []struct {
+		name     string
+		expr     string
+		depth    int
+		expected []*Relation
+		hasError bool
+	}{
+		{
+			name:     "Relations",
+			expr:     "Author, Comments",
+			depth:    1,
+			expected: []*Relation{{tables: []string{"Author"}}, {tables: []string{"Comments"}}},
+		},
+		{
+			name:  "Nested options",
+			expr:  "Comments($filter=approved eq 'true';$top=5;$skip=1;$orderby=id desc;$select=id,text;$expand=Author,Replies($top=2))",
+			depth: 3,
+			expected: []*Relation{
+				{tables: []string{"Comments"}, mods: []Modifier{
+					&Where{Column: "approved", Operator: Equal, Value: "true"},
+					&Limit{limit: 5},
+					&Offset{offset: 1},
+					&OrderBy{Column: "id", Direction: Descending},
+					&Select{columns: []string{"id", "text"}},
+				}},
+				{tables: []string{"Comments", "Author"}},
+				{tables: []string{"Comments", "Replies"}, mods: []Modifier{&Limit{limit: 2}}},
+			},
+		},
+		{
+			name:     "Path of relations",
+			expr:     "Comments/Author",
+			depth:    2,
+			expected: []*Relation{{tables: []string{"Comments", "Author"}}},
+		},
+		{name: "Not allowed relation", expr: "Password", depth: 1, hasError: true},
+		{name: "Not allowed nested relation", expr: "Comments($expand=Tenant)", depth: 2, hasError: true},
+		{name: "Exceeded depth", expr: "Comments($expand=Replies($expand=Author))", depth: 2, hasError: true},
+		{name: "Unsupported option", expr: "Comments($count=true)", depth: 1, hasError: true},
+		{name: "Invalid filter", expr: "Comments($filter=approved)", depth: 1, hasError: true},
+		{name: "Unbalanced parentheses", expr: "Comments($top=5", depth: 1, hasError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actually, err := NewRelationFrom(tt.expr, tt.depth, allowed...)
+			if tt.hasError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, actually)
+		})
+	}
+
+	_, err := NewRelationFrom("Author", 1)
+	require.Error(t, err)
+}
+
 func TestRelation_Relation(t *testing.T) {
 	relation := &Relation{
 		tables: []string{"users", "orders", "payments"},
@@ -54,6 +116,14 @@ func TestRelation_QueryMod(t *testing.T) {
 	result := relation.QueryMod()
 
 	require.Equal(t, expected, result)
+
+	relation = NewRelationWithMods([]string{"comments"}, NewLimit(5), NewOrderBy("id", Descending))
+
+	expected = []qm.QueryMod{
+		qm.Load("comments", qm.Limit(5), qm.OrderBy(`"id" DESC`)),
+	}
+
+	require.Equal(t, expected, relation.QueryMod())
 }
 
 func TestRelation_ToStringToString(t *testing.T) {
@@ -0,0 +1,187 @@
+package expression
+
+import (
+	"fmt"
+	"github.com/prorochestvo/sqlinjector/internal"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+	"strings"
+	"unicode"
+)
+
+// NewSearch makes the full-text search of the query over the text columns of the table, the row is matched when it has all words of the query.
+// The query is split into words by SearchTokens, so the operators of full-text syntax of database are not supported, e.g. the quotes or +word.
+func NewSearch(table string, columns []string, query string) *Search {
+	return &Search{Table: table, Columns: columns, Query: query}
+}
+
+// Search is the full-text search, it is rendered by the dialect:
+// to_tsvector(...) @@ plainto_tsquery(...) of PostgreSQL, MATCH (...) AGAINST (...) of MySQL and MATCH of FTS5 virtual table of SQLite.
+// The indexes (and the virtual table of SQLite) are created by SearchMigration.
+type Search struct {
+	Table   string
+	Columns []string
+	Query   string
+}
+
+func (s *Search) QueryMod() []qm.QueryMod {
+	return s.QueryModOf(CurrentDialect())
+}
+
+func (s *Search) QueryModOf(d internal.Dialect) []qm.QueryMod {
+	_, where := s.clause(d)
+	if where == nil {
+		return nil
+	}
+	return []qm.QueryMod{where}
+}
+
+func (s *Search) ToString() string {
+	return fmt.Sprintf("search(%s) %s", strings.Join(s.Columns, ", "), s.Query)
+}
+
+// clause returns nil, when the query has no words, since the search does not restrict the rows
+func (s *Search) clause(d internal.Dialect) ([]qm.QueryMod, qm.QueryMod) {
+	c, arg, ok := s.sql(d)
+	if !ok {
+		return nil, nil
+	}
+	return nil, qm.Where(c, arg)
+}
+
+// negate returns the clause of the opposite condition
+func (s *Search) negate(d internal.Dialect) ([]qm.QueryMod, qm.QueryMod) {
+	c, arg, ok := s.sql(d)
+	if !ok {
+		return nil, nil
+	}
+	return nil, qm.Where("NOT ("+c+")", arg)
+}
+
+// sql renders the search condition and its argument: the words of query in the syntax of dialect
+func (s *Search) sql(d internal.Dialect) (string, interface{}, bool) {
+	tokens := SearchTokens(s.Query)
+	if len(tokens) == 0 {
+		return "", nil, false
+	}
+	switch d {
+	case internal.DialectMySQL:
+		return "MATCH (" + s.columns() + ") AGAINST (? IN BOOLEAN MODE)", "+" + strings.Join(tokens, " +"), true
+	case internal.DialectSQLite3:
+		t := s.virtualTable()
+		return "\"" + s.Table + "\".\"rowid\" IN (SELECT \"rowid\" FROM " + t + " WHERE " + t + " MATCH ?)", "\"" + strings.Join(tokens, "\" \"") + "\"", true
+	}
+	return s.vector() + " @@ plainto_tsquery('" + searchConfiguration + "', ?)", strings.Join(tokens, " "), true
+}
+
+// columns returns the quoted columns of table separated by comma
+func (s *Search) columns() string {
+	items := make([]string, len(s.Columns))
+	for i, c := range s.Columns {
+		items[i] = "\"" + s.Table + "\".\"" + c + "\""
+	}
+	return strings.Join(items, ", ")
+}
+
+// vector returns tsvector of PostgreSQL over the columns, it is the same as the expression of index made by SearchMigration
+func (s *Search) vector() string {
+	items := make([]string, len(s.Columns))
+	for i, c := range s.Columns {
+		items[i] = "coalesce(\"" + s.Table + "\".\"" + c + "\", '')"
+	}
+	return "to_tsvector('" + searchConfiguration + "', " + strings.Join(items, " || ' ' || ") + ")"
+}
+
+// virtualTable returns the quoted name of FTS5 virtual table of SQLite
+func (s *Search) virtualTable() string {
+	return "\"" + s.Table + "_search\""
+}
+
+// NewSearchRank makes the ordering by the relevance of the search, the most relevant rows are the first ones
+func NewSearchRank(s *Search) *SearchRank {
+	return &SearchRank{Search: s}
+}
+
+// SearchRank orders the rows by the relevance of the search: ts_rank of PostgreSQL, MATCH (...) AGAINST (...) of MySQL and rank of FTS5 of SQLite
+type SearchRank struct {
+	Search *Search
+}
+
+func (r *SearchRank) QueryMod() []qm.QueryMod {
+	return r.QueryModOf(CurrentDialect())
+}
+
+func (r *SearchRank) QueryModOf(d internal.Dialect) []qm.QueryMod {
+	s := r.Search
+	tokens := SearchTokens(s.Query)
+	if len(tokens) == 0 {
+		return nil
+	}
+	switch d {
+	case internal.DialectMySQL:
+		return []qm.QueryMod{qm.OrderBy("MATCH ("+s.columns()+") AGAINST (? IN BOOLEAN MODE) DESC", "+"+strings.Join(tokens, " +"))}
+	case internal.DialectSQLite3:
+		// the rank of FTS5 is negative, the lower is the more relevant
+		t := s.virtualTable()
+		return []qm.QueryMod{qm.OrderBy("COALESCE((SELECT \"rank\" FROM "+t+" WHERE "+t+" MATCH ? AND \"rowid\" = \""+s.Table+"\".\"rowid\"), 0)", "\""+strings.Join(tokens, "\" \"")+"\"")}
+	}
+	return []qm.QueryMod{qm.OrderBy("ts_rank("+s.vector()+", plainto_tsquery('"+searchConfiguration+"', ?)) DESC", strings.Join(tokens, " "))}
+}
+
+// SearchTokens splits the query into the lower-case words, the rest of characters are separators
+func SearchTokens(query string) []string {
+	return strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// SearchMigration returns the scripts, which create and drop the indexes required by Search over the columns of table:
+// GIN index of PostgreSQL, FULLTEXT index of MySQL, FTS5 virtual table of SQLite with the triggers keeping it in sync with the table.
+// The scripts are idempotent except the index of MySQL, which does not support IF NOT EXISTS.
+func SearchMigration(d internal.Dialect, table string, columns []string) (string, string, error) {
+	if len(columns) == 0 {
+		return "", "", fmt.Errorf("search of %s without columns", table)
+	}
+	s := NewSearch(table, columns, "")
+	index := table + "_search"
+
+	switch d {
+	case internal.DialectPostgreSQL:
+		// the columns of index are not qualified by the table, it is the same expression for the planner
+		vector := strings.ReplaceAll(s.vector(), "\""+table+"\".", "")
+		up := "CREATE INDEX IF NOT EXISTS \"" + index + "\" ON \"" + table + "\" USING GIN (" + vector + ");"
+		down := "DROP INDEX IF EXISTS \"" + index + "\";"
+		return up, down, nil
+	case internal.DialectMySQL:
+		up := "ALTER TABLE `" + table + "` ADD FULLTEXT INDEX `" + index + "` (`" + strings.Join(columns, "`, `") + "`);"
+		down := "ALTER TABLE `" + table + "` DROP INDEX `" + index + "`;"
+		return up, down, nil
+	case internal.DialectSQLite3:
+		names := "\"" + strings.Join(columns, "\", \"") + "\""
+		values := func(row string) string {
+			return row + ".\"rowid\", " + row + ".\"" + strings.Join(columns, "\", "+row+".\"") + "\""
+		}
+		t := s.virtualTable()
+		up := "CREATE VIRTUAL TABLE IF NOT EXISTS " + t + " USING fts5(" + names + ", content='" + table + "', content_rowid='rowid');\n" +
+			"CREATE TRIGGER IF NOT EXISTS \"" + index + "_insert\" AFTER INSERT ON \"" + table + "\" BEGIN\n" +
+			"  INSERT INTO " + t + " (\"rowid\", " + names + ") VALUES (" + values("new") + ");\n" +
+			"END;\n" +
+			"CREATE TRIGGER IF NOT EXISTS \"" + index + "_delete\" AFTER DELETE ON \"" + table + "\" BEGIN\n" +
+			"  INSERT INTO " + t + " (" + t + ", \"rowid\", " + names + ") VALUES ('delete', " + values("old") + ");\n" +
+			"END;\n" +
+			"CREATE TRIGGER IF NOT EXISTS \"" + index + "_update\" AFTER UPDATE ON \"" + table + "\" BEGIN\n" +
+			"  INSERT INTO " + t + " (" + t + ", \"rowid\", " + names + ") VALUES ('delete', " + values("old") + ");\n" +
+			"  INSERT INTO " + t + " (\"rowid\", " + names + ") VALUES (" + values("new") + ");\n" +
+			"END;\n" +
+			"INSERT INTO " + t + " (" + t + ") VALUES ('rebuild');"
+		down := "DROP TRIGGER IF EXISTS \"" + index + "_insert\";\n" +
+			"DROP TRIGGER IF EXISTS \"" + index + "_delete\";\n" +
+			"DROP TRIGGER IF EXISTS \"" + index + "_update\";\n" +
+			"DROP TABLE IF EXISTS " + t + ";"
+		return up, down, nil
+	}
+
+	return "", "", fmt.Errorf("unsupported dialect: %s", d)
+}
+
+// searchConfiguration is the text search configuration of PostgreSQL, it lower-cases the words without stemming
+const searchConfiguration = "simple"
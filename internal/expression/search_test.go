@@ -0,0 +1,123 @@
+package expression
+
+import (
+	"github.com/prorochestvo/sqlinjector/internal"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/sqlboiler/v4/drivers"
+	"github.com/volatiletech/sqlboiler/v4/queries"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+	"testing"
+)
+
+var _ expression = &Search{}
+
+func TestSearch_QueryModOf(t *testing.T) {
+	search := NewSearch("articles", []string{"title", "body"}, "Go, +generics!")
+
+	tests := []struct {
+		name     string
+		mod      DialectExpression
+		dialect  internal.Dialect
+		expected string
+		args     []interface{}
+	}{
+		{
+			name:     "PostgreSQL",
+			mod:      search,
+			dialect:  internal.DialectPostgreSQL,
+			expected: `WHERE (to_tsvector('simple', coalesce("articles"."title", '') || ' ' || coalesce("articles"."body", '')) @@ plainto_tsquery('simple', $1));`,
+			args:     []interface{}{"go generics"},
+		},
+		{
+			name:     "MySQL",
+			mod:      search,
+			dialect:  internal.DialectMySQL,
+			expected: `WHERE (MATCH ("articles"."title", "articles"."body") AGAINST ($1 IN BOOLEAN MODE));`,
+			args:     []interface{}{"+go +generics"},
+		},
+		{
+			name:     "SQLite",
+			mod:      search,
+			dialect:  internal.DialectSQLite3,
+			expected: `WHERE ("articles"."rowid" IN (SELECT "rowid" FROM "articles_search" WHERE "articles_search" MATCH $1));`,
+			args:     []interface{}{`"go" "generics"`},
+		},
+		{
+			name:     "not of PostgreSQL",
+			mod:      NewCombinerNOT(search),
+			dialect:  internal.DialectPostgreSQL,
+			expected: `WHERE (NOT (to_tsvector('simple', coalesce("articles"."title", '') || ' ' || coalesce("articles"."body", '')) @@ plainto_tsquery('simple', $1)));`,
+			args:     []interface{}{"go generics"},
+		},
+		{
+			name:     "without words",
+			mod:      NewCombinerAND(NewSearch("articles", []string{"title"}, " ? "), NewWhere("id", Equal, 1)),
+			dialect:  internal.DialectSQLite3,
+			expected: `WHERE ("id" = $1);`,
+			args:     []interface{}{1},
+		},
+		{
+			name:     "rank of PostgreSQL",
+			mod:      NewSearchRank(search),
+			dialect:  internal.DialectPostgreSQL,
+			expected: `ORDER BY ts_rank(to_tsvector('simple', coalesce("articles"."title", '') || ' ' || coalesce("articles"."body", '')), plainto_tsquery('simple', $1)) DESC;`,
+			args:     []interface{}{"go generics"},
+		},
+		{
+			name:     "rank of MySQL",
+			mod:      NewSearchRank(search),
+			dialect:  internal.DialectMySQL,
+			expected: `ORDER BY MATCH ("articles"."title", "articles"."body") AGAINST ($1 IN BOOLEAN MODE) DESC;`,
+			args:     []interface{}{"+go +generics"},
+		},
+		{
+			name:     "rank of SQLite",
+			mod:      NewSearchRank(search),
+			dialect:  internal.DialectSQLite3,
+			expected: `ORDER BY COALESCE((SELECT "rank" FROM "articles_search" WHERE "articles_search" MATCH $1 AND "rowid" = "articles"."rowid"), 0);`,
+			args:     []interface{}{`"go" "generics"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := &queries.Query{}
+			queries.SetDialect(q, &drivers.Dialect{LQ: '"', RQ: '"', UseIndexPlaceholders: true})
+			queries.SetFrom(q, `"articles"`)
+			qm.Apply(q, tt.mod.QueryModOf(tt.dialect)...)
+			actually, args := queries.BuildQuery(q)
+			require.Contains(t, actually, tt.expected)
+			require.Equal(t, tt.args, args)
+		})
+	}
+}
+
+func TestSearch_SearchTokens(t *testing.T) {
+	require.Equal(t, []string{"go", "1", "22", "дженерики"}, SearchTokens(` "Go" 1.22 -Дженерики*`))
+	require.Empty(t, SearchTokens(" +-* "))
+}
+
+func TestSearch_SearchMigration(t *testing.T) {
+	up, down, err := SearchMigration(internal.DialectPostgreSQL, "articles", []string{"title", "body"})
+	require.NoError(t, err)
+	require.Equal(t, `CREATE INDEX IF NOT EXISTS "articles_search" ON "articles" USING GIN (to_tsvector('simple', coalesce("title", '') || ' ' || coalesce("body", '')));`, up)
+	require.Equal(t, `DROP INDEX IF EXISTS "articles_search";`, down)
+
+	up, down, err = SearchMigration(internal.DialectMySQL, "articles", []string{"title", "body"})
+	require.NoError(t, err)
+	require.Equal(t, "ALTER TABLE `articles` ADD FULLTEXT INDEX `articles_search` (`title`, `body`);", up)
+	require.Equal(t, "ALTER TABLE `articles` DROP INDEX `articles_search`;", down)
+
+	up, down, err = SearchMigration(internal.DialectSQLite3, "articles", []string{"title", "body"})
+	require.NoError(t, err)
+	require.Contains(t, up, `CREATE VIRTUAL TABLE IF NOT EXISTS "articles_search" USING fts5("title", "body", content='articles', content_rowid='rowid');`)
+	require.Contains(t, up, `INSERT INTO "articles_search" ("rowid", "title", "body") VALUES (new."rowid", new."title", new."body");`)
+	require.Contains(t, up, `INSERT INTO "articles_search" ("articles_search", "rowid", "title", "body") VALUES ('delete', old."rowid", old."title", old."body");`)
+	require.Contains(t, up, `CREATE TRIGGER IF NOT EXISTS "articles_search_insert" AFTER INSERT ON "articles" BEGIN`)
+	require.Contains(t, down, `DROP TABLE IF EXISTS "articles_search";`)
+
+	_, _, err = SearchMigration(internal.DialectSQLite3, "articles", nil)
+	require.Error(t, err)
+	_, _, err = SearchMigration("oracle", "articles", []string{"title"})
+	require.Error(t, err)
+}
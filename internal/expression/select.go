@@ -3,9 +3,31 @@ package expression
 import (
 	"fmt"
 	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+	"regexp"
 	"strings"
 )
 
+// NewSelectFrom parses a comma-separated list of columns, e.g. "id, name, user.email".
+func NewSelectFrom(expr string) (*Select, error) {
+	items := strings.Split(expr, ",")
+	columns := make([]string, 0, len(items))
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if !selectPattern.MatchString(item) {
+			return nil, fmt.Errorf("invalid select format: %q", item)
+		}
+		t, c := "", item
+		if i := strings.IndexAny(item, "./"); i >= 0 {
+			t, c = item[:i], item[i+1:]
+		}
+		if t != "" {
+			c = tableReference(t) + "." + c
+		}
+		columns = append(columns, c)
+	}
+	return &Select{columns: columns}, nil
+}
+
 func NewSelect(column string, columns ...string) *Select {
 	c := make([]string, 0, len(columns)+1)
 	c = append(c, column)
@@ -28,3 +50,5 @@ func (s *Select) QueryMod() []qm.QueryMod {
 func (s *Select) ToString() string {
 	return fmt.Sprintf("Select %s", strings.Join(s.columns, ", "))
 }
+
+var selectPattern = regexp.MustCompile(`^(\*|[A-Za-z_][A-Za-z0-9_]*([./]([A-Za-z_][A-Za-z0-9_]*|\*))?)$`)
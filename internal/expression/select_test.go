@@ -22,6 +22,36 @@ func TestSelect_NewSelect(t *testing.T) {
 	require.Equal(t, expectedColumns, s.Select())
 }
 
+func TestSelect_NewSelectFrom(t *testing.T) {
+	SetJoinHeuristic(true)
+	defer SetJoinHeuristic(false)
+
+	tests := []struct {
+		name     string
+		expr     string
+		expected []string
+		hasError bool
+	}{
+		{name: "Columns", expr: "id, name,age", expected: []string{"id", "name", "age"}},
+		{name: "Columns of tables", expr: "User.name, order/id, *", expected: []string{"users.name", "orders.id", "*"}},
+		{name: "Empty column", expr: "id,,name", hasError: true},
+		{name: "Injection", expr: "id; DROP TABLE users", hasError: true},
+		{name: "Expression", expr: "COUNT(id)", hasError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewSelectFrom(tt.expr)
+			if tt.hasError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, s.Select())
+		})
+	}
+}
+
 func TestSelect_Select(t *testing.T) {
 	s := NewSelect("id")
 	expected := []string{"id"}
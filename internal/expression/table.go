@@ -1,9 +1,9 @@
 package expression
 
 import (
+	"github.com/prorochestvo/sqlinjector/internal"
 	"github.com/volatiletech/sqlboiler/v4/queries/qm"
 	"strings"
-	"unicode"
 )
 
 func extractTableNameAndColumn(column string) (string, string) {
@@ -17,65 +17,34 @@ func extractTableNameAndColumn(column string) (string, string) {
 	return t, c
 }
 
+// TableName returns the name of table for the given model name by the naming strategy, e.g. "UserGroup" is turned into "user_groups".
+func TableName(model string) string {
+	return internal.TableName(model)
+}
+
+// joinTableNameAndColumn returns the quoted column qualified by the table, the table is referred as is (e.g. the alias of Join),
+// unless the join heuristic is enabled: the table is named by the naming strategy and joined by its foreign key, e.g. "users"."id" = "user_id".
 func joinTableNameAndColumn(table, column string, mods *[]qm.QueryMod) string {
 	c := "\"" + column + "\""
 
-	// TODO: rethink regarding this block, it is not cover all cases!
 	if table != "" {
-		t := toSnakeCase(table)
-		pluralTable := "\"" + toPluralize(t) + "\""
+		if !JoinHeuristic() {
+			return "\"" + table + "\"." + c
+		}
+		pluralTable := "\"" + TableName(table) + "\""
 		c = pluralTable + "." + c
 		if mods != nil {
-			*mods = append(*mods, qm.InnerJoin(pluralTable+" ON "+pluralTable+".\"id\" = \""+t+"_id\""))
+			*mods = append(*mods, qm.InnerJoin(pluralTable+" ON "+pluralTable+".\"id\" = \""+internal.ForeignKey(table)+"\""))
 		}
 	}
 
 	return c
 }
 
-func toPluralize(word string) string {
-	if plural, found := irregularNouns[word]; found {
-		return plural
+// tableReference returns the reference to the table of column, it is the table named by the naming strategy when the join heuristic is enabled
+func tableReference(table string) string {
+	if !JoinHeuristic() {
+		return table
 	}
-
-	// Regular nouns
-	if strings.HasSuffix(word, "s") || strings.HasSuffix(word, "x") || strings.HasSuffix(word, "z") || strings.HasSuffix(word, "ch") || strings.HasSuffix(word, "sh") {
-		return word + "es"
-	} else if strings.HasSuffix(word, "y") && !strings.ContainsAny(string(word[len(word)-2]), "aeiou") {
-		return word[:len(word)-1] + "ies"
-	} else if strings.HasSuffix(word, "f") {
-		return word[:len(word)-1] + "ves"
-	} else if strings.HasSuffix(word, "fe") {
-		return word[:len(word)-2] + "ves"
-	} else {
-		return word + "s"
-	}
-}
-
-func toSnakeCase(str string) string {
-	result := make([]rune, 0, len(str))
-	for i, r := range str {
-		if unicode.IsUpper(r) {
-			if i != 0 {
-				result = append(result, '_')
-			}
-			result = append(result, unicode.ToLower(r))
-		} else {
-			result = append(result, r)
-		}
-	}
-	return string(result)
-}
-
-// irregularNouns is a map of irregular nouns and their plural forms
-// IMPORTANT: This map is not exhaustive and only contains the most common irregular nouns.
-var irregularNouns = map[string]string{
-	"man":    "men",
-	"woman":  "women",
-	"child":  "children",
-	"tooth":  "teeth",
-	"foot":   "feet",
-	"person": "people",
-	"mouse":  "mice",
-	"goose":  "geese",
+	return TableName(table)
 }
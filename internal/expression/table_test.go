@@ -1,6 +1,7 @@
 package expression
 
 import (
+	"github.com/prorochestvo/sqlinjector/internal"
 	"github.com/stretchr/testify/require"
 	"github.com/volatiletech/sqlboiler/v4/queries/qm"
 	"testing"
@@ -29,10 +30,31 @@ func TestTable_ExtractTableNameAndColumn(t *testing.T) {
 	}
 }
 
+func TestTable_TableName(t *testing.T) {
+	require.Equal(t, "users", TableName("User"))
+	require.Equal(t, "user_groups", TableName("UserGroup"))
+	require.Equal(t, "categories", TableName("category"))
+	require.Equal(t, "boxes", TableName("Box"))
+	require.Equal(t, "people", TableName("Person"))
+	require.Equal(t, "people", TableName("people"))
+
+	internal.SetNaming(internal.BoilNaming{Prefix: "app_"})
+	defer internal.SetNaming(nil)
+	require.Equal(t, "app_user_groups", TableName("UserGroup"))
+	require.Equal(t, "app_user_groups", TableName("app_user_groups"))
+}
+
 func TestTable_JoinTableNameAndColumn(t *testing.T) {
 	var mods []qm.QueryMod
 
-	column := joinTableNameAndColumn("User", "name", &mods)
+	column := joinTableNameAndColumn("author", "name", &mods)
+	require.Equal(t, "\"author\".\"name\"", column)
+	require.Empty(t, mods)
+
+	SetJoinHeuristic(true)
+	defer SetJoinHeuristic(false)
+
+	column = joinTableNameAndColumn("User", "name", &mods)
 	expectedColumn := "\"users\".\"name\""
 	expectedJoin := qm.InnerJoin("\"users\" ON \"users\".\"id\" = \"user_id\"")
 
@@ -40,54 +62,16 @@ func TestTable_JoinTableNameAndColumn(t *testing.T) {
 	require.Len(t, mods, 1)
 	require.Equal(t, expectedJoin, mods[0])
 
+	column = joinTableNameAndColumn("Person", "name", &mods)
+	require.Equal(t, "\"people\".\"name\"", column)
+	require.Equal(t, qm.InnerJoin("\"people\" ON \"people\".\"id\" = \"person_id\""), mods[1])
+
 	column = joinTableNameAndColumn("", "name", nil)
 	expectedColumn = "\"name\""
 
 	require.Equal(t, expectedColumn, column)
 }
 
-func TestTable_ToPluralize(t *testing.T) {
-	tests := []struct {
-		name     string
-		word     string
-		expected string
-	}{
-		{"Pluralize regular word", "box", "boxes"},
-		{"Pluralize word ending with 'y'", "city", "cities"},
-		{"Pluralize irregular noun (leaf)", "leaf", "leaves"},
-		{"Pluralize irregular noun (man)", "man", "men"},
-		{"Pluralize irregular noun (woman)", "woman", "women"},
-		{"Pluralize regular noun", "dog", "dogs"},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			plural := toPluralize(tt.word)
-			require.Equal(t, tt.expected, plural)
-		})
-	}
-}
-
-func TestTable_ToSnakeCase(t *testing.T) {
-	tests := []struct {
-		name     string
-		input    string
-		expected string
-	}{
-		{"Convert UserName to snake_case", "UserName", "user_name"},
-		{"Convert FirstName to snake_case", "FirstName", "first_name"},
-		{"Convert userID to snake_case", "userID", "user_i_d"},
-		{"Leave simple unchanged", "simple", "simple"},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			snake := toSnakeCase(tt.input)
-			require.Equal(t, tt.expected, snake)
-		})
-	}
-}
-
 type expression interface {
 	QueryMod() []qm.QueryMod
 	ToString() string
@@ -1,92 +1,27 @@
 package expression
 
 import (
-	"errors"
 	"fmt"
+	"github.com/prorochestvo/sqlinjector/internal"
 	"github.com/volatiletech/sqlboiler/v4/queries/qm"
-	"regexp"
 	"strings"
 )
 
-// NewWhereFrom parses an Expression filter string into a slice of expression structs
-func NewWhereFrom(filter string) ([]*Where, error) {
-	pattern := regexp.MustCompile(`(\S+)\s*(eq|ne|gt|ge|lt|le|in|contains|startswith|endswith)\s*('[^']*'|[0-9]+)`)
-	matches := pattern.FindAllStringSubmatch(filter, -1)
-
-	if matches == nil {
-		return nil, errors.New("invalid filter format")
-	}
-
-	var expr []*Where
-	for _, match := range matches {
-		f := match[1]
-		o := Operator(match[2])
-		v := match[3]
-
-		switch o {
-		case Equal:
-		case NotEqual:
-		case GreaterThan:
-		case GreaterThanOrEqual:
-		case LessThan:
-		case LessThanOrEqual:
-		//case In:
-		//case NotIn:
-		case Contains:
-		case StartsWith:
-		case EndsWith:
-		//case IsNull:
-		//case IsNotNull:
-		default:
-			return nil, fmt.Errorf("unsupported Operator: %s", match[2])
-		}
-
-		t := ""
-		if strings.Contains(f, ".") {
-			s := strings.SplitN(f, ".", 2)
-			t = s[0]
-			f = s[1]
-		}
-
-		var value interface{}
-		if o == IsNull || o == IsNotNull {
-			value = nil
-		} else if o == In || o == NotIn {
-			v = strings.TrimPrefix(v, "(")
-			v = strings.TrimSuffix(v, ")")
-			v = strings.TrimSpace(v)
-			if strings.HasPrefix(v, "'") && strings.HasSuffix(v, "'") {
-				splitter := regexp.MustCompile(`^'\s*,\s*'$`)
-				parts := splitter.Split(v[1:len(v)-1], -1)
-				slice := make([]interface{}, len(parts))
-				for i, p := range parts {
-					slice[i] = p
-				}
-				value = slice
-				parts = nil
-				splitter = nil
-			} else {
-				parts := strings.Split(v, ",")
-				slice := make([]interface{}, len(parts))
-				for i, p := range parts {
-					slice[i] = strings.TrimSpace(p)
-				}
-				value = slice
-				parts = nil
-			}
-		} else {
-			value = strings.Trim(v, "'")
-		}
+// NewWhereFrom parses an Expression filter string into a slice of conditions joined by the "and" operator
+func NewWhereFrom(filter string) ([]Condition, error) {
+	return NewWhereFromWithOptions(filter, FilterOptions{})
+}
 
-		expr = append(expr, &Where{
-			Table:    t,
-			Column:   f,
-			Operator: o,
-			Value:    value,
-		})
+// NewWhereFromWithOptions parses an Expression filter string the same way as NewWhereFrom, the options restrict the filter.
+func NewWhereFromWithOptions(filter string, options FilterOptions) ([]Condition, error) {
+	c, err := parseFilter(filter, options)
+	if err != nil {
+		return nil, err
 	}
-
-	return expr, nil
+	if a, ok := c.(*And); ok {
+		return a.And(), nil
+	}
+	return []Condition{c}, nil
 }
 
 func NewWhere(column string, operator Operator, values ...interface{}) *Where {
@@ -116,61 +51,137 @@ func NewWhereWithTable(table string, column string, operator Operator, values ..
 	}
 }
 
+// NewWhereOfJSON makes the condition over the member of JSON column, e.g. NewWhereOfJSON("metadata", []string{"color"}, Equal, "red")
+func NewWhereOfJSON(column string, path []string, operator Operator, values ...interface{}) *Where {
+	w := NewWhere(column, operator, values...)
+	w.Path = path
+	return w
+}
+
+// NewWhereWithFunction makes the condition over the result of the function, e.g. tolower(name) eq 'john'
+func NewWhereWithFunction(function *Function, operator Operator, values ...interface{}) *Where {
+	w := NewWhereWithTable("", "", operator, values...)
+	if fields := function.Fields(); len(fields) > 0 {
+		w.Table = fields[0].Table
+		w.Column = fields[0].Column
+	}
+	w.Function = function
+	return w
+}
+
+// DefaultMaxLength is the length of filter string in characters allowed when FilterOptions.MaxLength is not set
+const DefaultMaxLength = 8192
+
+// FilterOptions restricts the filter string parsed by NewWhereFromWithOptions
+type FilterOptions struct {
+	// MaxInLength limits the number of values of in operator, 0 means no limit.
+	MaxInLength int
+	// MaxLength limits the length of filter string in characters, 0 means DefaultMaxLength, a negative value means no limit.
+	MaxLength int
+	// MaxPredicates limits the number of comparisons and functions, 0 means no limit.
+	MaxPredicates int
+	// MaxOrBranches limits the total number of branches of or operators, 0 means no limit.
+	MaxOrBranches int
+	// MaxDepth limits the nesting of parentheses, not and lambda operators, 0 means no limit.
+	MaxDepth int
+	// Table is the table of entity, it is required by the lambda operators over relations, e.g. items/any(i: i/qty gt 0).
+	Table string
+	// JSONColumns are the JSON columns, which members are filtered by the path, e.g. metadata/color eq 'red'.
+	// The column of related table is prefixed by its name, e.g. "author/metadata".
+	JSONColumns []string
+}
+
 type Where struct {
 	Table    string
 	Column   string
 	Operator Operator
 	Value    interface{}
+	// Function is applied to the column when it is specified, the Table and Column keep its first field
+	Function *Function
+	// Path is the path of member of JSON column, the condition is applied to the member
+	Path []string
 }
 
 func (w *Where) Where() string {
-	return joinTableNameAndColumn(w.Table, w.Column, nil)
+	if w.Function != nil {
+		c, _ := w.Function.sql(CurrentDialect())
+		return c
+	}
+	return jsonPath(CurrentDialect(), joinTableNameAndColumn(w.Table, w.Column, nil), w.Path, w.Value)
 }
 
 func (w *Where) QueryMod() []qm.QueryMod {
+	return w.QueryModOf(CurrentDialect())
+}
+
+func (w *Where) QueryModOf(d internal.Dialect) []qm.QueryMod {
+	return w.queryModOf(d, qm.Where)
+}
+
+// queryModOf renders the condition by the clause of query: qm.Where, or qm.Having for the conditions over aggregates
+func (w *Where) queryModOf(d internal.Dialect, clause func(string, ...interface{}) qm.QueryMod) []qm.QueryMod {
 	mods := make([]qm.QueryMod, 0)
 
-	c := joinTableNameAndColumn(w.Table, w.Column, &mods)
+	c, args := w.operand(d, &mods)
+	v, vArgs := "?", []interface{}{w.Value}
+	if f, ok := w.Value.(*Function); ok {
+		v, vArgs = f.sql(d)
+	}
+	args = append(args, vArgs...)
 
 	var m qm.QueryMod
 
 	switch w.Operator {
 	case Equal:
-		m = qm.Where(c+" = ?", w.Value)
+		m = clause(c+" = "+v, args...)
 	case NotEqual:
-		m = qm.Where(c+" <> ?", w.Value)
+		m = clause(c+" <> "+v, args...)
 	case GreaterThan:
-		m = qm.Where(c+" > ?", w.Value)
+		m = clause(c+" > "+v, args...)
 	case GreaterThanOrEqual:
-		m = qm.Where(c+" >= ?", w.Value)
+		m = clause(c+" >= "+v, args...)
 	case LessThan:
-		m = qm.Where(c+" < ?", w.Value)
+		m = clause(c+" < "+v, args...)
 	case LessThanOrEqual:
-		m = qm.Where(c+" <= ?", w.Value)
-	case In:
-		if vInterface, ok := w.Value.([]interface{}); ok {
-			m = qm.WhereIn(c+" IN ?", vInterface...)
-		} else {
-			m = qm.WhereIn(c+" IN ?", w.Value)
+		m = clause(c+" <= "+v, args...)
+	case In, NotIn:
+		values, ok := w.Value.([]interface{})
+		if !ok {
+			values = []interface{}{w.Value}
 		}
-	case NotIn:
-		if vInterface, ok := w.Value.([]interface{}); ok {
-			m = qm.WhereNotIn(c+" IN ?", vInterface...)
+		o := " IN "
+		if w.Operator == NotIn {
+			o = " NOT IN "
+		}
+		if l := len(args) - len(vArgs); l > 0 || w.Function.aggregate() {
+			// the IN clause of sqlboiler expands the first placeholder only and it is not supported by HAVING, so the arguments are placed manually
+			m = clause(c+o+"("+strings.TrimPrefix(strings.Repeat(", ?", len(values)), ", ")+")", append(args[:l], values...)...)
+		} else if w.Operator == In {
+			m = qm.WhereIn(c+" IN ?", values...)
 		} else {
-			m = qm.WhereNotIn(c+" IN ?", w.Value)
+			m = qm.WhereNotIn(c+" NOT IN ?", values...)
+		}
+	case Between, NotBetween:
+		values, ok := w.Value.([]interface{})
+		if !ok || len(values) != 2 {
+			// the condition without the pair of values matches nothing instead of being dropped
+			m = clause(unsatisfiable)
+			break
 		}
-	case Contains:
-		m = qm.Where(c+" LIKE ?", fmt.Sprintf("%%%v%%", w.Value))
-	case StartsWith:
-		m = qm.Where(c+" LIKE ?", fmt.Sprintf("%%%v", w.Value))
-	case EndsWith:
-		m = qm.Where(c+" LIKE ?", fmt.Sprintf("%v%%", w.Value))
+		o := " BETWEEN ? AND ?"
+		if w.Operator == NotBetween {
+			o = " NOT BETWEEN ? AND ?"
+		}
+		m = clause(c+o, append(args[:len(args)-len(vArgs)], values...)...)
+	case Contains, StartsWith, EndsWith, IContains, IStartsWith, IEndsWith, NotContains, Matches:
+		m = w.match(d, clause, c, args[:len(args)-len(vArgs)], false)
 	case IsNull:
-		m = qm.Where(c + " IS NULL")
+		m = clause(c+" IS NULL", args[:len(args)-len(vArgs)]...)
 	case IsNotNull:
-		m = qm.Where(c + " IS NOT NULL")
+		m = clause(c+" IS NOT NULL", args[:len(args)-len(vArgs)]...)
 	default:
-		return nil
+		// the unknown operator matches nothing instead of being dropped
+		m = clause(unsatisfiable)
 	}
 
 	mods = append(mods, m)
@@ -178,13 +189,93 @@ func (w *Where) QueryMod() []qm.QueryMod {
 	return mods
 }
 
+// unsatisfiable is the predicate of malformed condition, e.g. Between without the pair of values, it is false in all dialects
+const unsatisfiable = "1 = 0"
+
+// Negate returns the condition with the opposite operator, false is returned when the operator has no opposite one
+func (w *Where) Negate() (*Where, bool) {
+	o, ok := negations[w.Operator]
+	if !ok {
+		return nil, false
+	}
+	return &Where{Table: w.Table, Column: w.Column, Operator: o, Value: w.Value, Function: w.Function, Path: w.Path}, true
+}
+
+func (w *Where) clause(d internal.Dialect) ([]qm.QueryMod, qm.QueryMod) {
+	mods := w.QueryModOf(d)
+	l := len(mods)
+	if l == 0 {
+		return nil, nil
+	}
+	return mods[:l-1], mods[l-1]
+}
+
+// negate returns the clause of the opposite condition
+func (w *Where) negate(d internal.Dialect) ([]qm.QueryMod, qm.QueryMod) {
+	if n, ok := w.Negate(); ok {
+		return n.clause(d)
+	}
+	switch w.Operator {
+	case StartsWith, EndsWith, IContains, IStartsWith, IEndsWith, Matches:
+		mods := make([]qm.QueryMod, 0)
+		c, args := w.operand(d, &mods)
+		return mods, w.match(d, qm.Where, c, args, true)
+	}
+	return nil, qm.Where(unsatisfiable)
+}
+
+// match returns the clause of pattern matching operators: LIKE, the case-insensitive LIKE of dialect and the regular expression of dialect
+func (w *Where) match(d internal.Dialect, clause func(string, ...interface{}) qm.QueryMod, c string, args []interface{}, negated bool) qm.QueryMod {
+	not := ""
+	if negated {
+		not = "NOT "
+	}
+	switch w.Operator {
+	case IContains, IStartsWith, IEndsWith:
+		if d == internal.DialectPostgreSQL {
+			return clause(c+" "+not+"ILIKE ?"+likeEscape(d), append(args, LikePattern(w.Operator, w.Value))...)
+		}
+		return clause("LOWER("+c+") "+not+"LIKE LOWER(?)"+likeEscape(d), append(args, LikePattern(w.Operator, w.Value))...)
+	case NotContains:
+		return clause(c+" NOT LIKE ?"+likeEscape(d), append(args, LikePattern(w.Operator, w.Value))...)
+	case Matches:
+		switch {
+		case d == internal.DialectPostgreSQL && negated:
+			return clause(c+" !~ ?", append(args, w.Value)...)
+		case d == internal.DialectPostgreSQL:
+			return clause(c+" ~ ?", append(args, w.Value)...)
+		}
+		return clause(c+" "+not+"REGEXP ?", append(args, w.Value)...)
+	}
+	return clause(c+" "+not+"LIKE ?"+likeEscape(d), append(args, LikePattern(w.Operator, w.Value))...)
+}
+
+// operand returns the column or the function over columns with its arguments, the joins of tables are added into mods
+func (w *Where) operand(d internal.Dialect, mods *[]qm.QueryMod) (string, []interface{}) {
+	if w.Function == nil {
+		return jsonPath(d, joinTableNameAndColumn(w.Table, w.Column, mods), w.Path, w.Value), nil
+	}
+	joined := make(map[string]struct{})
+	for _, f := range w.Function.Fields() {
+		if _, ok := joined[f.Table]; ok || f.Table == "" {
+			continue
+		}
+		joined[f.Table] = struct{}{}
+		joinTableNameAndColumn(f.Table, f.Column, mods)
+	}
+	return w.Function.sql(d)
+}
+
 func (w *Where) ToString() string {
-	f := w.Column
-	if w.Table != "" {
-		t := toSnakeCase(w.Table)
-		f = toPluralize(t) + "." + f
+	f := (&Field{Table: w.Table, Column: w.Column, Path: w.Path}).ToString()
+	if w.Function != nil {
+		f = w.Function.ToString()
+	}
+	v := w.Value
+	if fn, ok := v.(*Function); ok {
+		v = fn.ToString()
 	}
-	return fmt.Sprintf("%s %s %v", f, w.Operator, w.Value)
+	return fmt.Sprintf("%s %s %v", f, w.Operator, v)
 }
 
 type Operator string
@@ -203,4 +294,63 @@ const (
 	Contains           Operator = "contains"
 	StartsWith         Operator = "startswith"
 	EndsWith           Operator = "endswith"
+	// Between takes the pair of values: the lower and the upper bounds, both are included
+	Between    Operator = "between"
+	NotBetween Operator = "notBetween"
+	// IContains, IStartsWith and IEndsWith are the case-insensitive variants of Contains, StartsWith and EndsWith
+	IContains   Operator = "icontains"
+	IStartsWith Operator = "istartswith"
+	IEndsWith   Operator = "iendswith"
+	NotContains Operator = "notContains"
+	// Matches takes the regular expression, e.g. ~ of PostgreSQL or REGEXP of MySQL and SQLite (the function regexp of SQLite is registered by the sandbox)
+	Matches Operator = "matches"
 )
+
+var negations = map[Operator]Operator{
+	Equal:              NotEqual,
+	NotEqual:           Equal,
+	GreaterThan:        LessThanOrEqual,
+	GreaterThanOrEqual: LessThan,
+	LessThan:           GreaterThanOrEqual,
+	LessThanOrEqual:    GreaterThan,
+	In:                 NotIn,
+	NotIn:              In,
+	IsNull:             IsNotNull,
+	IsNotNull:          IsNull,
+	Between:            NotBetween,
+	NotBetween:         Between,
+	Contains:           NotContains,
+	NotContains:        Contains,
+}
+
+// caseSensitive maps the case-insensitive operators into the case-sensitive ones
+var caseSensitive = map[Operator]Operator{
+	IContains:   Contains,
+	IStartsWith: StartsWith,
+	IEndsWith:   EndsWith,
+}
+
+// LikeEscape is the escape character of LIKE patterns, it precedes the wildcards % and _ of value
+const LikeEscape = '\\'
+
+// LikePattern makes the pattern of LIKE clause for the operator, the wildcards of value are escaped by LikeEscape
+func LikePattern(o Operator, v interface{}) string {
+	s := likeEscaper.Replace(fmt.Sprintf("%v", v))
+	switch o {
+	case StartsWith, IStartsWith:
+		return s + "%"
+	case EndsWith, IEndsWith:
+		return "%" + s
+	}
+	return "%" + s + "%"
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// likeEscape returns the ESCAPE clause of dialect, the backslash is the escape character of string literals in MySQL
+func likeEscape(d internal.Dialect) string {
+	if d == internal.DialectMySQL {
+		return ` ESCAPE '\\'`
+	}
+	return ` ESCAPE '\'`
+}
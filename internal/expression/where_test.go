@@ -1,7 +1,9 @@
 package expression
 
 import (
+	"fmt"
 	"github.com/stretchr/testify/require"
+	"strings"
 	"testing"
 )
 
@@ -11,34 +13,132 @@ func TestWhere_NewWhereFrom(t *testing.T) {
 	tests := []struct {
 		name     string
 		filter   string
-		expected []*Where
+		expected []Condition
 		hasError bool
 	}{
 		{
 			name:   "Valid filter with eq operator",
 			filter: "User.name eq 'John'",
-			expected: []*Where{
-				{Table: "User", Column: "name", Operator: Equal, Value: "John"},
+			expected: []Condition{
+				&Where{Table: "User", Column: "name", Operator: Equal, Value: "John"},
 			},
 			hasError: false,
 		},
 		{
 			name:   "Valid filter with gt operator",
 			filter: "age gt 30",
-			expected: []*Where{
-				{Table: "", Column: "age", Operator: GreaterThan, Value: "30"},
+			expected: []Condition{
+				&Where{Table: "", Column: "age", Operator: GreaterThan, Value: 30},
 			},
 			hasError: false,
 		},
 		{
 			name:   "Valid filter with multiple conditions",
-			filter: "User.age lt 25, User.name eq 'Alice'",
-			expected: []*Where{
-				{Table: "User", Column: "age", Operator: LessThan, Value: "25"},
-				{Table: "User", Column: "name", Operator: Equal, Value: "Alice"},
+			filter: "User.age lt 25 and User.name eq 'Alice'",
+			expected: []Condition{
+				&Where{Table: "User", Column: "age", Operator: LessThan, Value: 25},
+				&Where{Table: "User", Column: "name", Operator: Equal, Value: "Alice"},
 			},
 			hasError: false,
 		},
+		{
+			name:   "Valid filter with or operator",
+			filter: "age lt 25 or name eq 'Alice' and city eq 'Paris'",
+			expected: []Condition{
+				NewCombinerOR(
+					&Where{Column: "age", Operator: LessThan, Value: 25},
+					NewCombinerAND(
+						&Where{Column: "name", Operator: Equal, Value: "Alice"},
+						&Where{Column: "city", Operator: Equal, Value: "Paris"},
+					),
+				),
+			},
+			hasError: false,
+		},
+		{
+			name:   "Valid filter with parentheses and not operator",
+			filter: "(age lt 25 or name eq 'Alice') and not contains(city,'Paris')",
+			expected: []Condition{
+				NewCombinerOR(
+					&Where{Column: "age", Operator: LessThan, Value: 25},
+					&Where{Column: "name", Operator: Equal, Value: "Alice"},
+				),
+				NewCombinerNOT(&Where{Column: "city", Operator: Contains, Value: "Paris"}),
+			},
+			hasError: false,
+		},
+		{
+			name:     "Valid filter with matchesPattern function",
+			filter:   "matchesPattern(name,'^A.*e$')",
+			expected: []Condition{&Where{Column: "name", Operator: Matches, Value: "^A.*e$"}},
+			hasError: false,
+		},
+		{
+			name:   "Valid filter with escaped quote",
+			filter: "name eq 'O''Neil'",
+			expected: []Condition{
+				&Where{Column: "name", Operator: Equal, Value: "O'Neil"},
+			},
+			hasError: false,
+		},
+		{
+			name:   "Valid filter with functions",
+			filter: "tolower(User/name) eq 'john' and created_at lt now() and contains(trim(name),'o')",
+			expected: []Condition{
+				&Where{Table: "User", Column: "name", Operator: Equal, Value: "john", Function: &Function{Name: "tolower", Args: []interface{}{&Field{Table: "User", Column: "name"}}}},
+				&Where{Column: "created_at", Operator: LessThan, Value: &Function{Name: "now", Args: []interface{}{}}},
+				&Where{Column: "name", Operator: Contains, Value: "o", Function: &Function{Name: "trim", Args: []interface{}{&Field{Column: "name"}}}},
+			},
+			hasError: false,
+		},
+		{
+			name:   "Valid filter with in operator",
+			filter: "status in ('new', 'paid') and not (id in (1,2,3)) and tolower(city) in ('york')",
+			expected: []Condition{
+				&Where{Column: "status", Operator: In, Value: []interface{}{"new", "paid"}},
+				&Not{item: &Where{Column: "id", Operator: In, Value: []interface{}{1, 2, 3}}},
+				&Where{Column: "city", Operator: In, Value: []interface{}{"york"}, Function: &Function{Name: "tolower", Args: []interface{}{&Field{Column: "city"}}}},
+			},
+		},
+		{
+			name:     "Empty list of in operator",
+			filter:   "id in ()",
+			hasError: true,
+		},
+		{
+			name:     "Column in list of in operator",
+			filter:   "id in (1, parent_id)",
+			hasError: true,
+		},
+		{
+			name:     "Null in list of in operator",
+			filter:   "id in (1, null)",
+			hasError: true,
+		},
+		{
+			name:     "Unsupported function",
+			filter:   "unknown(name) eq 'john'",
+			expected: nil,
+			hasError: true,
+		},
+		{
+			name:     "Comparison of columns",
+			filter:   "name eq surname",
+			expected: nil,
+			hasError: true,
+		},
+		{
+			name:     "Unbalanced parentheses",
+			filter:   "(age lt 25 or name eq 'Alice'",
+			expected: nil,
+			hasError: true,
+		},
+		{
+			name:     "Unsupported operator",
+			filter:   "age like 25",
+			expected: nil,
+			hasError: true,
+		},
 		{
 			name:     "Invalid filter format",
 			filter:   "invalidFilter",
@@ -60,6 +160,94 @@ func TestWhere_NewWhereFrom(t *testing.T) {
 	}
 }
 
+func TestWhere_NewWhereFromWithOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  string
+		options FilterOptions
+		measure Measure
+	}{
+		{name: "in length", filter: "id in (1,2,3)", options: FilterOptions{MaxInLength: 3}},
+		{name: "in length exceeded", filter: "id in (1,2,3,4)", options: FilterOptions{MaxInLength: 3}, measure: MeasureInLength},
+		{name: "length", filter: "name eq 'Щ'", options: FilterOptions{MaxLength: 11}},
+		{name: "length exceeded", filter: "name eq 'Щ' ", options: FilterOptions{MaxLength: 11}, measure: MeasureFilterLength},
+		{name: "predicates", filter: "a eq 1 and contains(b,'x') or tags/any(t: t eq 1)", options: FilterOptions{MaxPredicates: 3}},
+		{name: "predicates exceeded", filter: "a eq 1 and contains(b,'x') or tags/any(t: t eq 1 or t eq 2)", options: FilterOptions{MaxPredicates: 3}, measure: MeasurePredicates},
+		{name: "or branches", filter: "a eq 1 or a eq 2 or (b eq 1 and (c eq 1 or c eq 2))", options: FilterOptions{MaxOrBranches: 5}},
+		{name: "or branches exceeded", filter: "a eq 1 or a eq 2 or (b eq 1 and (c eq 1 or c eq 2 or c eq 3))", options: FilterOptions{MaxOrBranches: 5}, measure: MeasureOrBranches},
+		{name: "depth", filter: "not (a eq 1 and (b eq 1))", options: FilterOptions{MaxDepth: 3}},
+		{name: "depth exceeded", filter: "not (a eq 1 and (not b eq 1))", options: FilterOptions{MaxDepth: 3}, measure: MeasureDepth},
+		{name: "depth of lambda exceeded", filter: "(tags/any(t: (t eq 1)))", options: FilterOptions{MaxDepth: 2}, measure: MeasureDepth},
+		{name: "deep parentheses", filter: strings.Repeat("(", 10000) + "a eq 1" + strings.Repeat(")", 10000), options: FilterOptions{MaxDepth: 32, MaxLength: -1}, measure: MeasureDepth},
+		{name: "default length exceeded", filter: "a eq 1" + strings.Repeat(" or a eq 1", DefaultMaxLength/10), measure: MeasureFilterLength},
+		{name: "unlimited length", filter: "a eq 1" + strings.Repeat(" or a eq 1", DefaultMaxLength/10), options: FilterOptions{MaxLength: -1}},
+		{name: "long literals", filter: "a eq 1" + strings.Repeat(" or a eq 12.5 or a eq 2024-01-02 or b eq deadbeef-0000-0000-0000-000000000000 or c eq 'ab'", 2000), options: FilterOptions{MaxLength: -1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewWhereFromWithOptions(tt.filter, tt.options)
+			if tt.measure == "" {
+				require.NoError(t, err)
+				return
+			}
+			var e *ComplexityError
+			require.ErrorAs(t, err, &e)
+			require.Equal(t, tt.measure, e.Measure)
+			require.Equal(t, 400, e.StatusCode())
+		})
+	}
+}
+
+func TestWhere_JSONColumns(t *testing.T) {
+	options := FilterOptions{JSONColumns: []string{"metadata", "author/profile"}}
+	tests := []struct {
+		name     string
+		filter   string
+		expected []Condition
+	}{
+		{
+			name:     "member",
+			filter:   "metadata/color eq 'red'",
+			expected: []Condition{&Where{Column: "metadata", Operator: Equal, Value: "red", Path: []string{"color"}}},
+		},
+		{
+			name:     "nested member of related table",
+			filter:   "author/profile/address/city in ('Paris','Rome')",
+			expected: []Condition{&Where{Table: "author", Column: "profile", Operator: In, Value: []interface{}{"Paris", "Rome"}, Path: []string{"address", "city"}}},
+		},
+		{
+			name:     "member of function",
+			filter:   "contains(tolower(metadata/tags/0),'new')",
+			expected: []Condition{&Where{Column: "metadata", Operator: Contains, Value: "new", Function: &Function{Name: "tolower", Args: []interface{}{&Field{Column: "metadata", Path: []string{"tags", "0"}}}}}},
+		},
+		{
+			name:     "column",
+			filter:   "metadata eq null",
+			expected: []Condition{&Where{Column: "metadata", Operator: IsNull}},
+		},
+		{
+			name:     "not json column",
+			filter:   "user/name eq 'x'",
+			expected: []Condition{&Where{Table: "user", Column: "name", Operator: Equal, Value: "x"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actually, err := NewWhereFromWithOptions(tt.filter, options)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, actually)
+
+			s, err := ToFilter(actually...)
+			require.NoError(t, err)
+			again, err := NewWhereFromWithOptions(s, options)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, again)
+		})
+	}
+}
+
 func TestWhere_NewWhere(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -187,6 +375,9 @@ func TestWhere_NewWhereWithTable(t *testing.T) {
 }
 
 func TestWhere_Where(t *testing.T) {
+	SetJoinHeuristic(true)
+	defer SetJoinHeuristic(false)
+
 	tests := []struct {
 		name     string
 		where    *Where
@@ -228,6 +419,9 @@ func TestWhere_Where(t *testing.T) {
 }
 
 func TestWhere_QueryMod(t *testing.T) {
+	SetJoinHeuristic(true)
+	defer SetJoinHeuristic(false)
+
 	tests := []struct {
 		name     string
 		where    *Where
@@ -329,3 +523,25 @@ func TestWhere_ToString(t *testing.T) {
 		})
 	}
 }
+
+func TestWhere_LikePattern(t *testing.T) {
+	tests := []struct {
+		operator Operator
+		value    interface{}
+		expected string
+	}{
+		{operator: Contains, value: "John", expected: "%John%"},
+		{operator: StartsWith, value: "Jo", expected: "Jo%"},
+		{operator: EndsWith, value: "hn", expected: "%hn"},
+		{operator: IStartsWith, value: "jo", expected: "jo%"},
+		{operator: IEndsWith, value: "HN", expected: "%HN"},
+		{operator: NotContains, value: 42, expected: "%42%"},
+		{operator: Contains, value: `50%_off\`, expected: `%50\%\_off\\%`},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s %v", tt.operator, tt.value), func(t *testing.T) {
+			require.Equal(t, tt.expected, LikePattern(tt.operator, tt.value))
+		})
+	}
+}
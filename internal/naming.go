@@ -0,0 +1,94 @@
+package internal
+
+import (
+	"github.com/volatiletech/strmangle"
+	"strings"
+	"sync/atomic"
+	"unicode"
+)
+
+// NamingStrategy converts the names of models and relations into the names of tables and columns,
+// e.g. the model "UserGroup" is stored in the table "user_groups" and referred by the foreign key "user_group_id".
+type NamingStrategy interface {
+	// Singular returns the singular form of word, e.g. "people" is turned into "person"
+	Singular(word string) string
+	// Plural returns the plural form of word, e.g. "box" is turned into "boxes"
+	Plural(word string) string
+	// SnakeCase returns the name in snake case, e.g. "UserID" is turned into "user_id"
+	SnakeCase(name string) string
+	// TablePrefix returns the prefix of all tables, e.g. "app_"
+	TablePrefix() string
+}
+
+// BoilNaming is the naming of sqlboiler built on strmangle: tables are the plural snake case of models with the prefix
+type BoilNaming struct {
+	Prefix string
+}
+
+func (n BoilNaming) Singular(word string) string {
+	return strmangle.Singular(word)
+}
+
+func (n BoilNaming) Plural(word string) string {
+	return strmangle.Plural(word)
+}
+
+// SnakeCase reverses strmangle.TitleCase, the abbreviations are kept as one word, e.g. "HTTPServer" is turned into "http_server"
+func (n BoilNaming) SnakeCase(name string) string {
+	runes := []rune(name)
+	result := make([]rune, 0, len(runes)+4)
+	for i, r := range runes {
+		if !unicode.IsUpper(r) {
+			result = append(result, r)
+			continue
+		}
+		if i > 0 && runes[i-1] != '_' {
+			previous := runes[i-1]
+			next := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(previous) || unicode.IsDigit(previous) || (unicode.IsUpper(previous) && next) {
+				result = append(result, '_')
+			}
+		}
+		result = append(result, unicode.ToLower(r))
+	}
+	return string(result)
+}
+
+func (n BoilNaming) TablePrefix() string {
+	return n.Prefix
+}
+
+// SetNaming sets the naming strategy of tables and columns, BoilNaming without prefix is used by default
+func SetNaming(n NamingStrategy) {
+	if n == nil {
+		n = BoilNaming{}
+	}
+	naming.Store(&n)
+}
+
+// Naming returns the naming strategy of tables and columns
+func Naming() NamingStrategy {
+	if n := naming.Load(); n != nil {
+		return *n
+	}
+	return BoilNaming{}
+}
+
+// TableName returns the table of model or relation, e.g. "UserGroup", "user_group" and "user_groups" are turned into "user_groups"
+func TableName(name string) string {
+	n := Naming()
+	return n.TablePrefix() + n.Plural(strings.TrimPrefix(n.SnakeCase(name), n.TablePrefix()))
+}
+
+// SingularName returns the singular snake case of model, relation or table without the prefix, e.g. "user_groups" is turned into "user_group"
+func SingularName(name string) string {
+	n := Naming()
+	return n.Singular(strings.TrimPrefix(n.SnakeCase(name), n.TablePrefix()))
+}
+
+// ForeignKey returns the column referring to the table of model or relation, e.g. "people" is turned into "person_id"
+func ForeignKey(name string) string {
+	return SingularName(name) + "_id"
+}
+
+var naming atomic.Pointer[NamingStrategy]
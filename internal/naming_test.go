@@ -0,0 +1,72 @@
+package internal
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+var _ NamingStrategy = BoilNaming{}
+
+func TestBoilNaming_Plural(t *testing.T) {
+	tests := []struct {
+		name     string
+		word     string
+		expected string
+	}{
+		{"Pluralize regular word", "box", "boxes"},
+		{"Pluralize word ending with 'y'", "city", "cities"},
+		{"Pluralize irregular noun (person)", "person", "people"},
+		{"Pluralize irregular noun (man)", "man", "men"},
+		{"Pluralize irregular noun (woman)", "woman", "women"},
+		{"Pluralize regular noun", "dog", "dogs"},
+		{"Pluralize last word of snake case", "user_status", "user_statuses"},
+		{"Keep plural", "boxes", "boxes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plural := BoilNaming{}.Plural(tt.word)
+			require.Equal(t, tt.expected, plural)
+		})
+	}
+}
+
+func TestBoilNaming_SnakeCase(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"Convert UserName to snake_case", "UserName", "user_name"},
+		{"Convert FirstName to snake_case", "FirstName", "first_name"},
+		{"Convert userID to snake_case", "userID", "user_id"},
+		{"Convert HTTPServer to snake_case", "HTTPServer", "http_server"},
+		{"Convert Address2 to snake_case", "Address2Line", "address2_line"},
+		{"Convert TASKS to snake_case", "TASKS", "tasks"},
+		{"Leave snake_case unchanged", "user_group", "user_group"},
+		{"Leave simple unchanged", "simple", "simple"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snake := BoilNaming{}.SnakeCase(tt.input)
+			require.Equal(t, tt.expected, snake)
+		})
+	}
+}
+
+func TestNaming(t *testing.T) {
+	require.Equal(t, BoilNaming{}, Naming())
+	require.Equal(t, "user_groups", TableName("UserGroup"))
+	require.Equal(t, "people", TableName("person"))
+	require.Equal(t, "person", SingularName("People"))
+	require.Equal(t, "box_id", ForeignKey("boxes"))
+
+	SetNaming(BoilNaming{Prefix: "app_"})
+	t.Cleanup(func() { SetNaming(nil) })
+	require.Equal(t, "app_", Naming().TablePrefix())
+	require.Equal(t, "app_user_groups", TableName("UserGroup"))
+	require.Equal(t, "app_user_groups", TableName("app_user_groups"))
+	require.Equal(t, "user_group", SingularName("app_user_groups"))
+	require.Equal(t, "user_group_id", ForeignKey("UserGroup"))
+}
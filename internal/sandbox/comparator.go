@@ -0,0 +1,285 @@
+package sandbox
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	gofrsuuid "github.com/gofrs/uuid"
+	googleuuid "github.com/google/uuid"
+	"github.com/prorochestvo/sqlinjector/internal/expression"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/types"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Comparator describes how the imitator recognizes, evaluates and sorts values of one Go type.
+type Comparator struct {
+	// Normalize converts the field value into the value kept by ImitatorModel, nil means SQL NULL.
+	// The value is kept as is when Normalize is not specified.
+	Normalize func(value interface{}) interface{}
+	// Compare evaluates the operator over the actual value and the expected values.
+	Compare func(operator expression.Operator, actually interface{}, expected []interface{}) (bool, error)
+	// Order returns a negative number when a < b, zero when a == b and a positive number when a > b.
+	Order func(a, b interface{}) (int, error)
+}
+
+// RegisterComparator registers the comparator for values of the given type, the previous one is replaced.
+func RegisterComparator(t reflect.Type, c Comparator) {
+	comparators.Lock()
+	defer comparators.Unlock()
+	comparators.items[t] = c
+}
+
+// LookupComparator returns the comparator registered for the type of the given value.
+func LookupComparator(value interface{}) (Comparator, bool) {
+	if value == nil {
+		return Comparator{}, false
+	}
+	return comparatorOf(reflect.TypeOf(value))
+}
+
+// NewComparator makes the comparator for type T from the three-way compare function.
+// The convert function turns expected values of foreign types (e.g. strings of query) into T, it could be nil.
+func NewComparator[T any](cmp func(a, b T) int, convert func(interface{}) (T, error)) Comparator {
+	cast := func(v interface{}) (T, error) {
+		if val, ok := v.(T); ok {
+			return val, nil
+		}
+		if convert != nil {
+			return convert(v)
+		}
+		var val T
+		return val, fmt.Errorf("incorrect type: %T != %T", v, val)
+	}
+	order := func(a, b interface{}) (int, error) {
+		valA, err := cast(a)
+		if err != nil {
+			return 0, err
+		}
+		valB, err := cast(b)
+		if err != nil {
+			return 0, err
+		}
+		return cmp(valA, valB), nil
+	}
+	compare := func(o expression.Operator, actually interface{}, expected []interface{}) (bool, error) {
+		switch o {
+		case expression.In, expression.NotIn:
+			for _, e := range expected {
+				r, err := order(actually, e)
+				if err != nil {
+					return false, err
+				}
+				if r == 0 {
+					return o == expression.In, nil
+				}
+			}
+			return o == expression.NotIn, nil
+		case expression.Between, expression.NotBetween:
+			if len(expected) != 2 {
+				return false, fmt.Errorf("%s expects the pair of values", o)
+			}
+			lower, err := order(actually, expected[0])
+			if err != nil {
+				return false, err
+			}
+			upper, err := order(actually, expected[1])
+			if err != nil {
+				return false, err
+			}
+			return (lower >= 0 && upper <= 0) == (o == expression.Between), nil
+		case expression.Contains, expression.StartsWith, expression.EndsWith,
+			expression.IContains, expression.IStartsWith, expression.IEndsWith, expression.NotContains, expression.Matches:
+			e := make([]string, len(expected))
+			for i, item := range expected {
+				e[i] = fmt.Sprintf("%v", item)
+			}
+			return compare(o, fmt.Sprintf("%v", actually), e...)
+		}
+		if len(expected) == 0 {
+			return false, fmt.Errorf("expected value is missing for %s", o)
+		}
+		r, err := order(actually, expected[0])
+		if err != nil {
+			return false, err
+		}
+		return compare(o, r, 0)
+	}
+	return Comparator{Compare: compare, Order: order}
+}
+
+// comparatorOf returns the comparator registered for the given type.
+func comparatorOf(t reflect.Type) (Comparator, bool) {
+	comparators.RLock()
+	defer comparators.RUnlock()
+	c, ok := comparators.items[t]
+	return c, ok
+}
+
+var comparators = struct {
+	sync.RWMutex
+	items map[reflect.Type]Comparator
+}{items: make(map[reflect.Type]Comparator)}
+
+func init() {
+	decimalComparator := NewComparator[types.Decimal](
+		func(a, b types.Decimal) int {
+			return a.Cmp(b.Big)
+		},
+		func(v interface{}) (res types.Decimal, err error) {
+			switch val := v.(type) {
+			case types.NullDecimal:
+				if val.Big == nil {
+					return res, fmt.Errorf("incorrect value: %v", v)
+				}
+				res.Big = val.Big
+			case int:
+				err = res.Scan(int64(val))
+			case uint:
+				err = res.Scan(strconv.FormatUint(uint64(val), 10))
+			case int64, float64, string, []byte:
+				err = res.Scan(val)
+			default:
+				err = fmt.Errorf("incorrect type: %T != %T", v, res)
+			}
+			return
+		},
+	)
+
+	decimalComparator.Normalize = func(value interface{}) interface{} {
+		if d := value.(types.Decimal); d.Big != nil {
+			return d
+		}
+		var d types.Decimal
+		_ = d.Scan(int64(0))
+		return d
+	}
+	RegisterComparator(reflect.TypeOf(types.Decimal{}), decimalComparator)
+
+	RegisterComparator(reflect.TypeOf(types.NullDecimal{}), Comparator{
+		Normalize: func(value interface{}) interface{} {
+			if d := value.(types.NullDecimal); d.Big != nil {
+				return types.Decimal{Big: d.Big}
+			}
+			return nil
+		},
+	})
+
+	RegisterComparator(reflect.TypeOf(null.Float32{}), Comparator{
+		Normalize: func(value interface{}) interface{} {
+			if f := value.(null.Float32); f.Valid {
+				// keeps the decimal representation of float32, e.g. 0.1 instead of 0.10000000149011612
+				v, _ := strconv.ParseFloat(strconv.FormatFloat(float64(f.Float32), 'f', -1, 32), 64)
+				return v
+			}
+			return nil
+		},
+	})
+
+	RegisterComparator(reflect.TypeOf(null.Float64{}), Comparator{
+		Normalize: func(value interface{}) interface{} {
+			if f := value.(null.Float64); f.Valid {
+				return f.Float64
+			}
+			return nil
+		},
+	})
+
+	RegisterComparator(reflect.TypeOf(types.JSON{}), NewComparator[types.JSON](compareJSON, convertJSON))
+
+	RegisterComparator(reflect.TypeOf(null.JSON{}), Comparator{
+		Normalize: func(value interface{}) interface{} {
+			if j := value.(null.JSON); j.Valid {
+				return types.JSON(j.JSON)
+			}
+			return nil
+		},
+	})
+
+	RegisterComparator(reflect.TypeOf(googleuuid.UUID{}), NewComparator[googleuuid.UUID](compareUUID[googleuuid.UUID], convertUUID(googleuuid.Parse)))
+	RegisterComparator(reflect.TypeOf(googleuuid.NullUUID{}), Comparator{
+		Normalize: func(value interface{}) interface{} {
+			if u := value.(googleuuid.NullUUID); u.Valid {
+				return u.UUID
+			}
+			return nil
+		},
+	})
+
+	RegisterComparator(reflect.TypeOf(gofrsuuid.UUID{}), NewComparator[gofrsuuid.UUID](compareUUID[gofrsuuid.UUID], convertUUID(gofrsuuid.FromString)))
+	RegisterComparator(reflect.TypeOf(gofrsuuid.NullUUID{}), Comparator{
+		Normalize: func(value interface{}) interface{} {
+			if u := value.(gofrsuuid.NullUUID); u.Valid {
+				return u.UUID
+			}
+			return nil
+		},
+	})
+}
+
+// compareJSON orders JSON documents by their canonical form, so the documents are equal regardless of spaces and order of keys
+func compareJSON(a, b types.JSON) int {
+	return strings.Compare(canonicalJSON(a), canonicalJSON(b))
+}
+
+// canonicalJSON returns the JSON text with sorted keys and without spaces, the incorrect JSON is returned as is
+func canonicalJSON(raw []byte) string {
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return string(raw)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return string(raw)
+	}
+	return string(b)
+}
+
+// convertJSON turns the JSON text of string, []byte or null.JSON into types.JSON, the rest of values are encoded into JSON
+func convertJSON(v interface{}) (types.JSON, error) {
+	switch val := v.(type) {
+	case string:
+		return types.JSON(val), nil
+	case []byte:
+		return types.JSON(val), nil
+	case null.JSON:
+		if !val.Valid {
+			return nil, fmt.Errorf("incorrect value: %v", v)
+		}
+		return types.JSON(val.JSON), nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// compareUUID orders UUIDs by their bytes
+func compareUUID[T ~[16]byte](a, b T) int {
+	return bytes.Compare(a[:], b[:])
+}
+
+// convertUUID turns the UUID of string (in any case), []byte or another type of UUID into T by the parse function
+func convertUUID[T ~[16]byte](parse func(string) (T, error)) func(interface{}) (T, error) {
+	return func(v interface{}) (res T, err error) {
+		switch val := v.(type) {
+		case string:
+			return parse(val)
+		case []byte:
+			if len(val) == len(res) {
+				copy(res[:], val)
+				return res, nil
+			}
+			return parse(string(val))
+		case [16]byte:
+			return T(val), nil
+		case fmt.Stringer:
+			return parse(val.String())
+		}
+		return res, fmt.Errorf("incorrect type: %T != %T", v, res)
+	}
+}
@@ -0,0 +1,278 @@
+package sandbox
+
+import (
+	"fmt"
+	gofrsuuid "github.com/gofrs/uuid"
+	googleuuid "github.com/google/uuid"
+	"github.com/prorochestvo/sqlinjector/internal/expression"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/types"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNewComparator(t *testing.T) {
+	c := NewComparator[internalMoney](
+		func(a, b internalMoney) int {
+			return int(a.Amount - b.Amount)
+		},
+		func(v interface{}) (internalMoney, error) {
+			if s, ok := v.(string); ok {
+				var m internalMoney
+				_, err := fmt.Sscanf(s, "%d %s", &m.Amount, &m.Currency)
+				return m, err
+			}
+			return internalMoney{}, fmt.Errorf("incorrect type: %T", v)
+		},
+	)
+
+	tests := []struct {
+		operator expression.Operator
+		expected []interface{}
+		result   bool
+	}{
+		{expression.Equal, []interface{}{internalMoney{Amount: 10}}, true},
+		{expression.Equal, []interface{}{"10 USD"}, true},
+		{expression.NotEqual, []interface{}{"10 USD"}, false},
+		{expression.GreaterThan, []interface{}{"9 USD"}, true},
+		{expression.GreaterThanOrEqual, []interface{}{"11 USD"}, false},
+		{expression.LessThan, []interface{}{"11 USD"}, true},
+		{expression.LessThanOrEqual, []interface{}{"9 USD"}, false},
+		{expression.In, []interface{}{"1 USD", "10 USD"}, true},
+		{expression.NotIn, []interface{}{"1 USD", "10 USD"}, false},
+		{expression.Contains, []interface{}{"USD"}, true},
+	}
+
+	actually := internalMoney{Amount: 10, Currency: "USD"}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s %v", tt.operator, tt.expected), func(t *testing.T) {
+			res, err := c.Compare(tt.operator, actually, tt.expected)
+			require.NoError(t, err)
+			require.Equal(t, tt.result, res)
+		})
+	}
+
+	t.Run("Order", func(t *testing.T) {
+		r, err := c.Order(internalMoney{Amount: 1}, internalMoney{Amount: 2})
+		require.NoError(t, err)
+		require.Less(t, r, 0)
+		_, err = c.Order(internalMoney{Amount: 1}, 2)
+		require.Error(t, err)
+	})
+}
+
+func TestRegisterComparator(t *testing.T) {
+	type item struct {
+		ID    int           `boil:"id"`
+		Price internalMoney `boil:"price"`
+	}
+
+	m, err := RecognizeImitatorModel(&item{ID: 1})
+	require.NoError(t, err)
+	_, err = m.Compare(expression.Equal, "", "price", internalMoney{})
+	require.Error(t, err)
+
+	RegisterComparator(reflect.TypeOf(internalMoney{}), NewComparator[internalMoney](
+		func(a, b internalMoney) int {
+			if c := strings.Compare(a.Currency, b.Currency); c != 0 {
+				return c
+			}
+			return int(a.Amount - b.Amount)
+		},
+		nil,
+	))
+	defer func() {
+		comparators.Lock()
+		delete(comparators.items, reflect.TypeOf(internalMoney{}))
+		comparators.Unlock()
+	}()
+
+	m1, err := RecognizeImitatorModel(&item{ID: 1, Price: internalMoney{Amount: 30, Currency: "USD"}})
+	require.NoError(t, err)
+	m2, err := RecognizeImitatorModel(&item{ID: 2, Price: internalMoney{Amount: 20, Currency: "EUR"}})
+	require.NoError(t, err)
+	m3, err := RecognizeImitatorModel(&item{ID: 3, Price: internalMoney{Amount: 10, Currency: "USD"}})
+	require.NoError(t, err)
+
+	items := []*ImitatorModel{m1, m2, m3}
+
+	actually, err := ImitatorSqlWhere(items, expression.NewWhere("price", expression.GreaterThan, internalMoney{Amount: 15, Currency: "USD"}))
+	require.NoError(t, err)
+	require.Equal(t, []*ImitatorModel{m1}, actually)
+
+	err = ImitatorSqlOrderBy(items, expression.NewOrderBy("price", expression.Descending))
+	require.NoError(t, err)
+	require.Equal(t, []*ImitatorModel{m1, m3, m2}, items)
+}
+
+func TestBuiltinComparators(t *testing.T) {
+	type item struct {
+		ID       int               `boil:"id"`
+		Amount   types.Decimal     `boil:"amount"`
+		Discount types.NullDecimal `boil:"discount"`
+		Rate     null.Float32      `boil:"rate"`
+		Weight   null.Float64      `boil:"weight"`
+	}
+
+	decimalOf := func(s string) types.Decimal {
+		var d types.Decimal
+		require.NoError(t, d.Scan(s))
+		return d
+	}
+
+	i1 := &item{ID: 1, Amount: decimalOf("9.99"), Discount: types.NewNullDecimal(decimalOf("0.5").Big), Rate: null.Float32From(0.1), Weight: null.Float64From(1.5)}
+	i2 := &item{ID: 2, Amount: decimalOf("19.99"), Rate: null.Float32From(0.2)}
+	i3 := &item{ID: 3, Amount: decimalOf("4.50"), Weight: null.Float64From(0.5)}
+
+	m1, err := RecognizeImitatorModel(i1)
+	require.NoError(t, err)
+	m2, err := RecognizeImitatorModel(i2)
+	require.NoError(t, err)
+	m3, err := RecognizeImitatorModel(i3)
+	require.NoError(t, err)
+
+	require.Nil(t, (*m2)["discount"])
+	require.Nil(t, (*m2)["weight"])
+	require.Equal(t, 0.1, (*m1)["rate"])
+
+	items := []*ImitatorModel{m1, m2, m3}
+
+	t.Run("amount gt 9.99", func(t *testing.T) {
+		actually, err := ImitatorSqlWhere(items, expression.NewWhere("amount", expression.GreaterThan, "9.99"))
+		require.NoError(t, err)
+		require.Equal(t, []*ImitatorModel{m2}, actually)
+	})
+	t.Run("amount ge 4.5", func(t *testing.T) {
+		actually, err := ImitatorSqlWhere(items, expression.NewWhere("amount", expression.GreaterThanOrEqual, 4.5))
+		require.NoError(t, err)
+		require.Len(t, actually, 3)
+	})
+	t.Run("discount is not null", func(t *testing.T) {
+		actually, err := ImitatorSqlWhere(items, expression.NewWhere("discount", expression.IsNotNull))
+		require.NoError(t, err)
+		require.Equal(t, []*ImitatorModel{m1}, actually)
+	})
+	t.Run("rate eq 0.2", func(t *testing.T) {
+		actually, err := ImitatorSqlWhere(items, expression.NewWhere("rate", expression.Equal, 0.2))
+		require.NoError(t, err)
+		require.Equal(t, []*ImitatorModel{m2}, actually)
+	})
+	t.Run("amount ASC", func(t *testing.T) {
+		sorted := []*ImitatorModel{m1, m2, m3}
+		err := ImitatorSqlOrderBy(sorted, expression.NewOrderBy("amount", expression.Ascending))
+		require.NoError(t, err)
+		require.Equal(t, []*ImitatorModel{m3, m1, m2}, sorted)
+	})
+}
+
+func TestBuiltinComparators_UUID(t *testing.T) {
+	type item struct {
+		ID       googleuuid.UUID     `boil:"id"`
+		OwnerID  gofrsuuid.UUID      `boil:"owner_id"`
+		ParentID googleuuid.NullUUID `boil:"parent_id"`
+		GroupID  gofrsuuid.NullUUID  `boil:"group_id"`
+	}
+
+	id1 := googleuuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	id2 := googleuuid.MustParse("f47ac10b-58cc-4372-a567-0e02b2c3d479")
+	owner := gofrsuuid.Must(gofrsuuid.FromString("a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"))
+
+	m1, err := RecognizeImitatorModel(&item{ID: id1, OwnerID: owner, ParentID: googleuuid.NullUUID{UUID: id2, Valid: true}})
+	require.NoError(t, err)
+	m2, err := RecognizeImitatorModel(&item{ID: id2, GroupID: gofrsuuid.NullUUID{UUID: owner, Valid: true}})
+	require.NoError(t, err)
+
+	require.Equal(t, id2, (*m1)["parent_id"])
+	require.Nil(t, (*m2)["parent_id"])
+	require.Nil(t, (*m1)["group_id"])
+	require.Equal(t, owner, (*m2)["group_id"])
+
+	items := []*ImitatorModel{m1, m2}
+
+	t.Run("id eq upper-case string", func(t *testing.T) {
+		actually, err := ImitatorSqlWhere(items, expression.NewWhere("id", expression.Equal, "F47AC10B-58CC-4372-A567-0E02B2C3D479"))
+		require.NoError(t, err)
+		require.Equal(t, []*ImitatorModel{m2}, actually)
+	})
+	t.Run("owner_id in uuids", func(t *testing.T) {
+		actually, err := ImitatorSqlWhere(items, expression.NewWhere("owner_id", expression.In, googleuuid.UUID(owner), "00000000-0000-0000-0000-000000000000"))
+		require.NoError(t, err)
+		require.Equal(t, []*ImitatorModel{m1, m2}, actually)
+	})
+	t.Run("parent_id eq uuid", func(t *testing.T) {
+		actually, err := ImitatorSqlWhere(items, expression.NewWhere("parent_id", expression.Equal, id2))
+		require.NoError(t, err)
+		require.Equal(t, []*ImitatorModel{m1}, actually)
+	})
+	t.Run("id startswith", func(t *testing.T) {
+		actually, err := ImitatorSqlWhere(items, expression.NewWhere("id", expression.StartsWith, "6ba7"))
+		require.NoError(t, err)
+		require.Equal(t, []*ImitatorModel{m1}, actually)
+	})
+	t.Run("incorrect uuid", func(t *testing.T) {
+		_, err := ImitatorSqlWhere(items, expression.NewWhere("id", expression.Equal, "not-a-uuid"))
+		require.Error(t, err)
+	})
+	t.Run("id DESC", func(t *testing.T) {
+		sorted := []*ImitatorModel{m1, m2}
+		err := ImitatorSqlOrderBy(sorted, expression.NewOrderBy("id", expression.Descending))
+		require.NoError(t, err)
+		require.Equal(t, []*ImitatorModel{m2, m1}, sorted)
+	})
+}
+
+func TestBuiltinComparators_JSON(t *testing.T) {
+	type item struct {
+		ID       int        `boil:"id"`
+		Metadata types.JSON `boil:"metadata"`
+		Extra    null.JSON  `boil:"extra"`
+	}
+
+	m1, err := RecognizeImitatorModel(&item{ID: 1, Metadata: types.JSON(`{"color": "red", "size": 1}`), Extra: null.JSONFrom([]byte(`[1, 2]`))})
+	require.NoError(t, err)
+	m2, err := RecognizeImitatorModel(&item{ID: 2, Metadata: types.JSON(`{"color":"blue"}`)})
+	require.NoError(t, err)
+
+	require.Equal(t, types.JSON(`[1, 2]`), (*m1)["extra"])
+	require.Nil(t, (*m2)["extra"])
+
+	items := []*ImitatorModel{m1, m2}
+
+	t.Run("metadata eq document of other order of keys", func(t *testing.T) {
+		actually, err := ImitatorSqlWhere(items, expression.NewWhere("metadata", expression.Equal, `{"size":1,"color":"red"}`))
+		require.NoError(t, err)
+		require.Equal(t, []*ImitatorModel{m1}, actually)
+	})
+	t.Run("metadata ne document", func(t *testing.T) {
+		actually, err := ImitatorSqlWhere(items, expression.NewWhere("metadata", expression.NotEqual, map[string]interface{}{"color": "blue"}))
+		require.NoError(t, err)
+		require.Equal(t, []*ImitatorModel{m1}, actually)
+	})
+	t.Run("extra eq null.JSON", func(t *testing.T) {
+		actually, err := ImitatorSqlWhere(items, expression.NewWhere("extra", expression.Equal, null.JSONFrom([]byte(`[1,2]`))))
+		require.NoError(t, err)
+		require.Equal(t, []*ImitatorModel{m1}, actually)
+	})
+	t.Run("extra is null", func(t *testing.T) {
+		actually, err := ImitatorSqlWhere(items, expression.NewWhere("extra", expression.IsNull))
+		require.NoError(t, err)
+		require.Equal(t, []*ImitatorModel{m2}, actually)
+	})
+	t.Run("metadata contains", func(t *testing.T) {
+		actually, err := ImitatorSqlWhere(items, expression.NewWhere("metadata", expression.Contains, `"blue"`))
+		require.NoError(t, err)
+		require.Equal(t, []*ImitatorModel{m2}, actually)
+	})
+	t.Run("member of metadata", func(t *testing.T) {
+		actually, err := ImitatorSqlWhere(items, expression.NewWhereOfJSON("metadata", []string{"color"}, expression.Equal, "red"))
+		require.NoError(t, err)
+		require.Equal(t, []*ImitatorModel{m1}, actually)
+	})
+}
+
+type internalMoney struct {
+	Amount   int64
+	Currency string
+}
@@ -0,0 +1,184 @@
+package sandbox
+
+import (
+	"fmt"
+	"github.com/prorochestvo/sqlinjector/internal/expression"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Calculate returns the result of the function over the model, nil means SQL NULL.
+func (m *ImitatorModel) Calculate(f *expression.Function) (interface{}, error) {
+	args := make([]interface{}, len(f.Args))
+	for i, a := range f.Args {
+		switch v := a.(type) {
+		case *expression.Field:
+			val, exists := m.GetValue(toLowerTableName(v.Table), v.Column)
+			if !exists {
+				return nil, fmt.Errorf(strings.Trim(fmt.Sprintf("%s.%s not found", v.Table, v.Column), "."))
+			}
+			if len(v.Path) > 0 {
+				var err error
+				if val, err = jsonMember(val, v.Path, true); err != nil {
+					return nil, err
+				}
+			}
+			args[i] = val
+		case *expression.Function:
+			val, err := m.Calculate(v)
+			if err != nil {
+				return nil, err
+			}
+			args[i] = val
+		default:
+			args[i] = v
+		}
+		if args[i] == nil {
+			// any function over NULL returns NULL in SQL
+			return nil, nil
+		}
+	}
+	return calculate(f.Name, args)
+}
+
+// calculate applies the OData canonical function to the values
+func calculate(name string, args []interface{}) (interface{}, error) {
+	switch name {
+	case "tolower", "toupper", "trim", "length":
+		s, err := asText(args[0])
+		if err != nil {
+			return nil, err
+		}
+		switch name {
+		case "tolower":
+			return strings.ToLower(s), nil
+		case "toupper":
+			return strings.ToUpper(s), nil
+		case "trim":
+			return strings.TrimSpace(s), nil
+		}
+		return utf8.RuneCountInString(s), nil
+	case "indexof":
+		s, err := asText(args[0])
+		if err != nil {
+			return nil, err
+		}
+		sub, err := asText(args[1])
+		if err != nil {
+			return nil, err
+		}
+		i := strings.Index(s, sub)
+		if i < 0 {
+			return -1, nil
+		}
+		return utf8.RuneCountInString(s[:i]), nil
+	case "substring":
+		s, err := asText(args[0])
+		if err != nil {
+			return nil, err
+		}
+		r := []rune(s)
+		start, err := asInt(args[1])
+		if err != nil {
+			return nil, err
+		}
+		start = min(max(start, 0), len(r))
+		end := len(r)
+		if len(args) > 2 {
+			var l int
+			if l, err = asInt(args[2]); err != nil {
+				return nil, err
+			}
+			end = min(start+max(l, 0), len(r))
+		}
+		return string(r[start:end]), nil
+	case "concat":
+		var b strings.Builder
+		for _, a := range args {
+			s, err := asText(a)
+			if err != nil {
+				return nil, err
+			}
+			b.WriteString(s)
+		}
+		return b.String(), nil
+	case "year", "month", "day", "hour", "date":
+		t, ok := args[0].(time.Time)
+		if !ok {
+			return nil, fmt.Errorf("incorrect type: %T != %T", args[0], t)
+		}
+		switch name {
+		case "year":
+			return t.Year(), nil
+		case "month":
+			return int(t.Month()), nil
+		case "day":
+			return t.Day(), nil
+		case "hour":
+			return t.Hour(), nil
+		}
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location()), nil
+	case "now":
+		return time.Now().UTC(), nil
+	case "round", "floor", "ceiling":
+		switch v := args[0].(type) {
+		case int, uint:
+			return v, nil
+		}
+		v, err := asFloat(args[0])
+		if err != nil {
+			return nil, err
+		}
+		switch name {
+		case "round":
+			return math.Round(v), nil
+		case "floor":
+			return math.Floor(v), nil
+		}
+		return math.Ceil(v), nil
+	}
+	return nil, fmt.Errorf("unsupported function: %s", name)
+}
+
+func asText(v interface{}) (string, error) {
+	switch val := v.(type) {
+	case string:
+		return val, nil
+	case []byte:
+		return string(val), nil
+	case int, uint, float64, bool:
+		return fmt.Sprintf("%v", val), nil
+	}
+	return "", fmt.Errorf("incorrect type: %T != string", v)
+}
+
+func asInt(v interface{}) (int, error) {
+	switch val := v.(type) {
+	case int:
+		return val, nil
+	case uint:
+		return int(val), nil
+	case float64:
+		return int(val), nil
+	case string:
+		return strconv.Atoi(strings.TrimSpace(val))
+	}
+	return 0, fmt.Errorf("incorrect type: %T != int", v)
+}
+
+func asFloat(v interface{}) (float64, error) {
+	switch val := v.(type) {
+	case float64:
+		return val, nil
+	case int:
+		return float64(val), nil
+	case uint:
+		return float64(val), nil
+	case string:
+		return strconv.ParseFloat(strings.TrimSpace(val), 64)
+	}
+	return 0, fmt.Errorf("incorrect type: %T != float64", v)
+}
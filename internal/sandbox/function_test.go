@@ -0,0 +1,203 @@
+package sandbox
+
+import (
+	"github.com/prorochestvo/sqlinjector/internal/expression"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/types"
+	"testing"
+	"time"
+)
+
+func TestImitatorModelCalculate(t *testing.T) {
+	createdAt := time.Date(2024, 3, 15, 10, 30, 0, 0, time.UTC)
+	m, err := RecognizeImitatorModel(&struct {
+		Name      string      `boil:"name"`
+		Price     float64     `boil:"price"`
+		Note      null.String `boil:"note"`
+		CreatedAt time.Time   `boil:"created_at"`
+	}{Name: " Привет, John ", Price: 9.5, CreatedAt: createdAt})
+	require.NoError(t, err)
+
+	name := expression.NewField("name")
+
+	tests := []struct {
+		name     string
+		function *expression.Function
+		expected interface{}
+	}{
+		{name: "tolower", function: &expression.Function{Name: "tolower", Args: []interface{}{name}}, expected: " привет, john "},
+		{name: "toupper", function: &expression.Function{Name: "toupper", Args: []interface{}{name}}, expected: " ПРИВЕТ, JOHN "},
+		{name: "trim", function: &expression.Function{Name: "trim", Args: []interface{}{name}}, expected: "Привет, John"},
+		{name: "length", function: &expression.Function{Name: "length", Args: []interface{}{name}}, expected: 14},
+		{name: "indexof", function: &expression.Function{Name: "indexof", Args: []interface{}{name, "John"}}, expected: 9},
+		{name: "indexof not found", function: &expression.Function{Name: "indexof", Args: []interface{}{name, "Tom"}}, expected: -1},
+		{name: "substring", function: &expression.Function{Name: "substring", Args: []interface{}{name, 1, 6}}, expected: "Привет"},
+		{name: "substring to the end", function: &expression.Function{Name: "substring", Args: []interface{}{name, "9"}}, expected: "John "},
+		{name: "concat", function: &expression.Function{Name: "concat", Args: []interface{}{&expression.Function{Name: "trim", Args: []interface{}{name}}, "!"}}, expected: "Привет, John!"},
+		{name: "year", function: &expression.Function{Name: "year", Args: []interface{}{expression.NewField("created_at")}}, expected: 2024},
+		{name: "month", function: &expression.Function{Name: "month", Args: []interface{}{expression.NewField("created_at")}}, expected: 3},
+		{name: "day", function: &expression.Function{Name: "day", Args: []interface{}{expression.NewField("created_at")}}, expected: 15},
+		{name: "hour", function: &expression.Function{Name: "hour", Args: []interface{}{expression.NewField("created_at")}}, expected: 10},
+		{name: "date", function: &expression.Function{Name: "date", Args: []interface{}{expression.NewField("created_at")}}, expected: time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{name: "round", function: &expression.Function{Name: "round", Args: []interface{}{expression.NewField("price")}}, expected: 10.0},
+		{name: "floor", function: &expression.Function{Name: "floor", Args: []interface{}{expression.NewField("price")}}, expected: 9.0},
+		{name: "ceiling", function: &expression.Function{Name: "ceiling", Args: []interface{}{expression.NewField("price")}}, expected: 10.0},
+		{name: "NULL", function: &expression.Function{Name: "tolower", Args: []interface{}{expression.NewField("note")}}, expected: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actually, err := m.Calculate(tt.function)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, actually)
+		})
+	}
+
+	t.Run("Evaluate", func(t *testing.T) {
+		ok, err := m.Evaluate(expression.NewWhereWithFunction(&expression.Function{Name: "year", Args: []interface{}{expression.NewField("created_at")}}, expression.Equal, 2024))
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		ok, err = m.Evaluate(expression.NewWhere("created_at", expression.LessThan, &expression.Function{Name: "now"}))
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		_, err = m.Calculate(&expression.Function{Name: "year", Args: []interface{}{name}})
+		require.Error(t, err)
+	})
+}
+
+func TestImitatorModelEvaluateLambda(t *testing.T) {
+	m, err := RecognizeImitatorModel(&struct {
+		Name string   `boil:"name"`
+		Tags []string `boil:"tags"`
+	}{Name: "John", Tags: []string{"red", "green"}})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		filter   string
+		expected bool
+	}{
+		{name: "any", filter: "tags/any(t: t eq 'red')", expected: true},
+		{name: "any not found", filter: "tags/any(t: t eq 'blue')", expected: false},
+		{name: "any without predicate", filter: "tags/any()", expected: true},
+		{name: "all", filter: "tags/all(t: contains(t,'e'))", expected: true},
+		{name: "all not matched", filter: "tags/all(t: t ne 'green')", expected: false},
+		{name: "not any", filter: "not tags/any(t: t eq 'blue') and name eq 'John'", expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conditions, err := expression.NewWhereFrom(tt.filter)
+			require.NoError(t, err)
+
+			actually := true
+			for _, c := range conditions {
+				ok, err := m.Evaluate(c)
+				require.NoError(t, err)
+				actually = actually && ok
+			}
+			require.Equal(t, tt.expected, actually)
+		})
+	}
+}
+
+func TestImitatorModelEvaluateOperators(t *testing.T) {
+	var price types.Decimal
+	require.NoError(t, price.Scan("10.50"))
+	m, err := RecognizeImitatorModel(&struct {
+		Name  string        `boil:"name"`
+		Age   int           `boil:"age"`
+		Price types.Decimal `boil:"price"`
+		Note  null.String   `boil:"note"`
+		Code  string        `boil:"code"`
+	}{Name: "John", Age: 30, Price: price, Note: null.String{}, Code: "50%_off"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		condition expression.Condition
+		expected  bool
+	}{
+		{name: "between", condition: expression.NewWhere("age", expression.Between, 18, 30), expected: true},
+		{name: "between out of range", condition: expression.NewWhere("age", expression.Between, 31, 65), expected: false},
+		{name: "not between", condition: expression.NewWhere("age", expression.NotBetween, 31, 65), expected: true},
+		{name: "between of comparator", condition: expression.NewWhere("price", expression.Between, 10, 11), expected: true},
+		{name: "not between of comparator", condition: expression.NewWhere("price", expression.NotBetween, 10, 11), expected: false},
+		{name: "icontains", condition: expression.NewWhere("name", expression.IContains, "OH"), expected: true},
+		{name: "istartswith", condition: expression.NewWhere("name", expression.IStartsWith, "jo"), expected: true},
+		{name: "iendswith", condition: expression.NewWhere("name", expression.IEndsWith, "HN"), expected: true},
+		{name: "not contains", condition: expression.NewWhere("name", expression.NotContains, "oh"), expected: false},
+		{name: "startswith", condition: expression.NewWhere("name", expression.StartsWith, "Jo"), expected: true},
+		{name: "endswith", condition: expression.NewWhere("name", expression.EndsWith, "hn"), expected: true},
+		{name: "percent is literal", condition: expression.NewWhere("name", expression.Contains, "J%n"), expected: false},
+		{name: "underscore is literal", condition: expression.NewWhere("name", expression.StartsWith, "J_h"), expected: false},
+		{name: "wildcards of value", condition: expression.NewWhere("code", expression.StartsWith, "50%_"), expected: true},
+		{name: "matches", condition: expression.NewWhere("name", expression.Matches, "^J.*n$"), expected: true},
+		{name: "not matches", condition: expression.NewCombinerNOT(expression.NewWhere("name", expression.Matches, "^j")), expected: true},
+		{name: "null never matches", condition: expression.NewWhere("note", expression.NotContains, "x"), expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actually, err := m.Evaluate(tt.condition)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, actually)
+		})
+	}
+
+	_, err = m.Evaluate(expression.NewWhere("name", expression.Matches, "("))
+	require.Error(t, err)
+}
+
+func TestImitatorModelSearch(t *testing.T) {
+	type article struct {
+		ID    int         `boil:"id"`
+		Title string      `boil:"title"`
+		Body  null.String `boil:"body"`
+	}
+	items := make([]*ImitatorModel, 0)
+	for _, a := range []*article{
+		{ID: 1, Title: "Go generics", Body: null.StringFrom("Generics of Go, generics everywhere")},
+		{ID: 2, Title: "Rust", Body: null.StringFrom("Traits and generics")},
+		{ID: 3, Title: "Go channels"},
+	} {
+		m, err := RecognizeImitatorModel(a)
+		require.NoError(t, err)
+		items = append(items, m)
+	}
+
+	ids := func(items []*ImitatorModel) []interface{} {
+		res := make([]interface{}, len(items))
+		for i, item := range items {
+			res[i], _ = item.GetValue("", "id")
+		}
+		return res
+	}
+
+	search := expression.NewSearch("articles", []string{"title", "body"}, "GENERICS")
+	found, err := ImitatorSqlWhere(items, search)
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{1, 2}, ids(found))
+
+	found[0], found[1] = found[1], found[0]
+	require.NoError(t, imitatorSqlOrder(found, []expression.Modifier{expression.NewSearchRank(search)}))
+	require.Equal(t, []interface{}{1, 2}, ids(found))
+
+	found, err = ImitatorSqlWhere(items, expression.NewSearch("articles", []string{"title", "body"}, "go, generics"))
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{1}, ids(found))
+
+	found, err = ImitatorSqlWhere(items, expression.NewCombinerNOT(expression.NewSearch("articles", []string{"title"}, "go")))
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{2}, ids(found))
+
+	found, err = ImitatorSqlWhere(items, expression.NewSearch("articles", []string{"title"}, " * "))
+	require.NoError(t, err)
+	require.Len(t, found, 3)
+
+	_, err = ImitatorSqlWhere(items, expression.NewSearch("articles", []string{"summary"}, "go"))
+	require.Error(t, err)
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"github.com/prorochestvo/sqlinjector/internal"
 	"github.com/prorochestvo/sqlinjector/internal/expression"
 	"github.com/volatiletech/null/v8"
 	"golang.org/x/exp/constraints"
@@ -49,9 +50,9 @@ func Merge(entity interface{}, values map[string]interface{}) error {
 
 func ImitatorSql[ID constraints.Ordered, T any](
 	entities map[ID]*T,
-	where []*expression.Where,
+	where []expression.Condition,
 	groupBy []*expression.GroupBy,
-	orderBy []*expression.OrderBy,
+	orderBy []expression.Modifier,
 ) ([]*T, error) {
 	items := make([]*ImitatorModel, 0, len(entities))
 	ids := make(map[unsafe.Pointer]ID, len(entities))
@@ -83,7 +84,7 @@ func ImitatorSql[ID constraints.Ordered, T any](
 	}
 
 	if len(orderBy) > 0 {
-		err := ImitatorSqlOrderBy(items, orderBy...)
+		err := imitatorSqlOrder(items, orderBy)
 		if err != nil {
 			return nil, err
 		}
@@ -105,13 +106,13 @@ func ImitatorSql[ID constraints.Ordered, T any](
 	return res, nil
 }
 
-func ImitatorSqlWhere(entities []*ImitatorModel, expressions ...*expression.Where) ([]*ImitatorModel, error) {
+func ImitatorSqlWhere(entities []*ImitatorModel, expressions ...expression.Condition) ([]*ImitatorModel, error) {
 	result := make([]*ImitatorModel, 0, len(entities))
 
 	for _, entity := range entities {
 		needed := true
 		for _, eWhere := range expressions {
-			res, err := entity.Compare(eWhere.Operator, eWhere.Table, eWhere.Column, eWhere.Value)
+			res, err := entity.Evaluate(eWhere)
 			if err != nil {
 				return nil, err
 			}
@@ -129,8 +130,34 @@ func ImitatorSqlWhere(entities []*ImitatorModel, expressions ...*expression.Wher
 }
 
 func ImitatorSqlOrderBy(entities []*ImitatorModel, expressions ...*expression.OrderBy) (err error) {
+	orders := make([]expression.Modifier, len(expressions))
+	for i, e := range expressions {
+		orders[i] = e
+	}
+	return imitatorSqlOrder(entities, orders)
+}
+
+// imitatorSqlOrder sorts the entities by the sequence of OrderBy and SearchRank
+func imitatorSqlOrder(entities []*ImitatorModel, expressions []expression.Modifier) (err error) {
 	sort.SliceStable(entities, func(a, b int) bool {
-		for _, eOrderBy := range expressions {
+		for _, e := range expressions {
+			if r, ok := e.(*expression.SearchRank); ok {
+				rankA, _, errA := entities[a].Search(r.Search)
+				rankB, _, errB := entities[b].Search(r.Search)
+				if errA != nil || errB != nil {
+					err = errors.Join(err, errA, errB)
+					return false
+				}
+				if rankA == rankB {
+					continue
+				}
+				return rankA > rankB
+			}
+			eOrderBy, ok := e.(*expression.OrderBy)
+			if !ok {
+				err = errors.Join(err, fmt.Errorf("unsupported ordering %T", e))
+				return false
+			}
 			valA, existsI := entities[a].GetValue(eOrderBy.Table, eOrderBy.Column)
 			if !existsI {
 				err = errors.Join(err, fmt.Errorf("could not find %s.%s in %v", eOrderBy.Table, eOrderBy.Column, entities[a]))
@@ -147,7 +174,24 @@ func ImitatorSqlOrderBy(entities []*ImitatorModel, expressions ...*expression.Or
 			if valB == nil && valA != nil {
 				return true
 			}
-			if valA == valB || valA == nil || valB == nil {
+			if valA == nil || valB == nil {
+				continue
+			}
+			if c, ok := LookupComparator(valA); ok && c.Order != nil {
+				r, e := c.Order(valA, valB)
+				if e != nil {
+					err = errors.Join(err, e)
+					return false
+				}
+				if r == 0 {
+					continue
+				}
+				if eOrderBy.Direction == expression.Descending {
+					return r > 0
+				}
+				return r < 0
+			}
+			if valA == valB {
 				continue
 			}
 			switch eOrderBy.Direction {
@@ -212,10 +256,22 @@ func ImitatorSqlGroupBy(entities []*ImitatorModel, expressions ...*expression.Gr
 }
 
 func RecognizeImitatorModel(entity interface{}) (*ImitatorModel, error) {
+	return recognizeImitatorModel(entity, make(map[unsafe.Pointer]struct{}))
+}
+
+// recognizeImitatorModel recognizes the entity, path keeps pointers of the entities being recognized,
+// so mutually related entities (e.g. book.R.Author.R.Books) do not lead to the endless recursion.
+func recognizeImitatorModel(entity interface{}, path map[unsafe.Pointer]struct{}) (*ImitatorModel, error) {
 	t := reflect.TypeOf(entity)
 	v := reflect.ValueOf(entity)
 
 	if t.Kind() == reflect.Ptr {
+		p := v.UnsafePointer()
+		if _, exists := path[p]; exists {
+			return nil, nil
+		}
+		path[p] = struct{}{}
+		defer delete(path, p)
 		t = t.Elem()
 		v = v.Elem()
 	}
@@ -238,6 +294,15 @@ func RecognizeImitatorModel(entity interface{}) (*ImitatorModel, error) {
 			columnName = fType.Name
 		}
 
+		if c, ok := comparatorOf(fType.Type); ok {
+			if c.Normalize != nil {
+				values[columnName] = c.Normalize(fValue.Interface())
+			} else {
+				values[columnName] = fValue.Interface()
+			}
+			continue
+		}
+
 		switch fValue.Kind() {
 		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 			values[columnName] = int(fValue.Int())
@@ -293,6 +358,23 @@ func RecognizeImitatorModel(entity interface{}) (*ImitatorModel, error) {
 					items[j] = byte(fValue.Index(j).Uint())
 				}
 				values[columnName] = items
+			case reflect.Pointer, reflect.Struct:
+				l := fValue.Len()
+				items := make([]*ImitatorModel, 0, l)
+				for j := 0; j < l; j++ {
+					item := fValue.Index(j)
+					if item.Kind() == reflect.Pointer && item.IsNil() {
+						continue
+					}
+					internalDataset, err := recognizeImitatorModel(item.Interface(), path)
+					if err != nil {
+						return nil, fmt.Errorf("could not parse %s[%d] field: %w", columnName, j, err)
+					}
+					if internalDataset != nil {
+						items = append(items, internalDataset)
+					}
+				}
+				values[columnName] = items
 			default:
 				return nil, fmt.Errorf("unsupported type %s of field %s", f, columnName)
 			}
@@ -300,10 +382,14 @@ func RecognizeImitatorModel(entity interface{}) (*ImitatorModel, error) {
 			if fValue.IsNil() {
 				values[columnName] = nil
 			} else {
-				internalDataset, err := RecognizeImitatorModel(fValue.Elem().Interface())
+				internalDataset, err := recognizeImitatorModel(fValue.Interface(), path)
 				if err != nil {
 					return nil, fmt.Errorf("could not parse %s field: %w", columnName, err)
 				}
+				if internalDataset == nil {
+					values[columnName] = nil
+					continue
+				}
 				if columnName == "R" || columnName == "L" {
 					var relation ImitatorModel = make(map[string]interface{})
 					for key, val := range *internalDataset {
@@ -332,13 +418,6 @@ func RecognizeImitatorModel(entity interface{}) (*ImitatorModel, error) {
 				} else {
 					values[columnName] = nil
 				}
-			case "null.JSON":
-				internalValue := fValue.Interface().(null.JSON)
-				if !internalValue.IsZero() {
-					values[columnName] = internalValue.JSON
-				} else {
-					values[columnName] = nil
-				}
 			case "null.Int":
 				internalValue := fValue.Interface().(null.Int)
 				if !internalValue.IsZero() {
@@ -431,7 +510,7 @@ func RecognizeImitatorModel(entity interface{}) (*ImitatorModel, error) {
 					values[columnName] = nil
 				}
 			default:
-				internalDataset, err := RecognizeImitatorModel(fValue.Interface())
+				internalDataset, err := recognizeImitatorModel(fValue.Interface(), path)
 				if err != nil {
 					return nil, fmt.Errorf("could not parse %s field: %w", columnName, err)
 				}
@@ -452,21 +531,11 @@ func (m *ImitatorModel) GetValue(table string, column string) (interface{}, bool
 	raw := m
 
 	if table != "" && table != "*" && table != "-" {
-		exists := false
-		if relation, ok := (*raw)["R"]; ok {
-			var t *ImitatorModel
-			if t, ok = relation.(*ImitatorModel); ok && t != nil {
-				if relation, ok = (*t)[table]; ok && relation != nil {
-					if t, ok = relation.(*ImitatorModel); ok && t != nil {
-						raw = t
-						exists = true
-					}
-				}
-			}
-		}
-		if !exists {
+		related, exists := m.Related(table)
+		if !exists || len(related) != 1 {
 			return nil, false
 		}
+		raw = related[0]
 	}
 
 	value, ok := (*raw)[column]
@@ -477,13 +546,62 @@ func (m *ImitatorModel) GetValue(table string, column string) (interface{}, bool
 	return value, true
 }
 
+// Related returns the models of the relation loaded into R, a to-one relation is returned as a single item slice.
+// The flag is false when the model has no such relation.
+func (m *ImitatorModel) Related(table string) ([]*ImitatorModel, bool) {
+	relations, ok := (*m)["R"].(*ImitatorModel)
+	if !ok || relations == nil {
+		return nil, false
+	}
+
+	relation, ok := (*relations)[table]
+	if !ok {
+		return nil, false
+	}
+
+	switch r := relation.(type) {
+	case *ImitatorModel:
+		if r == nil {
+			return nil, true
+		}
+		return []*ImitatorModel{r}, true
+	case []*ImitatorModel:
+		return r, true
+	case nil:
+		return nil, true
+	}
+
+	return nil, false
+}
+
 func (m *ImitatorModel) Compare(operator expression.Operator, table, column string, expected interface{}) (bool, error) {
 	table = toLowerTableName(table)
+
+	if table != "" && table != "*" && table != "-" {
+		related, exists := m.Related(table)
+		if !exists {
+			return false, fmt.Errorf("%s.%s not found", table, column)
+		}
+		// the row is matched when any of the joined rows satisfies the condition
+		for _, r := range related {
+			res, err := r.Compare(operator, "", column, expected)
+			if err != nil || res {
+				return res, err
+			}
+		}
+		return false, nil
+	}
+
 	actually, exists := m.GetValue(table, column)
 	if !exists {
 		return false, fmt.Errorf(strings.Trim(fmt.Sprintf("%s.%s not found", table, column), "."))
 	}
 
+	return compareValue(operator, actually, expected)
+}
+
+// compareValue compares the actual value with the expected one by the operator
+func compareValue(operator expression.Operator, actually interface{}, expected interface{}) (bool, error) {
 	switch operator {
 	case expression.IsNull:
 		return actually == nil, nil
@@ -491,6 +609,19 @@ func (m *ImitatorModel) Compare(operator expression.Operator, table, column stri
 		return actually != nil, nil
 	}
 
+	if actually == nil {
+		// NULL never satisfies comparison in SQL
+		return false, nil
+	}
+
+	if c, ok := LookupComparator(actually); ok && c.Compare != nil {
+		expectedItems, err := asSlice[interface{}](expected)
+		if err != nil {
+			return false, err
+		}
+		return c.Compare(operator, actually, expectedItems)
+	}
+
 	switch val := actually.(type) {
 	case string:
 		expectedItems, err := asSlice[string](expected)
@@ -559,22 +690,195 @@ func (m *ImitatorModel) Compare(operator expression.Operator, table, column stri
 	return false, fmt.Errorf("unsupported type: %T %s %T", actually, operator, expected)
 }
 
-// TODO: rethink this function, it's not covered all cases
+// Evaluate returns the result of the condition over the model, e.g. the tree of And, Or and Not with Where leaves
+func (m *ImitatorModel) Evaluate(c expression.Condition) (bool, error) {
+	switch v := c.(type) {
+	case *expression.Where:
+		expected := v.Value
+		if f, ok := expected.(*expression.Function); ok {
+			var err error
+			if expected, err = m.Calculate(f); err != nil {
+				return false, err
+			}
+		}
+		if v.Function == nil && len(v.Path) > 0 {
+			actually, exists := m.GetValue(toLowerTableName(v.Table), v.Column)
+			if !exists {
+				return false, fmt.Errorf(strings.Trim(fmt.Sprintf("%s.%s not found", v.Table, v.Column), "."))
+			}
+			actually, err := jsonMember(actually, v.Path, isText(expected))
+			if err != nil {
+				return false, err
+			}
+			return compareValue(v.Operator, actually, expected)
+		}
+		if v.Function == nil {
+			return m.Compare(v.Operator, v.Table, v.Column, expected)
+		}
+		actually, err := m.Calculate(v.Function)
+		if err != nil {
+			return false, err
+		}
+		return compareValue(v.Operator, actually, expected)
+	case *expression.Search:
+		_, ok, err := m.Search(v)
+		return ok, err
+	case *expression.Lambda:
+		items, err := m.elements(v)
+		if err != nil {
+			return false, err
+		}
+		if v.Condition == nil {
+			return len(items) > 0, nil
+		}
+		// all() is rendered as NOT EXISTS of the elements, which satisfy NOT of condition, so the element of NULL does not break it
+		condition := v.Condition
+		if v.Kind == expression.All {
+			condition = expression.NewCombinerNOT(condition)
+		}
+		for _, item := range items {
+			ok, err := item.Evaluate(condition)
+			if err != nil {
+				return false, err
+			}
+			if ok {
+				return v.Kind == expression.Any, nil
+			}
+		}
+		return v.Kind == expression.All, nil
+	case *expression.And:
+		for _, item := range v.And() {
+			if ok, err := m.Evaluate(item); err != nil || !ok {
+				return false, err
+			}
+		}
+		return true, nil
+	case *expression.Or:
+		for _, item := range v.Or() {
+			if ok, err := m.Evaluate(item); err != nil || ok {
+				return ok, err
+			}
+		}
+		return false, nil
+	case *expression.Not:
+		return m.negate(v.Not())
+	}
+	return false, fmt.Errorf("unsupported condition %T", c)
+}
+
+// negate evaluates NOT of the condition the same way as it is rendered into SQL: NOT is pushed down to the leaves by De Morgan's laws,
+// the leaves are replaced by the opposite operators, so SQL semantics of NULL is kept, e.g. NOT (a = 1) is false when a is NULL.
+func (m *ImitatorModel) negate(c expression.Condition) (bool, error) {
+	switch v := c.(type) {
+	case *expression.Where:
+		if n, exists := v.Negate(); exists {
+			return m.Evaluate(n)
+		}
+		switch v.Operator {
+		case expression.StartsWith, expression.EndsWith, expression.IContains, expression.IStartsWith, expression.IEndsWith, expression.Matches:
+			// NOT LIKE of NULL is not satisfied
+			notNull, err := m.Evaluate(&expression.Where{Table: v.Table, Column: v.Column, Operator: expression.IsNotNull, Function: v.Function, Path: v.Path})
+			if err != nil || !notNull {
+				return false, err
+			}
+			ok, err := m.Evaluate(v)
+			return !ok && err == nil, err
+		}
+		// the negation of unknown operator is unsatisfiable
+		return false, nil
+	case *expression.Search:
+		if len(expression.SearchTokens(v.Query)) == 0 {
+			return true, nil
+		}
+		ok, err := m.Evaluate(v)
+		return !ok && err == nil, err
+	case *expression.Lambda:
+		ok, err := m.Evaluate(v)
+		return !ok && err == nil, err
+	case *expression.Not:
+		return m.Evaluate(v.Not())
+	case *expression.And:
+		// NOT (a AND b) is NOT a OR NOT b
+		for _, item := range v.And() {
+			if ok, err := m.negate(item); err != nil || ok {
+				return ok, err
+			}
+		}
+		return false, nil
+	case *expression.Or:
+		// NOT (a OR b) is NOT a AND NOT b
+		for _, item := range v.Or() {
+			if ok, err := m.negate(item); err != nil || !ok {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+	return false, fmt.Errorf("unsupported condition %T", c)
+}
+
+// Search approximates the full-text search by the words of columns: the rank is the number of occurrences of the words of query,
+// the model is matched when it has all words of query (or the query has no words).
+func (m *ImitatorModel) Search(s *expression.Search) (int, bool, error) {
+	words := make(map[string]int)
+	for _, c := range s.Columns {
+		value, exists := m.GetValue("", c)
+		if !exists {
+			return 0, false, fmt.Errorf("%s not found", c)
+		}
+		if value == nil {
+			continue
+		}
+		for _, w := range expression.SearchTokens(fmt.Sprintf("%v", value)) {
+			words[w]++
+		}
+	}
+
+	rank, matched := 0, true
+	for _, w := range expression.SearchTokens(s.Query) {
+		rank += words[w]
+		matched = matched && words[w] > 0
+	}
+	return rank, matched, nil
+}
+
+// elements returns the collection of lambda: the related rows or the elements of array as the models with the column "value"
+func (m *ImitatorModel) elements(l *expression.Lambda) ([]*ImitatorModel, error) {
+	if l.Relation {
+		related, exists := m.Related(toLowerTableName(l.Column))
+		if !exists {
+			return nil, fmt.Errorf("relation %s not found", l.Column)
+		}
+		return related, nil
+	}
+
+	value, exists := m.GetValue("", l.Column)
+	if !exists {
+		return nil, fmt.Errorf("%s not found", l.Column)
+	}
+	v := reflect.ValueOf(value)
+	if !v.IsValid() {
+		return nil, nil
+	}
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, fmt.Errorf("%s of %T is not an array", l.Column, value)
+	}
+	items := make([]*ImitatorModel, v.Len())
+	for i := range items {
+		items[i] = &ImitatorModel{expression.LambdaValue: v.Index(i).Interface()}
+	}
+	return items, nil
+}
+
+// toLowerTableName returns the name of model, relation or table reduced by the naming strategy to compare them,
+// e.g. "UserGroup", "user_groups" and "userGroups" are turned into "usergroup", "People" and "person" into "person".
 func toLowerTableName(str string) string {
-	rx := regexp.MustCompile(`[^a-z0-9]+`)
-	str = strings.ToLower(str)
-	str = rx.ReplaceAllString(str, "")
-	// reduce the plurality of the table name
-	if strings.HasSuffix(str, "ies") {
-		str = str[:len(str)-3] + "y"
-	} else if strings.HasSuffix(str, "es") {
-		str = str[:len(str)-1]
-	} else if strings.HasSuffix(str, "s") {
-		str = str[:len(str)-1]
-	}
-	return str
+	str = internal.SingularName(str)
+	return nonAlphanumeric.ReplaceAllString(strings.ToLower(str), "")
 }
 
+var nonAlphanumeric = regexp.MustCompile(`[^a-z0-9]+`)
+
 // compare compares a pair of value by the given operator.
 func compare[T constraints.Ordered](o expression.Operator, actually T, expected ...T) (bool, error) {
 	switch o {
@@ -610,32 +914,91 @@ func compare[T constraints.Ordered](o expression.Operator, actually T, expected
 			}
 		}
 		return true, nil
-	case expression.Contains:
-		return strings.Contains(fmt.Sprintf("%v", actually), fmt.Sprintf("%v", expected[0])), nil
-	case expression.StartsWith:
-		return strings.HasPrefix(fmt.Sprintf("%v", actually), fmt.Sprintf("%v", expected[0])), nil
-	case expression.EndsWith:
-		return strings.HasSuffix(fmt.Sprintf("%v", actually), fmt.Sprintf("%v", expected[0])), nil
+	case expression.Contains, expression.StartsWith, expression.EndsWith:
+		return like(fmt.Sprintf("%v", actually), expression.LikePattern(o, expected[0])), nil
+	case expression.Between:
+		return len(expected) == 2 && expected[0] <= actually && actually <= expected[1], nil
+	case expression.NotBetween:
+		return len(expected) == 2 && (actually < expected[0] || expected[1] < actually), nil
+	case expression.IContains, expression.IStartsWith, expression.IEndsWith:
+		return like(strings.ToLower(fmt.Sprintf("%v", actually)), strings.ToLower(expression.LikePattern(o, expected[0]))), nil
+	case expression.NotContains:
+		return !like(fmt.Sprintf("%v", actually), expression.LikePattern(o, expected[0])), nil
+	case expression.Matches:
+		return regexp.MatchString(fmt.Sprintf("%v", expected[0]), fmt.Sprintf("%v", actually))
 	}
 	return false, fmt.Errorf("unsupported operator: %T %s %T", actually, o, expected)
 }
 
+// like matches the value with the pattern of LIKE clause: % is any sequence, _ is any character, expression.LikeEscape makes the next character literal
+func like(value, pattern string) bool {
+	var rx strings.Builder
+	rx.WriteString("(?s)^")
+	escaped := false
+	for _, r := range pattern {
+		switch {
+		case escaped:
+			rx.WriteString(regexp.QuoteMeta(string(r)))
+			escaped = false
+		case r == expression.LikeEscape:
+			escaped = true
+		case r == '%':
+			rx.WriteString(".*")
+		case r == '_':
+			rx.WriteString(".")
+		default:
+			rx.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	rx.WriteString("$")
+	return regexp.MustCompile(rx.String()).MatchString(value)
+}
+
 // asSlice converts the given value to a slice of the given type.
+// The numbers are converted to each other, e.g. the integer literal is compared with the float column.
 func asSlice[T any](v interface{}) ([]T, error) {
 	if items, ok := v.([]interface{}); ok {
 		var tmp []T
 		for i, item := range items {
 			var val T
-			if val, ok = item.(T); !ok {
+			if val, ok = asNumber[T](item); !ok {
 				return nil, fmt.Errorf("incorrect type: %T[%d]%T != %T", v, i, item, val)
 			}
 			tmp = append(tmp, val)
 		}
 		return tmp, nil
 	}
-	if val, ok := v.(T); ok {
+	if val, ok := asNumber[T](v); ok {
 		return []T{val}, nil
 	}
 	var val T
 	return nil, fmt.Errorf("incorrect type: %T != %T", v, val)
 }
+
+// asNumber returns the value of the given type, the numbers are converted when the value is kept exactly.
+func asNumber[T any](v interface{}) (T, bool) {
+	val, ok := v.(T)
+	if ok {
+		return val, true
+	}
+	rv, rt := reflect.ValueOf(v), reflect.TypeOf(val)
+	if !rv.IsValid() || rt == nil || !isNumberKind(rv.Kind()) || !isNumberKind(rt.Kind()) {
+		return val, false
+	}
+	if rv.CanInt() && rv.Int() < 0 && rt.Kind() >= reflect.Uint && rt.Kind() <= reflect.Uint64 {
+		return val, false
+	}
+	converted := rv.Convert(rt)
+	if !converted.Convert(rv.Type()).Equal(rv) && !isFloatKind(rt.Kind()) {
+		return val, false
+	}
+	return converted.Interface().(T), true
+}
+
+func isNumberKind(k reflect.Kind) bool {
+	return (k >= reflect.Int && k <= reflect.Uint64) || isFloatKind(k)
+}
+
+func isFloatKind(k reflect.Kind) bool {
+	return k == reflect.Float32 || k == reflect.Float64
+}
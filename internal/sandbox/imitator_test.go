@@ -49,7 +49,7 @@ func TestSimulate(t *testing.T) {
 	t.Run("WHERE is_enabled = true", func(t *testing.T) {
 		actually, err := ImitatorSql[int64, internalTask](
 			items,
-			[]*expression.Where{expression.NewWhere("is_enabled", expression.Equal, true)},
+			[]expression.Condition{expression.NewWhere("is_enabled", expression.Equal, true)},
 			nil,
 			nil,
 		)
@@ -83,7 +83,7 @@ func TestSimulate(t *testing.T) {
 			items,
 			nil,
 			nil,
-			[]*expression.OrderBy{expression.NewOrderBy("id", expression.Descending)},
+			[]expression.Modifier{expression.NewOrderBy("id", expression.Descending)},
 		)
 		require.NoError(t, err)
 		require.NotNil(t, actually)
@@ -99,9 +99,9 @@ func TestSimulate(t *testing.T) {
 	t.Run("WHERE id in (6,7); GROUP BY is_enabled; ORDER BY id", func(t *testing.T) {
 		actually, err := ImitatorSql[int64, internalTask](
 			items,
-			[]*expression.Where{expression.NewWhere("id", expression.In, 6, 3, 2, 7)},
+			[]expression.Condition{expression.NewWhere("id", expression.In, 6, 3, 2, 7)},
 			[]*expression.GroupBy{expression.NewGroupBy("subject_id")},
-			[]*expression.OrderBy{expression.NewOrderBy("id", expression.Descending)},
+			[]expression.Modifier{expression.NewOrderBy("id", expression.Descending)},
 		)
 		require.NoError(t, err)
 		require.NotNil(t, actually)
@@ -153,6 +153,31 @@ func TestImitatorSqlWhere(t *testing.T) {
 		require.Len(t, actually, 1)
 		require.Equal(t, m2, actually[0])
 	})
+	t.Run("id eq 2 or (id ge 6 and not is_enabled eq false)", func(t *testing.T) {
+		actually, err := ImitatorSqlWhere(items, expression.NewCombinerOR(
+			expression.NewWhere("id", expression.Equal, 2),
+			expression.NewCombinerAND(
+				expression.NewWhere("id", expression.GreaterThanOrEqual, 6),
+				expression.NewCombinerNOT(expression.NewWhere("is_enabled", expression.Equal, false)),
+			),
+		))
+		require.NoError(t, err)
+		require.Len(t, actually, 2)
+		require.Equal(t, m2, actually[0])
+		require.Equal(t, m6, actually[1])
+	})
+	t.Run("not last_sync_error eq ''", func(t *testing.T) {
+		actually, err := ImitatorSqlWhere(items, expression.NewCombinerNOT(expression.NewWhere("last_sync_error", expression.Equal, "")))
+		require.NoError(t, err)
+		require.Len(t, actually, 0)
+	})
+	t.Run("not contains(name,'um')", func(t *testing.T) {
+		actually, err := ImitatorSqlWhere(items, expression.NewCombinerNOT(expression.NewWhere("name", expression.Contains, "um")))
+		require.NoError(t, err)
+		require.Len(t, actually, 4)
+		require.Equal(t, m1, actually[0])
+		require.Equal(t, m3, actually[1])
+	})
 	t.Run("id in (2,3)", func(t *testing.T) {
 		actually, err := ImitatorSqlWhere(items, expression.NewWhere("id", expression.In, 2, 3))
 		require.NoError(t, err)
@@ -477,8 +502,8 @@ func TestRecognizeImitatorModel(t *testing.T) {
 	require.Equal(t, obj.IsEnabled, (*m)["is_enabled"])
 	require.Equal(t, obj.LastSyncError.String, (*m)["last_sync_error"])
 	require.Equal(t, obj.LastSyncModifiedAt, (*m)["last_sync_modified_at"])
-	require.Equal(t, []byte(obj.Metadata), (*m)["metadata"])
-	require.Equal(t, obj.RawExternalDataset.JSON, (*m)["raw_external_dataset"])
+	require.Equal(t, obj.Metadata, (*m)["metadata"])
+	require.Equal(t, types.JSON(obj.RawExternalDataset.JSON), (*m)["raw_external_dataset"])
 	require.Equal(t, obj.DeletedAt.Time, (*m)["deleted_at"])
 }
 
@@ -496,6 +521,11 @@ func TestToLowerTableName(t *testing.T) {
 	require.Equal(t, "task", toLowerTableName("TASK"))
 	require.Equal(t, "task", toLowerTableName("TASKS"))
 	require.Equal(t, "dataset", toLowerTableName("data_set"))
+	require.Equal(t, "box", toLowerTableName("boxes"))
+	require.Equal(t, "box", toLowerTableName("Box"))
+	require.Equal(t, "person", toLowerTableName("people"))
+	require.Equal(t, "usergroup", toLowerTableName("UserGroups"))
+	require.Equal(t, "status", toLowerTableName("statuses"))
 }
 
 func TestCompare(t *testing.T) {
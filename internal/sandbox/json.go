@@ -0,0 +1,70 @@
+package sandbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// jsonMember decodes the JSON value of column (types.JSON, null.JSON, []byte or string) and returns its member by the path,
+// nil means SQL NULL: the column or the member is absent or null. The objects and arrays are returned as JSON text,
+// the rest of members are returned as string, float64 or bool, the scalars are returned as text when the text is expected, the same as ->> of PostgreSQL.
+func jsonMember(value interface{}, path []string, text bool) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	var raw []byte
+	switch v := reflect.ValueOf(value); {
+	case v.Kind() == reflect.String:
+		raw = []byte(v.String())
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+		raw = v.Bytes()
+	default:
+		return nil, fmt.Errorf("incorrect JSON: %T", value)
+	}
+
+	var member interface{}
+	if err := json.Unmarshal(raw, &member); err != nil {
+		return nil, fmt.Errorf("incorrect JSON: %w", err)
+	}
+
+	for _, p := range path {
+		switch v := member.(type) {
+		case map[string]interface{}:
+			member = v[p]
+		case []interface{}:
+			i, err := strconv.Atoi(p)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, nil
+			}
+			member = v[i]
+		default:
+			return nil, nil
+		}
+	}
+
+	switch v := member.(type) {
+	case nil, string:
+		return v, nil
+	case float64, bool:
+		if !text {
+			return v, nil
+		}
+	}
+	b, err := json.Marshal(member)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// isText reports whether the expected value (or the first of list) is the text
+func isText(expected interface{}) bool {
+	if items, ok := expected.([]interface{}); ok && len(items) > 0 {
+		expected = items[0]
+	}
+	_, ok := expected.(string)
+	return ok
+}
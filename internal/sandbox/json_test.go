@@ -0,0 +1,56 @@
+package sandbox
+
+import (
+	"github.com/prorochestvo/sqlinjector/internal/expression"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/types"
+	"testing"
+)
+
+func TestImitatorModelEvaluateJSON(t *testing.T) {
+	type product struct {
+		ID       int        `boil:"id"`
+		Metadata null.JSON  `boil:"metadata"`
+		Options  types.JSON `boil:"options"`
+	}
+	items := make([]*ImitatorModel, 0)
+	for _, p := range []*product{
+		{ID: 1, Metadata: null.JSONFrom([]byte(`{"color":"red","size":{"width":10},"tags":["new","sale"],"active":true}`)), Options: types.JSON(`{"gift":false}`)},
+		{ID: 2, Metadata: null.JSONFrom([]byte(`{"color":"Blue","size":{"width":25},"tags":[],"active":false}`)), Options: types.JSON(`{"gift":true}`)},
+		{ID: 3, Options: types.JSON(`{}`)},
+	} {
+		m, err := RecognizeImitatorModel(p)
+		require.NoError(t, err)
+		items = append(items, m)
+	}
+
+	lower := &expression.Function{Name: "tolower", Args: []interface{}{&expression.Field{Column: "metadata", Path: []string{"color"}}}}
+	tests := []struct {
+		name      string
+		condition expression.Condition
+		expected  []interface{}
+	}{
+		{name: "string", condition: expression.NewWhereOfJSON("metadata", []string{"color"}, expression.Equal, "red"), expected: []interface{}{1}},
+		{name: "nested number", condition: expression.NewWhereOfJSON("metadata", []string{"size", "width"}, expression.GreaterThan, 20), expected: []interface{}{2}},
+		{name: "number as text", condition: expression.NewWhereOfJSON("metadata", []string{"size", "width"}, expression.Equal, "10"), expected: []interface{}{1}},
+		{name: "element of array", condition: expression.NewWhereOfJSON("metadata", []string{"tags", "1"}, expression.Equal, "sale"), expected: []interface{}{1}},
+		{name: "bool", condition: expression.NewWhereOfJSON("metadata", []string{"active"}, expression.Equal, true), expected: []interface{}{1}},
+		{name: "bool of types.JSON", condition: expression.NewWhereOfJSON("options", []string{"gift"}, expression.Equal, true), expected: []interface{}{2}},
+		{name: "null member", condition: expression.NewWhereOfJSON("metadata", []string{"tags", "0"}, expression.IsNull), expected: []interface{}{2, 3}},
+		{name: "member of function", condition: expression.NewWhereWithFunction(lower, expression.Equal, "blue"), expected: []interface{}{2}},
+		{name: "not", condition: expression.NewCombinerNOT(expression.NewWhereOfJSON("metadata", []string{"color"}, expression.Equal, "red")), expected: []interface{}{2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found, err := ImitatorSqlWhere(items, tt.condition)
+			require.NoError(t, err)
+			ids := make([]interface{}, len(found))
+			for i, item := range found {
+				ids[i], _ = item.GetValue("", "id")
+			}
+			require.ElementsMatch(t, tt.expected, ids)
+		})
+	}
+}
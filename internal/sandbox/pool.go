@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"github.com/prorochestvo/sqlinjector/internal"
 	"sync"
-	"time"
 )
 
 func NewPool() *Pool {
@@ -142,10 +141,11 @@ func (p *Pool) NewSQLite3() (internal.Vault, error) {
 		return nil, err
 	}
 
-	sqlBase.SetConnMaxLifetime(time.Minute * 5)
-	sqlBase.SetConnMaxIdleTime(time.Minute)
-	sqlBase.SetMaxOpenConns(13)
-	sqlBase.SetMaxIdleConns(3)
+	// every connection opens its own in-memory database, so the only connection is kept for whole life of the vault
+	sqlBase.SetConnMaxLifetime(0)
+	sqlBase.SetConnMaxIdleTime(0)
+	sqlBase.SetMaxOpenConns(1)
+	sqlBase.SetMaxIdleConns(1)
 
 	err = sqlBase.Ping()
 	if err != nil {
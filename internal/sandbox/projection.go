@@ -0,0 +1,49 @@
+package sandbox
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Project returns the copy of entity, which keeps the values of the given columns only (the same way as sqlboiler binds selected columns).
+// The fields without column (e.g. the relation structs R and L) are copied as is.
+func Project(entity interface{}, columns []string) (interface{}, error) {
+	v := reflect.ValueOf(entity)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("unsupported type %T of entity", entity)
+	}
+	v = v.Elem()
+	t := v.Type()
+
+	selected := make(map[string]struct{}, len(columns))
+	for _, c := range columns {
+		selected[c] = struct{}{}
+	}
+
+	res := reflect.New(t).Elem()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		columnName := strings.TrimSpace(f.Tag.Get("boil"))
+		if columnName == "-" || f.Name == "R" || f.Name == "L" {
+			res.Field(i).Set(v.Field(i))
+			continue
+		}
+		if columnName == "" {
+			columnName = f.Name
+		}
+		if _, ok := selected[columnName]; ok {
+			res.Field(i).Set(v.Field(i))
+			delete(selected, columnName)
+		}
+	}
+
+	for c := range selected {
+		return nil, fmt.Errorf("column %s not found into %T", c, entity)
+	}
+
+	return res.Addr().Interface(), nil
+}
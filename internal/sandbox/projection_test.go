@@ -0,0 +1,22 @@
+package sandbox
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestProject(t *testing.T) {
+	subject := &internalSubject{ID: "1", Name: "Subject01", IsEnabled: true}
+	task := &internalTask{ID: 1, Name: "N001", SubjectID: 1, IsEnabled: true, R: &internalTaskR{Subject: subject}}
+
+	actually, err := Project(task, []string{"id", "name"})
+	require.NoError(t, err)
+	require.Equal(t, &internalTask{ID: 1, Name: "N001", R: &internalTaskR{Subject: subject}}, actually)
+	require.Equal(t, int64(1), task.SubjectID)
+
+	_, err = Project(task, []string{"id", "password"})
+	require.Error(t, err)
+
+	_, err = Project(*task, []string{"id"})
+	require.Error(t, err)
+}
@@ -0,0 +1,29 @@
+package sandbox
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"github.com/glebarez/go-sqlite"
+	"regexp"
+)
+
+func init() {
+	// SQLite has the syntax of REGEXP operator without its implementation, X REGEXP Y calls the user function regexp(Y, X)
+	sqlite.MustRegisterDeterministicScalarFunction("regexp", 2, sqliteRegexp)
+}
+
+// sqliteRegexp reports whether the value matches the pattern by the syntax of Go, NULL is returned for NULL of any argument
+func sqliteRegexp(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+	if args[0] == nil || args[1] == nil {
+		return nil, nil
+	}
+	pattern, value := fmt.Sprintf("%s", args[0]), fmt.Sprintf("%v", args[1])
+	if b, ok := args[1].([]byte); ok {
+		value = string(b)
+	}
+	rx, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return rx.MatchString(value), nil
+}
@@ -0,0 +1,114 @@
+package sandbox
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Relate loads the related entities into the relation field of entity.R (the same way as sqlboiler eager loading does).
+// The related entity is matched when its foreignKey column equals the localKey column of the entity.
+// A pointer field of R receives the first matched entity, a slice field receives all of them.
+func Relate(entity interface{}, relation string, related []interface{}, localKey, foreignKey string) error {
+	v := reflect.ValueOf(entity)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("unsupported type %T of entity", entity)
+	}
+	v = v.Elem()
+
+	r := v.FieldByName("R")
+	if !r.IsValid() || r.Kind() != reflect.Pointer || r.Type().Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("relation struct R not found into %T", entity)
+	}
+	if r.IsNil() {
+		r.Set(reflect.New(r.Type().Elem()))
+	}
+
+	field, ok := relationField(r.Elem(), relation)
+	if !ok {
+		return fmt.Errorf("relation %s not found into %s", relation, r.Type().Elem())
+	}
+
+	m, err := RecognizeImitatorModel(entity)
+	if err != nil {
+		return err
+	}
+	key, exists := m.GetValue("", localKey)
+	if !exists {
+		return fmt.Errorf("%s not found into %T", localKey, entity)
+	}
+
+	matched := make([]reflect.Value, 0, 1)
+	for _, item := range related {
+		if item == nil {
+			continue
+		}
+		var rm *ImitatorModel
+		rm, err = RecognizeImitatorModel(item)
+		if err != nil {
+			return err
+		}
+		var val interface{}
+		if val, exists = rm.GetValue("", foreignKey); !exists {
+			return fmt.Errorf("%s not found into %T", foreignKey, item)
+		}
+		if key == nil || val == nil || !equalValues(key, val) {
+			continue
+		}
+		matched = append(matched, reflect.ValueOf(item))
+	}
+
+	switch field.Kind() {
+	case reflect.Pointer:
+		field.Set(reflect.Zero(field.Type()))
+		if len(matched) > 0 {
+			if !matched[0].Type().AssignableTo(field.Type()) {
+				return fmt.Errorf("type mismatch: cannot assign %s to %s", matched[0].Type(), field.Type())
+			}
+			field.Set(matched[0])
+		}
+	case reflect.Slice:
+		items := reflect.MakeSlice(field.Type(), 0, len(matched))
+		for _, item := range matched {
+			if !item.Type().AssignableTo(field.Type().Elem()) {
+				return fmt.Errorf("type mismatch: cannot assign %s to %s", item.Type(), field.Type().Elem())
+			}
+			items = reflect.Append(items, item)
+		}
+		field.Set(items)
+	default:
+		return fmt.Errorf("unsupported type %s of relation %s", field.Type(), relation)
+	}
+
+	return nil
+}
+
+// SameTable reports whether both names refer to the same table or relation, e.g. "Author", "authors" and "author".
+func SameTable(a, b string) bool {
+	return toLowerTableName(a) == toLowerTableName(b)
+}
+
+// relationField looks for the field of relation struct by boil tag or by name.
+func relationField(r reflect.Value, relation string) (reflect.Value, bool) {
+	t := r.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.TrimSpace(f.Tag.Get("boil"))
+		if name == "" || name == "-" {
+			name = f.Name
+		}
+		if name == relation || f.Name == relation || SameTable(name, relation) {
+			return r.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// equalValues compares a pair of recognized values.
+func equalValues(a, b interface{}) bool {
+	if c, ok := LookupComparator(a); ok && c.Order != nil {
+		r, err := c.Order(a, b)
+		return err == nil && r == 0
+	}
+	return reflect.DeepEqual(a, b)
+}
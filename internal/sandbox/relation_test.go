@@ -0,0 +1,50 @@
+package sandbox
+
+import (
+	"github.com/prorochestvo/sqlinjector/internal"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestRelate(t *testing.T) {
+	s1 := &internalSubject{ID: "1", Name: "Subject01"}
+	s2 := &internalSubject{ID: "2", Name: "Subject02"}
+	related := []interface{}{s1, s2}
+
+	t.Run("to-one", func(t *testing.T) {
+		task := &internalTask{ID: 1, SubjectID: 2}
+		err := Relate(task, "Subject", related, "subject_id", "id")
+		require.NoError(t, err)
+		require.Nil(t, task.R.Subject, "subject_id is int, id is string")
+
+		task = &internalTask{ID: 1, Name: "2"}
+		err = Relate(task, "Subject", related, "name", "id")
+		require.NoError(t, err)
+		require.NotNil(t, task.R)
+		require.Equal(t, s2, task.R.Subject)
+	})
+	t.Run("not matched", func(t *testing.T) {
+		task := &internalTask{ID: 1, Name: "3", R: &internalTaskR{Subject: s1}}
+		err := Relate(task, "subjects", related, "name", "id")
+		require.NoError(t, err)
+		require.Nil(t, task.R.Subject)
+	})
+	t.Run("unknown relation", func(t *testing.T) {
+		task := &internalTask{ID: 1, Name: "1"}
+		err := Relate(task, "Owner", related, "name", "id")
+		require.Error(t, err)
+	})
+}
+
+func TestSameTable(t *testing.T) {
+	require.True(t, SameTable("Subject", "subjects"))
+	require.True(t, SameTable("Category", "categories"))
+	require.False(t, SameTable("Subject", "Task"))
+	require.True(t, SameTable("Box", "boxes"))
+	require.True(t, SameTable("Person", "people"))
+	require.True(t, SameTable("UserGroup", "user_groups"))
+
+	internal.SetNaming(internal.BoilNaming{Prefix: "app_"})
+	defer internal.SetNaming(nil)
+	require.True(t, SameTable("UserGroup", "app_user_groups"))
+}
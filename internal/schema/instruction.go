@@ -68,12 +68,21 @@ func (i *instruction) MD5() string {
 }
 
 // MakeTableInstruction creates a migration instruction for SQLite3 database from a struct
+// The empty table name is derived from the name of struct by the naming strategy, e.g. "UserGroup" is turned into "user_groups".
 func MakeTableInstruction(tableName string, tableFields interface{}, dialect internal.Dialect) (Instruction, error) {
 	fields, err := parseTableFields(tableFields)
 	if err != nil {
 		return nil, err
 	}
 
+	if tableName == "" {
+		model := reflect.Indirect(reflect.ValueOf(tableFields)).Type().Name()
+		if model == "" {
+			return nil, fmt.Errorf("table name is required for anonymous struct %T", tableFields)
+		}
+		tableName = internal.TableName(model)
+	}
+
 	f := make([]string, 0, len(fields))
 	for k, v := range fields {
 		var t string
@@ -4,6 +4,7 @@ import (
 	"embed"
 	"fmt"
 	"github.com/prorochestvo/sqlinjector/internal"
+	"github.com/prorochestvo/sqlinjector/internal/expression"
 	"github.com/prorochestvo/sqlinjector/internal/schema"
 	"hash/crc64"
 	"net/http"
@@ -204,7 +205,7 @@ func NewMemoryMigration(up, down string, id ...string) (Migration, error) {
 }
 
 // NewStructMigration creates a new migration for SQLite3 database from a struct
-// The struct must be a pointer to a sqlboiler struct.
+// The struct must be a pointer to a sqlboiler struct, the empty table is named by the naming strategy (see SetNamingStrategy).
 func NewStructMigration(boil interface{}, table string, dialect internal.Dialect) (Migration, error) {
 	items, err := schema.MakeTableInstruction(table, boil, dialect)
 	if err != nil || items == nil {
@@ -220,6 +221,22 @@ func NewStructMigration(boil interface{}, table string, dialect internal.Dialect
 	return m, nil
 }
 
+// NewSearchMigration creates a new migration of the indexes required by Search over the columns of table:
+// GIN index of PostgreSQL, FULLTEXT index of MySQL or FTS5 virtual table of SQLite, which is kept in sync with the table by triggers.
+// The migration is identified by the table and columns, e.g. "articles_title_body_search", so it is applied once.
+func NewSearchMigration(table string, columns []string, dialect internal.Dialect) (Migration, error) {
+	up, down, err := expression.SearchMigration(dialect, table, columns)
+	if err != nil {
+		return nil, err
+	}
+	items := schema.NewInstruction(fmt.Sprintf("%s_%s_search", table, strings.Join(columns, "_")), up, down)
+	m := make(Migration, len(items))
+	for i, item := range items {
+		m[i] = item
+	}
+	return m, nil
+}
+
 // MultipleMigration combines multiple migrations into one
 func MultipleMigration(migrations ...Migration) Migration {
 	res := make(Migration, 0, len(migrations))
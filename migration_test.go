@@ -598,6 +598,24 @@ func TestNewStructMigration(t *testing.T) {
 	require.Equal(t, "CREATE"+" TABLE IF NOT EXISTS demo (\n   id INTEGER NOT NULL DEFAULT 0 PRIMARY KEY);", m[0].(instructionUp).Up())
 	require.Equal(t, "DROP"+" TABLE IF EXISTS demo;", m[0].(instructionDown).Down())
 	require.Equal(t, makeMD5(m[0].(instructionUp).Up()+"\n"+m[0].(instructionDown).Down()), m[0].MD5())
+
+	t.Run("NamingStrategy", func(t *testing.T) {
+		type UserBox struct {
+			ID int `boil:"id" `
+		}
+		SetNamingStrategy(NewBoilNamingStrategy("app_"))
+		t.Cleanup(func() { SetNamingStrategy(nil) })
+
+		m, err := NewStructMigration(&UserBox{}, "", internal.DialectSQLite3)
+		require.NoError(t, err)
+		require.Len(t, m, 1)
+		require.Equal(t, "DROP"+" TABLE IF EXISTS app_user_boxes;", m[0].(instructionDown).Down())
+
+		_, err = NewStructMigration(&struct {
+			ID int `boil:"id" `
+		}{}, "", internal.DialectSQLite3)
+		require.Error(t, err)
+	})
 }
 
 //go:embed internal/schema/*.sql
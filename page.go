@@ -0,0 +1,95 @@
+package sqlinjector
+
+import (
+	"github.com/prorochestvo/sqlinjector/internal/expression"
+	"golang.org/x/exp/constraints"
+	"net/url"
+	"strconv"
+)
+
+// Page is the envelope of entities obtained by OData request
+type Page[DATASET any] struct {
+	Items    []*DATASET `json:"items"`
+	Count    *int64     `json:"@odata.count,omitempty"`
+	NextLink string     `json:"@odata.nextLink,omitempty"`
+}
+
+// ObtainPage obtains the page of entities from Repository and fills the envelope.
+// The total count is obtained when it is requested by $count=true, it ignores limit, offset, order, select and relations of the expressions,
+// the count and the page are obtained consistently when the repository implements Pager (e.g. in the one transaction of SandboxRepository
+// or under the same lock of DummyRepository), otherwise they are obtained by Count and ObtainAll one after another.
+// The next link is made of the link by advancing the offset (under the parameter name of OData request, $skip for the request by $top),
+// it is empty for the last page, without limit or link.
+func ObtainPage[DATAKEY constraints.Ordered, DATASET any](repo Repository[DATAKEY, DATASET], link *url.URL, expressions ...Expression) (*Page[DATASET], error) {
+	counting := false
+	limit, offset := -1, 0
+	skip := defaultQueryNameOffset
+	for _, e := range expressions {
+		if c, ok := e.(*combiner); ok && c.skip != "" {
+			skip = c.skip
+		}
+	}
+	filtering := make([]Expression, 0, len(expressions))
+	for _, e := range flatten(expressions) {
+		switch v := e.(type) {
+		case *expression.Count:
+			counting = true
+			continue
+		case *expression.Limit:
+			limit = v.Limit()
+			continue
+		case *expression.Offset:
+			offset = v.Offset()
+			continue
+		case *expression.OrderBy, *expression.Select, *expression.Relation:
+			continue
+		}
+		filtering = append(filtering, e)
+	}
+
+	page := &Page[DATASET]{}
+
+	var err error
+	if p, ok := repo.(Pager[DATASET]); ok && counting {
+		var count int64
+		if page.Items, count, err = p.ObtainPage(filtering, expressions); err != nil {
+			return nil, err
+		}
+		page.Count = &count
+	} else {
+		if counting {
+			var count int64
+			if count, err = repo.Count(filtering...); err != nil {
+				return nil, err
+			}
+			page.Count = &count
+		}
+		if page.Items, err = repo.ObtainAll(expressions...); err != nil {
+			return nil, err
+		}
+	}
+
+	if page.Items == nil {
+		page.Items = make([]*DATASET, 0)
+	}
+
+	next := len(page.Items) == limit
+	if page.Count != nil {
+		next = int64(offset+len(page.Items)) < *page.Count
+	}
+	if link != nil && limit > 0 && next {
+		q := link.Query()
+		q.Set(skip, strconv.Itoa(offset+limit))
+		u := *link
+		u.RawQuery = q.Encode()
+		page.NextLink = u.String()
+	}
+
+	return page, nil
+}
+
+// Pager is implemented by repositories, which obtain the page and the total count consistently,
+// the count is made of the filtering expressions and the page is made of all expressions.
+type Pager[DATASET any] interface {
+	ObtainPage(filtering []Expression, expressions []Expression) ([]*DATASET, int64, error)
+}
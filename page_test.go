@@ -0,0 +1,105 @@
+package sqlinjector
+
+import (
+	"encoding/json"
+	"github.com/prorochestvo/sqlinjector/internal/expression"
+	"github.com/stretchr/testify/require"
+	"net/url"
+	"testing"
+)
+
+var _ Pager[internalSubject] = &DummyRepository[string, internalSubject]{}
+
+func TestObtainPage(t *testing.T) {
+	repo, err := NewDummySqlBoilerRepository[string, internalSubject](
+		&internalSubject{ID: "1", Name: "SubjectName 1", IsEnabled: true},
+		&internalSubject{ID: "2", Name: "SubjectName 2", IsEnabled: true},
+		&internalSubject{ID: "3", Name: "SubjectName 3", IsEnabled: false},
+		&internalSubject{ID: "4", Name: "SubjectName 4", IsEnabled: true},
+		&internalSubject{ID: "5", Name: "SubjectName 5", IsEnabled: true},
+		&internalSubject{ID: "6", Name: "SubjectName 6", IsEnabled: true},
+		&internalSubject{ID: "7", Name: "SubjectName 7", IsEnabled: false},
+	)
+	require.NoError(t, err)
+
+	link, err := url.Parse("https://example.com/subjects?$filter=name%20ne%20'SubjectName%203'%20and%20name%20ne%20'SubjectName%207'&$count=true&$Limit=2")
+	require.NoError(t, err)
+
+	t.Run("FirstPage", func(t *testing.T) {
+		q := link.Query()
+		e, err := ODataExpression(&q)
+		require.NoError(t, err)
+
+		page, err := ObtainPage[string, internalSubject](repo, link, e, OrderBy("id", Ascending))
+		require.NoError(t, err)
+		require.NotNil(t, page.Count)
+		require.Equal(t, int64(5), *page.Count)
+		require.Len(t, page.Items, 2)
+		require.Equal(t, "1", page.Items[0].ID)
+		require.Equal(t, "2", page.Items[1].ID)
+
+		next, err := url.Parse(page.NextLink)
+		require.NoError(t, err)
+		require.Equal(t, "2", next.Query().Get(defaultQueryNameOffset))
+		require.Equal(t, "true", next.Query().Get(defaultQueryNameCount))
+	})
+	t.Run("LastPage", func(t *testing.T) {
+		q := link.Query()
+		q.Set(defaultQueryNameOffset, "4")
+		e, err := ODataExpression(&q)
+		require.NoError(t, err)
+
+		page, err := ObtainPage[string, internalSubject](repo, link, e, OrderBy("id", Ascending))
+		require.NoError(t, err)
+		require.Equal(t, int64(5), *page.Count)
+		require.Len(t, page.Items, 1)
+		require.Equal(t, "6", page.Items[0].ID)
+		require.Empty(t, page.NextLink)
+	})
+	t.Run("StandardAliases", func(t *testing.T) {
+		q := url.Values{"$top": {"3"}, "$skip": {"1"}, "$orderby": {"id asc"}}
+		e, err := ODataExpression(&q)
+		require.NoError(t, err)
+
+		page, err := ObtainPage[string, internalSubject](repo, &url.URL{Path: "/subjects", RawQuery: q.Encode()}, e)
+		require.NoError(t, err)
+		require.Len(t, page.Items, 3)
+		require.Equal(t, "2", page.Items[0].ID)
+		require.Equal(t, "/subjects?%24orderby=id+asc&%24skip=4&%24top=3", page.NextLink)
+	})
+	t.Run("FirstPageOfStandardTop", func(t *testing.T) {
+		q := url.Values{"$top": {"3"}, "$orderby": {"id asc"}}
+		e, err := ODataExpression(&q)
+		require.NoError(t, err)
+
+		page, err := ObtainPage[string, internalSubject](repo, &url.URL{Path: "/subjects", RawQuery: q.Encode()}, e)
+		require.NoError(t, err)
+		require.Len(t, page.Items, 3)
+		require.Equal(t, "/subjects?%24orderby=id+asc&%24skip=3&%24top=3", page.NextLink)
+	})
+	t.Run("WithoutCount", func(t *testing.T) {
+		page, err := ObtainPage[string, internalSubject](repo, link, Where("enabled", Equal, false), Limit(2))
+		require.NoError(t, err)
+		require.Nil(t, page.Count)
+		require.Len(t, page.Items, 2)
+		require.NotEmpty(t, page.NextLink)
+
+		b, err := json.Marshal(&Page[internalSubject]{Items: page.Items[:0]})
+		require.NoError(t, err)
+		require.JSONEq(t, `{"items":[]}`, string(b))
+	})
+	t.Run("WithoutPager", func(t *testing.T) {
+		var plain Repository[string, internalSubject] = struct {
+			Repository[string, internalSubject]
+		}{repo}
+		_, ok := plain.(Pager[internalSubject])
+		require.False(t, ok)
+
+		page, err := ObtainPage[string, internalSubject](plain, link, Where("enabled", Equal, true), expression.NewCount(), Limit(2), Offset(2))
+		require.NoError(t, err)
+		require.NotNil(t, page.Count)
+		require.Equal(t, int64(5), *page.Count)
+		require.Len(t, page.Items, 2)
+		require.NotEmpty(t, page.NextLink)
+	})
+}
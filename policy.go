@@ -0,0 +1,315 @@
+package sqlinjector
+
+import (
+	"errors"
+	"fmt"
+	"github.com/prorochestvo/sqlinjector/internal/expression"
+	"github.com/prorochestvo/sqlinjector/internal/sandbox"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// FieldPolicy maps the public field of OData request to the column and restricts its usage
+type FieldPolicy struct {
+	// Column is the column of the field, the column of related table is prefixed by its name, e.g. "author/name". The public name is used by default.
+	Column string
+	// Filterable allows the field in $filter
+	Filterable bool
+	// Sortable allows the field in $orderby
+	Sortable bool
+	// Selectable allows the field in $select
+	Selectable bool
+	// Operators restricts the operators of $filter allowed for the field, all ones are allowed when it is empty.
+	Operators []operator
+}
+
+// FieldError is returned when the request uses the field, which is not allowed by the policy, it means 400 Bad Request.
+type FieldError struct {
+	Field    string
+	Usage    string
+	Operator operator
+}
+
+func (e *FieldError) Error() string {
+	if e.Operator != "" {
+		return fmt.Sprintf("operator %s is not allowed for field %s", e.Operator, e.Field)
+	}
+	return fmt.Sprintf("field %s is not %s", e.Field, e.Usage)
+}
+
+// StatusCode returns the HTTP status of the error
+func (e *FieldError) StatusCode() int {
+	return http.StatusBadRequest
+}
+
+// fieldPolicy checks the fields of the request by their public names (the related table is separated by "/") and maps them into columns.
+// The unknown fields are rejected the same way as not allowed ones, so the schema could not be probed.
+type fieldPolicy map[string]FieldPolicy
+
+// column returns the table and the column of the public field, which is allowed by the usage
+func (p fieldPolicy) column(table, column, usage string) (string, string, error) {
+	name := column
+	if table != "" {
+		name = table + "/" + column
+	}
+
+	f, ok := p[name]
+	allowed := ok && ((usage == usageFilter && f.Filterable) || (usage == usageOrder && f.Sortable) || (usage == usageSelect && f.Selectable))
+	if !allowed {
+		return "", "", &FieldError{Field: name, Usage: usage}
+	}
+
+	if f.Column != "" {
+		name = f.Column
+	}
+	if t, c, found := strings.Cut(name, "/"); found {
+		return t, c, nil
+	}
+	return "", name, nil
+}
+
+// where checks and maps the fields of the condition including the arguments of functions
+func (p fieldPolicy) where(c expression.Condition) error {
+	for _, w := range expression.Leaves(c) {
+		var err error
+		if w.Function == nil {
+			if err = p.operator(w.Table, w.Column, w.Operator); err != nil {
+				return err
+			}
+			if w.Table, w.Column, err = p.column(w.Table, w.Column, usageFilter); err != nil {
+				return err
+			}
+		} else {
+			fields := w.Function.Fields()
+			for _, f := range fields {
+				if err = p.operator(f.Table, f.Column, w.Operator); err != nil {
+					return err
+				}
+				if f.Table, f.Column, err = p.column(f.Table, f.Column, usageFilter); err != nil {
+					return err
+				}
+			}
+			w.Table, w.Column = fields[0].Table, fields[0].Column
+		}
+		if v, ok := w.Value.(*expression.Function); ok {
+			for _, f := range v.Fields() {
+				if f.Table, f.Column, err = p.column(f.Table, f.Column, usageFilter); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	for _, l := range expression.Lambdas(c) {
+		if err := p.lambda(l); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lambda checks and maps the collection of lambda, the operators over the elements of array are checked by the policy of array,
+// the columns of relation are checked by their public names prefixed by the relation, e.g. "items/qty".
+func (p fieldPolicy) lambda(l *expression.Lambda) error {
+	name := l.Column
+	_, column, err := p.column("", name, usageFilter)
+	if err != nil {
+		return err
+	}
+	l.Column = column
+	if l.Condition == nil {
+		return nil
+	}
+
+	leaves := expression.Leaves(l.Condition)
+	if !l.Relation {
+		for _, w := range leaves {
+			if err = p.operator("", name, w.Operator); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// the columns of related table are prefixed by the relation to be checked as the public fields
+	for _, w := range leaves {
+		w.Table = name
+		if w.Function != nil {
+			for _, f := range w.Function.Fields() {
+				f.Table = name
+			}
+		}
+	}
+	err = p.where(l.Condition)
+	for _, w := range leaves {
+		w.Table = ""
+		if w.Function != nil {
+			for _, f := range w.Function.Fields() {
+				f.Table = ""
+			}
+		}
+	}
+	return err
+}
+
+// operator checks the operator of the public field
+func (p fieldPolicy) operator(table, column string, o operator) error {
+	name := column
+	if table != "" {
+		name = table + "/" + column
+	}
+	if f, ok := p[name]; ok && f.Filterable && len(f.Operators) > 0 && !slices.Contains(f.Operators, o) {
+		return &FieldError{Field: name, Usage: usageFilter, Operator: o}
+	}
+	return nil
+}
+
+// orderBy checks and maps the field of the order
+func (p fieldPolicy) orderBy(o *expression.OrderBy) (err error) {
+	o.Table, o.Column, err = p.column(o.Table, o.Column, usageOrder)
+	return
+}
+
+// aggregation checks and maps the fields of $apply: the conditions are checked as the ones of $filter, the grouped and aggregated columns are selectable,
+// the selection of grouped columns is rebuilt by the mapped columns
+func (p fieldPolicy) aggregation(mods []expression.Modifier) error {
+	var grouped []string
+	for _, m := range mods {
+		var err error
+		switch v := m.(type) {
+		case expression.Condition:
+			err = p.where(v)
+		case *expression.GroupBy:
+			if v.Table, v.Column, err = p.column(v.Table, v.Column, usageSelect); err == nil {
+				grouped = append(grouped, strings.TrimPrefix(v.Table+"/"+v.Column, "/"))
+			}
+		case *expression.Aggregate:
+			// the aggregate is shared with Having, so it is mapped once
+			if v.Column != "" {
+				v.Table, v.Column, err = p.column(v.Table, v.Column, usageSelect)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	for i, m := range mods {
+		if _, ok := m.(*expression.Select); ok && len(grouped) > 0 {
+			s, err := expression.NewSelectFrom(strings.Join(grouped, ","))
+			if err != nil {
+				return err
+			}
+			mods[i] = s
+		}
+	}
+	return nil
+}
+
+// relation checks and maps the nested options of $expand by the fields of related table, i.e. the fields prefixed by the path of relation,
+// e.g. the field "author/name" is the field "name" of $expand=Author($filter=name eq 'Leo')
+func (p fieldPolicy) relation(r *expression.Relation) error {
+	related := p.related(r.Relation())
+	mods := r.Mods()
+	for i, m := range mods {
+		var err error
+		switch v := m.(type) {
+		case expression.Condition:
+			err = related.where(v)
+		case *expression.OrderBy:
+			err = related.orderBy(v)
+		case *expression.Select:
+			var columns string
+			if columns, err = related.selection(expression.ToSelect(v)); err == nil {
+				var s *expression.Select
+				s, err = expression.NewSelectFrom(columns)
+				mods[i] = s
+			}
+		}
+		var fieldError *FieldError
+		if errors.As(err, &fieldError) {
+			fieldError.Field = strings.Join(r.Relation(), "/") + "/" + fieldError.Field
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// related returns the policy of fields of the related table, the fields and their columns are named without the prefix of relation,
+// the fields mapped into the columns of other tables are omitted
+func (p fieldPolicy) related(path []string) fieldPolicy {
+	within := func(name string) ([]string, bool) {
+		segments := strings.Split(name, "/")
+		if len(segments) <= len(path) {
+			return nil, false
+		}
+		for i, t := range path {
+			if !sandbox.SameTable(segments[i], t) {
+				return nil, false
+			}
+		}
+		return segments[len(path):], true
+	}
+
+	res := make(fieldPolicy)
+	for name, f := range p {
+		field, ok := within(name)
+		if !ok {
+			continue
+		}
+		if f.Column != "" {
+			column, ok := within(f.Column)
+			if !ok {
+				continue
+			}
+			f.Column = strings.Join(column, "/")
+		}
+		res[strings.Join(field, "/")] = f
+	}
+	return res
+}
+
+// selection maps the comma-separated public fields of $select into columns, "*" is turned into all selectable fields
+func (p fieldPolicy) selection(expr string) (string, error) {
+	items := strings.Split(expr, ",")
+	columns := make([]string, 0, len(items))
+	for _, item := range items {
+		item = strings.ReplaceAll(strings.TrimSpace(item), ".", "/")
+		if item == "*" {
+			for _, name := range p.selectable() {
+				t, c, _ := p.column("", name, usageSelect)
+				columns = append(columns, strings.TrimPrefix(t+"/"+c, "/"))
+			}
+			continue
+		}
+		t, c, found := strings.Cut(item, "/")
+		if !found {
+			t, c = "", item
+		}
+		t, c, err := p.column(t, c, usageSelect)
+		if err != nil {
+			return "", err
+		}
+		columns = append(columns, strings.TrimPrefix(t+"/"+c, "/"))
+	}
+	return strings.Join(columns, ","), nil
+}
+
+// selectable returns the sorted public names of the selectable fields
+func (p fieldPolicy) selectable() []string {
+	names := make([]string, 0, len(p))
+	for name, f := range p {
+		if f.Selectable {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+const (
+	usageFilter = "filterable"
+	usageOrder  = "sortable"
+	usageSelect = "selectable"
+)
@@ -0,0 +1,101 @@
+package sqlinjector
+
+import (
+	"errors"
+	"github.com/prorochestvo/sqlinjector/internal/expression"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestFieldPolicy(t *testing.T) {
+	options := ODataOptions{
+		Fields: map[string]FieldPolicy{
+			"id":          {Filterable: true, Sortable: true, Selectable: true},
+			"name":        {Column: "full_name", Filterable: true, Sortable: true, Selectable: true, Operators: []operator{Equal, Contains}},
+			"created":     {Column: "created_at", Sortable: true},
+			"author/name": {Column: "user/name", Filterable: true, Selectable: true},
+		},
+	}
+
+	t.Run("Mapped", func(t *testing.T) {
+		q := url.Values{
+			defaultQueryNameWhere:  {"contains(tolower(name),'john') and author/name eq 'Leo'"},
+			defaultQueryNameOrder:  {"created desc, id"},
+			defaultQueryNameSelect: {"id, name, author.name"},
+		}
+		e, err := ODataExpressionWithOptions(&q, options)
+		require.NoError(t, err)
+
+		c := e.(*combiner)
+		require.Len(t, c.expressions, 5)
+		require.Equal(t, "tolower(full_name) contains john", c.expressions[0].(*expression.Where).ToString())
+		require.Equal(t, "users.name eq Leo", c.expressions[1].(*expression.Where).ToString())
+		require.Equal(t, "created_at DESC", c.expressions[2].(*expression.OrderBy).ToString())
+		require.Equal(t, "id", c.expressions[3].(*expression.OrderBy).ToString())
+		require.Equal(t, []string{"id", "full_name", "user.name"}, c.expressions[4].(*expression.Select).Select())
+	})
+	t.Run("SelectAll", func(t *testing.T) {
+		q := url.Values{defaultQueryNameSelect: {"*"}}
+		e, err := ODataExpressionWithOptions(&q, options)
+		require.NoError(t, err)
+		require.Equal(t, []string{"user.name", "id", "full_name"}, e.(*combiner).expressions[0].(*expression.Select).Select())
+	})
+	t.Run("Expand", func(t *testing.T) {
+		options := ODataOptions{
+			Expandable: []string{"Author"},
+			Fields: map[string]FieldPolicy{
+				"id":           {Filterable: true},
+				"author/name":  {Column: "authors/full_name", Filterable: true, Sortable: true, Selectable: true},
+				"author/email": {Column: "user/email", Filterable: true},
+			},
+		}
+
+		q := url.Values{defaultQueryNameExpand: {"Author($filter=name eq 'Leo';$orderby=name;$select=name)"}}
+		e, err := ODataExpressionWithOptions(&q, options)
+		require.NoError(t, err)
+		r := e.(*combiner).expressions[0].(*expression.Relation)
+		require.Len(t, r.Mods(), 3)
+		require.Equal(t, "full_name eq Leo", r.Mods()[0].(*expression.Where).ToString())
+		require.Equal(t, "full_name", r.Mods()[1].(*expression.OrderBy).ToString())
+		require.Equal(t, []string{"full_name"}, r.Mods()[2].(*expression.Select).Select())
+
+		for query, expected := range map[string]string{
+			"Author($filter=password_hash eq 'x')":                 "field Author/password_hash is not filterable",
+			"Author($filter=name eq 'Leo' or length(secret) gt 1)": "field Author/secret is not filterable",
+			"Author($orderby=id)":                                  "field Author/id is not sortable",
+			"Author($select=name,password_hash)":                   "field Author/password_hash is not selectable",
+			"Author($filter=email eq 'x')":                         "field Author/email is not filterable",
+		} {
+			q := url.Values{defaultQueryNameExpand: {query}}
+			_, err := ODataExpressionWithOptions(&q, options)
+			require.EqualError(t, err, expected)
+		}
+	})
+
+	tests := []struct {
+		name     string
+		query    url.Values
+		expected string
+	}{
+		{name: "unknown field of filter", query: url.Values{defaultQueryNameWhere: {"password eq 'secret'"}}, expected: "field password is not filterable"},
+		{name: "unknown field of function", query: url.Values{defaultQueryNameWhere: {"length(password) gt 3"}}, expected: "field password is not filterable"},
+		{name: "unknown field of value", query: url.Values{defaultQueryNameWhere: {"name eq tolower(password)"}}, expected: "field password is not filterable"},
+		{name: "not filterable", query: url.Values{defaultQueryNameWhere: {"id eq '1' or created gt '2024-01-01'"}}, expected: "field created is not filterable"},
+		{name: "not allowed operator", query: url.Values{defaultQueryNameWhere: {"startswith(name,'J')"}}, expected: "operator startswith is not allowed for field name"},
+		{name: "not sortable", query: url.Values{defaultQueryNameOrder: {"author/name"}}, expected: "field author/name is not sortable"},
+		{name: "not selectable", query: url.Values{defaultQueryNameSelect: {"id,created"}}, expected: "field created is not selectable"},
+		{name: "column instead of field", query: url.Values{defaultQueryNameSelect: {"full_name"}}, expected: "field full_name is not selectable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ODataExpressionWithOptions(&tt.query, options)
+			require.EqualError(t, err, tt.expected)
+
+			var fieldError *FieldError
+			require.True(t, errors.As(err, &fieldError))
+			require.Equal(t, http.StatusBadRequest, fieldError.StatusCode())
+		})
+	}
+}
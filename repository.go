@@ -5,6 +5,9 @@ import (
 	"github.com/prorochestvo/sqlinjector/internal/expression"
 	"github.com/prorochestvo/sqlinjector/internal/sandbox"
 	"golang.org/x/exp/constraints"
+	"reflect"
+	"slices"
+	"strings"
 	"sync"
 )
 
@@ -44,6 +47,13 @@ func NewDummySqlBoilerRepository[DATAKEY constraints.Ordered, DATASET any](items
 		}
 
 		res, exists = val.(DATAKEY)
+		if !exists {
+			// the imitator keeps integers as int and uint, so int64 and other keys are converted back
+			rVal, rType := reflect.ValueOf(val), reflect.TypeOf(res)
+			if rVal.IsValid() && rVal.CanInt() == (rType.Kind() >= reflect.Int && rType.Kind() <= reflect.Int64) && rVal.CanConvert(rType) && rType.Kind() != reflect.String {
+				res, exists = rVal.Convert(rType).Interface().(DATAKEY)
+			}
+		}
 		if !exists {
 			err = fmt.Errorf("%T is incorrect id field into %T(%v)", val, model, model)
 		}
@@ -51,21 +61,41 @@ func NewDummySqlBoilerRepository[DATAKEY constraints.Ordered, DATASET any](items
 		return
 	}
 	obtainItems := func(items map[DATAKEY]*DATASET, expressions []Expression) (res []*DATASET, err error) {
-		var where []*expression.Where
+		var where []expression.Condition
 		var groupBy []*expression.GroupBy
-		var orderBy []*expression.OrderBy
-		for _, e := range expressions {
-			if w, ok := e.(*expression.Where); ok {
+		var orderBy []expression.Modifier
+		limit, offset := -1, 0
+		for _, e := range flatten(expressions) {
+			if _, ok := e.(*expression.Having); ok {
+				// the aggregation is not imitated, the same as the aggregates
+				continue
+			}
+			if w, ok := e.(expression.Condition); ok {
 				where = append(where, w)
 			}
 			if g, ok := e.(*expression.GroupBy); ok {
 				groupBy = append(groupBy, g)
 			}
-			if o, ok := e.(*expression.OrderBy); ok {
+			switch o := e.(type) {
+			case *expression.OrderBy, *expression.SearchRank:
 				orderBy = append(orderBy, o)
 			}
+			if l, ok := e.(*expression.Limit); ok {
+				limit = l.Limit()
+			}
+			if o, ok := e.(*expression.Offset); ok {
+				offset = o.Offset()
+			}
+		}
+		res, err = sandbox.ImitatorSql(items, where, groupBy, orderBy)
+		if err != nil {
+			return nil, err
 		}
-		return sandbox.ImitatorSql(items, where, groupBy, orderBy)
+		res = res[min(offset, len(res)):]
+		if limit >= 0 {
+			res = res[:min(limit, len(res))]
+		}
+		return project(res, expressions)
 	}
 	dataset := make(map[DATAKEY]*DATASET)
 	for i, item := range items {
@@ -78,6 +108,48 @@ func NewDummySqlBoilerRepository[DATAKEY constraints.Ordered, DATASET any](items
 	return &DummyRepository[DATAKEY, DATASET]{entities: dataset, Extractor: obtainID, Filtrator: obtainItems}, nil
 }
 
+// LinkDummyRepository links the related repository to the repository under the name of relation (the field of R struct).
+// Relation(relation) loads the related entities into R, and Where("relation/column", ...) filters through the relation.
+// The related entity is matched when its foreignKey column equals the localKey column of the entity,
+// e.g. LinkDummyRepository(books, "Author", authors, "author_id", "id") or LinkDummyRepository(authors, "Books", books, "id", "author_id").
+func LinkDummyRepository[DATAKEY, RELATEDKEY constraints.Ordered, DATASET, RELATED any](
+	repo *DummyRepository[DATAKEY, DATASET],
+	relation string,
+	related *DummyRepository[RELATEDKEY, RELATED],
+	localKey, foreignKey string,
+) {
+	repo.m.Lock()
+	defer repo.m.Unlock()
+
+	if repo.relations == nil {
+		repo.relations = make(map[string]*dummyRelation)
+	}
+
+	repo.relations[relation] = &dummyRelation{
+		localKey:   localKey,
+		foreignKey: foreignKey,
+		dataset: func(expressions []Expression) ([]interface{}, error) {
+			related.m.RLock()
+			defer related.m.RUnlock()
+			items := make([]interface{}, 0, len(related.entities))
+			if len(expressions) == 0 {
+				for _, item := range related.entities {
+					items = append(items, item)
+				}
+				return items, nil
+			}
+			entities, err := related.Filtrator(related.entities, expressions)
+			if err != nil {
+				return nil, err
+			}
+			for _, item := range entities {
+				items = append(items, item)
+			}
+			return items, nil
+		},
+	}
+}
+
 // DummyRepository is a implementation of Repository with dummy data for testing
 type DummyRepository[DATAKEY constraints.Ordered, DATASET any] struct {
 	m                      sync.RWMutex
@@ -92,25 +164,42 @@ type DummyRepository[DATAKEY constraints.Ordered, DATASET any] struct {
 	OnAfterCreateOrUpdate  func(*DATASET) error
 	OnAfterUpdate          func(*DATASET) error
 	OnAfterDelete          func(*DATASET) error
+	relations              map[string]*dummyRelation
+}
+
+// dummyRelation describes the link to the related repository
+type dummyRelation struct {
+	localKey   string
+	foreignKey string
+	dataset    func([]Expression) ([]interface{}, error)
 }
 
 // Count returns count of entities from Repository
 func (r *DummyRepository[DATAKEY, DATASET]) Count(expressions ...Expression) (int64, error) {
+	related, err := r.relate(expressions)
+	if err != nil {
+		return 0, err
+	}
+
 	r.m.RLock()
 	defer r.m.RUnlock()
 
-	if r.entities == nil {
+	entities := r.entities
+	if related != nil {
+		entities = related
+	}
+	if entities == nil {
 		return 0, nil
 	}
 
 	var where []Expression
-	for _, e := range expressions {
-		if _, ok := e.(*expression.Where); ok {
+	for _, e := range flatten(expressions) {
+		if _, ok := e.(expression.Condition); ok {
 			where = append(where, e)
 		}
 	}
 
-	items, err := r.Filtrator(r.entities, where)
+	items, err := r.Filtrator(entities, where)
 	if err != nil {
 		return 0, err
 	}
@@ -118,16 +207,63 @@ func (r *DummyRepository[DATAKEY, DATASET]) Count(expressions ...Expression) (in
 	return int64(len(items)), nil
 }
 
+// ObtainPage returns the page of entities and the total count of filtered ones under the same lock of Repository
+func (r *DummyRepository[DATAKEY, DATASET]) ObtainPage(filtering []Expression, expressions []Expression) ([]*DATASET, int64, error) {
+	related, err := r.relate(expressions)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	entities := r.entities
+	if related != nil {
+		entities = related
+	}
+	if entities == nil {
+		return nil, 0, nil
+	}
+
+	var where []Expression
+	for _, e := range filtering {
+		if _, ok := e.(expression.Condition); ok {
+			where = append(where, e)
+		}
+	}
+
+	filtered, err := r.Filtrator(entities, where)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	items, err := r.Filtrator(entities, expressions)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return items, int64(len(filtered)), nil
+}
+
 // ObtainAll returns all entities from Repository
 func (r *DummyRepository[DATAKEY, DATASET]) ObtainAll(expressions ...Expression) ([]*DATASET, error) {
+	related, err := r.relate(expressions)
+	if err != nil {
+		return nil, err
+	}
+
 	r.m.RLock()
 	defer r.m.RUnlock()
 
-	if r.entities == nil {
+	entities := r.entities
+	if related != nil {
+		entities = related
+	}
+	if entities == nil {
 		return nil, nil
 	}
 
-	items, err := r.Filtrator(r.entities, expressions)
+	items, err := r.Filtrator(entities, expressions)
 	if err != nil {
 		return nil, err
 	}
@@ -136,20 +272,34 @@ func (r *DummyRepository[DATAKEY, DATASET]) ObtainAll(expressions ...Expression)
 }
 
 // ObtainOne returns one item from Repository by key
-func (r *DummyRepository[DATAKEY, DATASET]) ObtainOne(key DATAKEY, _ ...Expression) (*DATASET, error) {
+func (r *DummyRepository[DATAKEY, DATASET]) ObtainOne(key DATAKEY, expressions ...Expression) (*DATASET, error) {
+	related, err := r.relate(expressions)
+	if err != nil {
+		return nil, err
+	}
+
 	r.m.RLock()
 	defer r.m.RUnlock()
 
-	if r.entities == nil {
+	entities := r.entities
+	if related != nil {
+		entities = related
+	}
+	if entities == nil {
 		return nil, fmt.Errorf("entities is empty")
 	}
 
-	item, ok := r.entities[key]
+	item, ok := entities[key]
 	if !ok {
 		return nil, fmt.Errorf("not found")
 	}
 
-	return item, nil
+	items, err := project([]*DATASET{item}, expressions)
+	if err != nil {
+		return nil, err
+	}
+
+	return items[0], nil
 }
 
 // Create creates new entity in Repository
@@ -310,6 +460,106 @@ func (r *DummyRepository[DATAKEY, DATASET]) Delete(model *DATASET, moreModels ..
 	return nil
 }
 
+// relate returns the copies of entities with the entities of the linked repositories loaded into their R,
+// when the relations are required by the expressions, otherwise nil. The stored entities are never changed.
+func (r *DummyRepository[DATAKEY, DATASET]) relate(expressions []Expression) (map[DATAKEY]*DATASET, error) {
+	r.m.RLock()
+	required := make(map[string]*dummyRelation)
+	mods := make(map[string][]Expression)
+	if len(r.relations) > 0 {
+		var tables []string
+		for _, e := range flatten(expressions) {
+			switch v := e.(type) {
+			case *expression.Relation:
+				for _, t := range v.Relation() {
+					tables = append(tables, strings.SplitN(t, ".", 2)[0])
+				}
+				// the mods restrict the loaded entities of the first level of relations only
+				if t := v.Relation(); len(t) == 1 && !strings.Contains(t[0], ".") {
+					for _, m := range v.Mods() {
+						mods[t[0]] = append(mods[t[0]], m)
+					}
+				}
+			case expression.Condition:
+				for _, w := range expression.Leaves(v) {
+					tables = append(tables, w.Table)
+				}
+				for _, l := range expression.Lambdas(v) {
+					if l.Relation {
+						tables = append(tables, l.Column)
+					}
+				}
+			case *expression.OrderBy:
+				tables = append(tables, v.Table)
+			case *expression.GroupBy:
+				tables = append(tables, v.Table)
+			}
+		}
+		for name, relation := range r.relations {
+			for _, t := range tables {
+				if t != "" && sandbox.SameTable(name, t) {
+					required[name] = relation
+					break
+				}
+			}
+		}
+	}
+	var entities map[DATAKEY]*DATASET
+	if len(required) > 0 {
+		entities = make(map[DATAKEY]*DATASET, len(r.entities))
+		for key, entity := range r.entities {
+			entities[key] = detach(entity)
+		}
+	}
+	r.m.RUnlock()
+
+	if len(required) == 0 {
+		return nil, nil
+	}
+
+	// the related entities are obtained before locking of the repository, since the repository could be linked with itself
+	datasets := make(map[string][]interface{}, len(required))
+	for name, relation := range required {
+		var restrictions []Expression
+		for t, m := range mods {
+			if sandbox.SameTable(name, t) {
+				restrictions = append(restrictions, m...)
+			}
+		}
+		dataset, err := relation.dataset(restrictions)
+		if err != nil {
+			return nil, fmt.Errorf("could not obtain %s relation: %w", name, err)
+		}
+		datasets[name] = dataset
+	}
+
+	for _, entity := range entities {
+		for name, relation := range required {
+			err := sandbox.Relate(entity, name, datasets[name], relation.localKey, relation.foreignKey)
+			if err != nil {
+				return nil, fmt.Errorf("could not load %s relation: %w", name, err)
+			}
+		}
+	}
+
+	return entities, nil
+}
+
+// detach returns the shallow copy of entity with its own R, so the relations are loaded into the copy only
+func detach[DATASET any](entity *DATASET) *DATASET {
+	c := *entity
+	v := reflect.ValueOf(&c).Elem()
+	if v.Kind() != reflect.Struct {
+		return &c
+	}
+	if rel := v.FieldByName("R"); rel.IsValid() && rel.Kind() == reflect.Pointer && !rel.IsNil() && rel.CanSet() {
+		cloned := reflect.New(rel.Type().Elem())
+		cloned.Elem().Set(rel.Elem())
+		rel.Set(cloned)
+	}
+	return &c
+}
+
 // Erase deletes existing item in Repository
 func (r *DummyRepository[DATAKEY, DATASET]) Erase(key DATAKEY) error {
 	item, err := r.ObtainOne(key)
@@ -321,11 +571,12 @@ func (r *DummyRepository[DATAKEY, DATASET]) Erase(key DATAKEY) error {
 
 // UpdateAll updates all entities in Repository
 func (r *DummyRepository[DATAKEY, DATASET]) UpdateAll(m map[string]interface{}, expressions ...Expression) error {
-	items, err := r.ObtainAll(expressions...)
+	items, err := r.stored(expressions)
 	if err != nil {
 		return err
 	}
 	for _, item := range items {
+		item = detach(item)
 		err = sandbox.Merge(item, m)
 		if err != nil {
 			return fmt.Errorf("merge error for %v: %w", item, err)
@@ -340,7 +591,7 @@ func (r *DummyRepository[DATAKEY, DATASET]) UpdateAll(m map[string]interface{},
 
 // DeleteAll deletes all entities in Repository
 func (r *DummyRepository[DATAKEY, DATASET]) DeleteAll(expressions ...Expression) error {
-	items, err := r.ObtainAll(expressions...)
+	items, err := r.stored(expressions)
 	if err != nil {
 		return err
 	}
@@ -352,3 +603,69 @@ func (r *DummyRepository[DATAKEY, DATASET]) DeleteAll(expressions ...Expression)
 	}
 	return nil
 }
+
+// stored returns the stored entities matched by the expressions, the columns of Select are ignored,
+// since the projected copies lose the key and the copies with loaded relations must not be stored.
+func (r *DummyRepository[DATAKEY, DATASET]) stored(expressions []Expression) ([]*DATASET, error) {
+	filtering := slices.DeleteFunc(flatten(expressions), func(e Expression) bool {
+		_, ok := e.(*expression.Select)
+		return ok
+	})
+	items, err := r.ObtainAll(filtering...)
+	if err != nil {
+		return nil, err
+	}
+
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	res := make([]*DATASET, 0, len(items))
+	for _, item := range items {
+		id, err := r.Extractor(item)
+		if err != nil {
+			return nil, err
+		}
+		if entity, exists := r.entities[id]; exists {
+			res = append(res, entity)
+		}
+	}
+	return res, nil
+}
+
+// project returns copies of the items, which keep the columns selected by the expressions only.
+// The columns of other tables are ignored, since they are not bound into the items.
+func project[DATASET any](items []*DATASET, expressions []Expression) ([]*DATASET, error) {
+	var columns []string
+	table := expression.TableName(reflect.TypeOf((*DATASET)(nil)).Elem().Name())
+	for _, e := range flatten(expressions) {
+		s, ok := e.(*expression.Select)
+		if !ok {
+			continue
+		}
+		for _, c := range s.Select() {
+			if parts := strings.SplitN(c, ".", 2); len(parts) == 2 {
+				if !sandbox.SameTable(parts[0], table) {
+					continue
+				}
+				c = parts[1]
+			}
+			if c == "*" {
+				return items, nil
+			}
+			columns = append(columns, c)
+		}
+	}
+	if len(columns) == 0 {
+		return items, nil
+	}
+
+	res := make([]*DATASET, len(items))
+	for i, item := range items {
+		p, err := sandbox.Project(item, columns)
+		if err != nil {
+			return nil, err
+		}
+		res[i] = p.(*DATASET)
+	}
+	return res, nil
+}
@@ -3,6 +3,8 @@ package sqlinjector
 import (
 	"github.com/prorochestvo/sqlinjector/internal/expression"
 	"github.com/stretchr/testify/require"
+	"net/url"
+	"sync"
 	"testing"
 )
 
@@ -79,6 +81,98 @@ func TestDummyRepository_ObtainAll(t *testing.T) {
 		require.Contains(t, "45", val[0].ID)
 		require.Contains(t, "23", val[1].ID)
 	})
+	t.Run("Functions", func(t *testing.T) {
+		q := url.Values{defaultQueryNameWhere: {"startswith(tolower(name),'subjectname 3') or substring(name,12) eq '7' or indexof(name,'4') gt length('SubjectName')"}}
+		e, err := ODataExpression(&q)
+		require.NoError(t, err)
+
+		var val []*internalSubject
+		val, err = repo.ObtainAll(e, expression.NewOrderBy("id", expression.Ascending))
+		require.NoError(t, err)
+		require.Len(t, val, 3)
+		require.Equal(t, "1", val[0].ID)
+		require.Equal(t, "4", val[1].ID)
+		require.Equal(t, "5", val[2].ID)
+	})
+	t.Run("Typed literals", func(t *testing.T) {
+		q := url.Values{defaultQueryNameWhere: {"enabled eq false and name ne null and length(name) gt 1.5e1 - 5"}}
+		_, err := ODataExpression(&q)
+		require.Error(t, err)
+
+		q = url.Values{defaultQueryNameWhere: {"enabled eq false and name ne null and length(name) ge 13.0"}}
+		e, err := ODataExpression(&q)
+		require.NoError(t, err)
+
+		var val []*internalSubject
+		val, err = repo.ObtainAll(e, expression.NewOrderBy("id", expression.Ascending))
+		require.NoError(t, err)
+		require.Len(t, val, 3)
+		require.Equal(t, "4", val[0].ID)
+	})
+	t.Run("In operator", func(t *testing.T) {
+		q := url.Values{defaultQueryNameWhere: {"id in ('1','2','3','4') and not (name in ('SubjectName 6','SubjectName 4'))"}}
+		e, err := ODataExpression(&q)
+		require.NoError(t, err)
+
+		var val []*internalSubject
+		val, err = repo.ObtainAll(e, expression.NewOrderBy("id", expression.Ascending))
+		require.NoError(t, err)
+		require.Len(t, val, 2)
+		require.Equal(t, "1", val[0].ID)
+		require.Equal(t, "3", val[1].ID)
+
+		q = url.Values{defaultQueryNameWhere: {"id in ('1','2','3')"}}
+		_, err = ODataExpressionWithOptions(&q, ODataOptions{MaxInLength: 2})
+		require.Error(t, err)
+	})
+	t.Run("Combinators", func(t *testing.T) {
+		var val []*internalSubject
+		val, err = repo.ObtainAll(
+			Or(And(Where("enabled", Equal, false), Not(Where("id", In, "4", "5"))), Or(Where("id", Equal, "1"), Where("name", Equal, "SubjectName 5"))),
+			OrderBy("id", Ascending),
+		)
+		require.NoError(t, err)
+		require.Len(t, val, 3)
+		require.Equal(t, "1", val[0].ID)
+		require.Equal(t, "3", val[1].ID)
+		require.Equal(t, "6", val[2].ID)
+	})
+	t.Run("Search", func(t *testing.T) {
+		var val []*internalSubject
+		val, err = repo.ObtainAll(Search("internal_subjects", []string{"name"}, "subjectname 3"))
+		require.NoError(t, err)
+		require.Len(t, val, 1)
+		require.Equal(t, "5", val[0].ID)
+
+		val, err = repo.ObtainAll(
+			Or(Search("internal_subjects", []string{"name"}, "7"), Search("internal_subjects", []string{"name"}, "1")),
+			SearchRank("internal_subjects", []string{"name"}, "1"),
+			OrderBy("id", Ascending),
+		)
+		require.NoError(t, err)
+		require.Len(t, val, 2)
+		require.Equal(t, "7", val[0].ID)
+		require.Equal(t, "1", val[1].ID)
+	})
+	t.Run("Projected", func(t *testing.T) {
+		q := url.Values{defaultQueryNameSelect: {"id, internalSubject.name, author.name"}}
+		e, err := ODataExpression(&q)
+		require.NoError(t, err)
+
+		var val []*internalSubject
+		val, err = repo.ObtainAll(e, Where("enabled", Equal, true), OrderBy("id", Descending))
+		require.NoError(t, err)
+		require.Equal(t, []*internalSubject{{ID: "7", Name: "SubjectName 1"}, {ID: "3", Name: "SubjectName 5"}, {ID: "2", Name: "SubjectName 6"}, {ID: "1", Name: "SubjectName 7"}}, val)
+		require.True(t, repo.entities["7"].IsEnabled)
+
+		var item *internalSubject
+		item, err = repo.ObtainOne("4", Select("enabled"))
+		require.NoError(t, err)
+		require.Equal(t, &internalSubject{}, item)
+
+		_, err = repo.ObtainAll(Select("password"))
+		require.Error(t, err)
+	})
 }
 
 func TestDummyRepository_ObtainOne(t *testing.T) {
@@ -336,6 +430,11 @@ func TestDummyRepository_UpdateAll(t *testing.T) {
 		}
 		require.Equal(t, true, entity.IsEnabled)
 	}
+
+	err = repo.UpdateAll(map[string]interface{}{"name": "SELECTED"}, expression.NewWhere("id", expression.Equal, "1"), Select("name"))
+	require.NoError(t, err)
+	require.Len(t, repo.entities, 7)
+	require.Equal(t, &internalSubject{ID: "1", Name: "SELECTED", IsEnabled: true}, repo.entities["1"])
 }
 
 func TestDummyRepository_DeleteAll(t *testing.T) {
@@ -365,3 +464,167 @@ type internalSubject struct {
 	Name      string `boil:"name"`
 	IsEnabled bool   `boil:"enabled"`
 }
+
+func TestLinkDummyRepository(t *testing.T) {
+	authors, err := NewDummySqlBoilerRepository[int64, internalAuthor](
+		&internalAuthor{ID: 1, Name: "Leo Tolstoy"},
+		&internalAuthor{ID: 2, Name: "Fyodor Dostoevsky"},
+		&internalAuthor{ID: 3, Name: "Anton Chekhov"},
+	)
+	require.NoError(t, err)
+	books, err := NewDummySqlBoilerRepository[int64, internalBook](
+		&internalBook{ID: 1, Title: "War and Peace", AuthorID: 1},
+		&internalBook{ID: 2, Title: "Anna Karenina", AuthorID: 1},
+		&internalBook{ID: 3, Title: "Crime and Punishment", AuthorID: 2},
+		&internalBook{ID: 4, Title: "Demons", AuthorID: 2},
+		&internalBook{ID: 5, Title: "The Idiot", AuthorID: 2},
+	)
+	require.NoError(t, err)
+
+	LinkDummyRepository(books, "Author", authors, "author_id", "id")
+	LinkDummyRepository(authors, "Books", books, "id", "author_id")
+
+	t.Run("Relation to-one", func(t *testing.T) {
+		items, err := books.ObtainAll(Relation("Author"), OrderBy("id", Ascending))
+		require.NoError(t, err)
+		require.Len(t, items, 5)
+		for _, item := range items {
+			require.NotNil(t, item.R)
+			require.NotNil(t, item.R.Author)
+			require.Equal(t, item.AuthorID, item.R.Author.ID)
+		}
+	})
+	t.Run("Relation to-many", func(t *testing.T) {
+		item, err := authors.ObtainOne(2, Relation("Books"))
+		require.NoError(t, err)
+		require.NotNil(t, item.R)
+		require.Len(t, item.R.Books, 3)
+
+		item, err = authors.ObtainOne(3, Relation("Books"))
+		require.NoError(t, err)
+		require.NotNil(t, item.R)
+		require.Len(t, item.R.Books, 0)
+	})
+	t.Run("Where through to-one relation", func(t *testing.T) {
+		items, err := books.ObtainAll(Where("author/name", Equal, "Leo Tolstoy"), OrderBy("id", Descending))
+		require.NoError(t, err)
+		require.Len(t, items, 2)
+		require.Equal(t, int64(2), items[0].ID)
+		require.Equal(t, int64(1), items[1].ID)
+
+		count, err := books.Count(Where("author/name", StartsWith, "Fyodor"))
+		require.NoError(t, err)
+		require.Equal(t, int64(3), count)
+	})
+	t.Run("Expand with nested options", func(t *testing.T) {
+		q := url.Values{defaultQueryNameExpand: {"Books($filter=startswith(title,'Crime') or title eq 'Demons';$orderby=id desc)"}}
+
+		_, err := ODataExpression(&q)
+		require.Error(t, err)
+
+		e, err := ODataExpressionWithOptions(&q, ODataOptions{Expandable: []string{"Books"}})
+		require.NoError(t, err)
+
+		item, err := authors.ObtainOne(2, e)
+		require.NoError(t, err)
+		require.NotNil(t, item.R)
+		require.Len(t, item.R.Books, 2)
+		require.Equal(t, int64(4), item.R.Books[0].ID)
+		require.Equal(t, int64(3), item.R.Books[1].ID)
+	})
+	t.Run("Where through to-many relation", func(t *testing.T) {
+		items, err := authors.ObtainAll(Where("books/title", Equal, "Demons"))
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		require.Equal(t, int64(2), items[0].ID)
+	})
+	t.Run("Lambda operators of relation", func(t *testing.T) {
+		q := url.Values{defaultQueryNameWhere: {"books/any(b: b/title eq 'Demons' or b/title eq 'War and Peace')"}}
+		e, err := ODataExpressionWithOptions(&q, ODataOptions{Table: "authors"})
+		require.NoError(t, err)
+
+		items, err := authors.ObtainAll(e, OrderBy("id", Ascending))
+		require.NoError(t, err)
+		require.Len(t, items, 2)
+		require.Equal(t, int64(1), items[0].ID)
+		require.Equal(t, int64(2), items[1].ID)
+
+		q = url.Values{defaultQueryNameWhere: {"books/all(b: contains(b/title,'a'))"}}
+		e, err = ODataExpressionWithOptions(&q, ODataOptions{Table: "authors"})
+		require.NoError(t, err)
+
+		items, err = authors.ObtainAll(e, OrderBy("id", Ascending))
+		require.NoError(t, err)
+		require.Len(t, items, 2)
+		require.Equal(t, int64(1), items[0].ID)
+		require.Equal(t, int64(3), items[1].ID)
+
+		q = url.Values{defaultQueryNameWhere: {"books/any(b: b/title eq 'Demons')"}}
+		_, err = ODataExpression(&q)
+		require.Error(t, err)
+	})
+	t.Run("Stored entities are not changed", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := authors.ObtainAll(Relation("Books"))
+				require.NoError(t, err)
+				_, err = books.ObtainAll(Where("author/name", Equal, "Leo Tolstoy"))
+				require.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+
+		for _, item := range authors.entities {
+			require.Nil(t, item.R)
+		}
+		for _, item := range books.entities {
+			require.Nil(t, item.R)
+		}
+
+		item, err := authors.ObtainOne(1)
+		require.NoError(t, err)
+		require.Nil(t, item.R)
+	})
+	t.Run("UpdateAll and DeleteAll through relation", func(t *testing.T) {
+		err := books.UpdateAll(map[string]interface{}{"title": "Resurrection"}, Where("author/name", Equal, "Leo Tolstoy"), Select("title"))
+		require.NoError(t, err)
+		for _, item := range books.entities {
+			require.Nil(t, item.R)
+			require.NotZero(t, item.ID)
+		}
+
+		count, err := books.Count(Where("title", Equal, "Resurrection"))
+		require.NoError(t, err)
+		require.Equal(t, int64(2), count)
+
+		err = books.DeleteAll(Where("author/name", Equal, "Leo Tolstoy"), Relation("Author"))
+		require.NoError(t, err)
+		count, err = books.Count()
+		require.NoError(t, err)
+		require.Equal(t, int64(3), count)
+	})
+}
+
+type internalAuthor struct {
+	ID   int64            `boil:"id"`
+	Name string           `boil:"name"`
+	R    *internalAuthorR `boil:"-" json:"-" toml:"-" yaml:"-"`
+}
+
+type internalAuthorR struct {
+	Books []*internalBook `boil:"Books"`
+}
+
+type internalBook struct {
+	ID       int64          `boil:"id"`
+	Title    string         `boil:"title"`
+	AuthorID int64          `boil:"author_id"`
+	R        *internalBookR `boil:"-" json:"-" toml:"-" yaml:"-"`
+}
+
+type internalBookR struct {
+	Author *internalAuthor `boil:"Author"`
+}
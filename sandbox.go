@@ -1,9 +1,22 @@
 package sqlinjector
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"github.com/prorochestvo/sqlinjector/internal"
+	"github.com/prorochestvo/sqlinjector/internal/expression"
 	"github.com/prorochestvo/sqlinjector/internal/sandbox"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+	"github.com/volatiletech/sqlboiler/v4/drivers"
+	"github.com/volatiletech/sqlboiler/v4/queries"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+	"github.com/volatiletech/strmangle"
+	"golang.org/x/exp/constraints"
 	"math/rand"
+	"reflect"
+	"strings"
 )
 
 // NewSandboxOfPostgreSQL create new connection PostgreSQL via docker container.
@@ -91,3 +104,379 @@ func NewSandboxOfSQLite3(migrations ...Migration) (Vault, error) {
 }
 
 var poll = sandbox.NewPool()
+
+// NewSandboxSqlBoilerRepository creates new Repository backed by SQLite in memory for testing.
+// The table is created from the DATASET struct via NewStructMigration and every Expression is executed as the real query,
+// so the results follow SQL semantics (LIKE, NULL ordering, collation) unlike the dummy repository.
+// The relations ($expand) are not loaded, ObtainAll and ObtainOne return an error for them.
+// Closing the repository is necessary to free the database.
+func NewSandboxSqlBoilerRepository[DATAKEY constraints.Ordered, DATASET any](items ...*DATASET) (*SandboxRepository[DATAKEY, DATASET], error) {
+	model := new(DATASET)
+
+	table := expression.TableName(reflect.TypeOf(model).Elem().Name())
+
+	columns, key, err := recognizeSandboxColumns(model)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := NewStructMigration(model, table, internal.DialectSQLite3)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := NewSandboxOfSQLite3(m)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &SandboxRepository[DATAKEY, DATASET]{
+		vault:   db,
+		table:   table,
+		key:     key,
+		columns: columns,
+	}
+
+	if len(items) > 0 {
+		if err = r.Create(items[0], items[1:]...); err != nil {
+			return nil, errors.Join(err, db.Close())
+		}
+	}
+
+	return r, nil
+}
+
+// SandboxRepository is a implementation of Repository backed by SQLite in memory for testing
+type SandboxRepository[DATAKEY constraints.Ordered, DATASET any] struct {
+	vault   Vault
+	table   string
+	key     string
+	columns []string
+}
+
+// Vault returns the database of Repository, e.g. for the seeding of related tables
+func (r *SandboxRepository[DATAKEY, DATASET]) Vault() Vault {
+	return r.vault
+}
+
+// Table returns the table name of Repository
+func (r *SandboxRepository[DATAKEY, DATASET]) Table() string {
+	return r.table
+}
+
+// Close closes the database of Repository
+func (r *SandboxRepository[DATAKEY, DATASET]) Close() error {
+	return r.vault.Close()
+}
+
+// Count returns count of entities from Repository
+func (r *SandboxRepository[DATAKEY, DATASET]) Count(expressions ...Expression) (int64, error) {
+	return r.count(r.vault, expressions)
+}
+
+// ObtainAll returns all entities from Repository
+func (r *SandboxRepository[DATAKEY, DATASET]) ObtainAll(expressions ...Expression) ([]*DATASET, error) {
+	return r.obtainAll(r.vault, expressions)
+}
+
+// ObtainPage returns the page of entities and the total count of filtered ones in the one transaction
+func (r *SandboxRepository[DATAKEY, DATASET]) ObtainPage(filtering []Expression, expressions []Expression) ([]*DATASET, int64, error) {
+	res, err := TransactionRollback(
+		r.vault,
+		func(executor boil.ContextExecutor) (interface{}, error) {
+			return r.count(executor, filtering)
+		},
+		func(executor boil.ContextExecutor) (interface{}, error) {
+			return r.obtainAll(executor, expressions)
+		},
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	results, ok := res.([]interface{})
+	if !ok || len(results) != 2 {
+		return nil, 0, fmt.Errorf("unexpected result %T of transaction", res)
+	}
+	count, ok := results[0].(int64)
+	if !ok {
+		return nil, 0, fmt.Errorf("could not convert %T to int64", results[0])
+	}
+	items, ok := results[1].([]*DATASET)
+	if !ok {
+		return nil, 0, fmt.Errorf("could not convert %T to %T", results[1], items)
+	}
+
+	return items, count, nil
+}
+
+// ObtainOne returns one item from Repository by key
+func (r *SandboxRepository[DATAKEY, DATASET]) ObtainOne(key DATAKEY, expressions ...Expression) (*DATASET, error) {
+	q, err := r.query(expressions, false)
+	if err != nil {
+		return nil, err
+	}
+	qm.Apply(q, qm.Where(strmangle.IdentQuote('"', '"', r.key)+" = ?", key))
+
+	item := new(DATASET)
+	if err = q.Bind(context.Background(), r.vault, item); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("not found")
+		}
+		return nil, err
+	}
+
+	return item, nil
+}
+
+// Create creates new entity in Repository
+func (r *SandboxRepository[DATAKEY, DATASET]) Create(model *DATASET, moreModels ...*DATASET) error {
+	return r.insert("INSERT", model, moreModels...)
+}
+
+// CreateOrUpdate creates new entity in Repository or updates existing item
+func (r *SandboxRepository[DATAKEY, DATASET]) CreateOrUpdate(model *DATASET, moreModels ...*DATASET) error {
+	return r.insert("INSERT OR REPLACE", model, moreModels...)
+}
+
+// Update updates existing entity in Repository
+func (r *SandboxRepository[DATAKEY, DATASET]) Update(model *DATASET, moreModels ...*DATASET) error {
+	set := make([]string, 0, len(r.columns))
+	for _, c := range r.columns {
+		set = append(set, strmangle.IdentQuote('"', '"', c)+" = ?")
+	}
+
+	statement := fmt.Sprintf(
+		"UPDATE %s SET %s WHERE %s = ?;",
+		strmangle.IdentQuote('"', '"', r.table),
+		strings.Join(set, ", "),
+		strmangle.IdentQuote('"', '"', r.key),
+	)
+
+	for i := -1; i < len(moreModels); i++ {
+		if i >= 0 {
+			model = moreModels[i]
+		}
+		values, key, err := r.values(model)
+		if err != nil {
+			return err
+		}
+		res, err := r.vault.Exec(statement, append(values, key)...)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil || n == 0 {
+			if err == nil {
+				err = fmt.Errorf("not found")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Delete deletes existing item in Repository
+func (r *SandboxRepository[DATAKEY, DATASET]) Delete(model *DATASET, moreModels ...*DATASET) error {
+	for i := -1; i < len(moreModels); i++ {
+		if i >= 0 {
+			model = moreModels[i]
+		}
+		_, key, err := r.values(model)
+		if err != nil {
+			return err
+		}
+		if err = r.erase(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Erase deletes existing item in Repository
+func (r *SandboxRepository[DATAKEY, DATASET]) Erase(key DATAKEY) error {
+	return r.erase(key)
+}
+
+// UpdateAll updates all entities in Repository
+func (r *SandboxRepository[DATAKEY, DATASET]) UpdateAll(m map[string]interface{}, expressions ...Expression) error {
+	q, err := r.query(expressions, true)
+	if err != nil {
+		return err
+	}
+	queries.SetUpdate(q, m)
+	_, err = q.Exec(r.vault)
+	return err
+}
+
+// DeleteAll deletes all entities in Repository
+func (r *SandboxRepository[DATAKEY, DATASET]) DeleteAll(expressions ...Expression) error {
+	q, err := r.query(expressions, true)
+	if err != nil {
+		return err
+	}
+	queries.SetDelete(q)
+	_, err = q.Exec(r.vault)
+	return err
+}
+
+// query builds the query of sqlboiler from the expressions,
+// filtering keeps where conditions only (e.g. for count, update and delete statements).
+// The relations are rejected unless filtering, since eager loading requires generated models of sqlboiler.
+func (r *SandboxRepository[DATAKEY, DATASET]) query(expressions []Expression, filtering bool) (*queries.Query, error) {
+	q := &queries.Query{}
+	queries.SetDialect(q, &drivers.Dialect{LQ: '"', RQ: '"', UseDefaultKeyword: true})
+	queries.SetFrom(q, strmangle.IdentQuote('"', '"', r.table))
+
+	for _, e := range flatten(expressions) {
+		switch v := e.(type) {
+		case *expression.Relation:
+			if !filtering {
+				return nil, fmt.Errorf("relation %s is not supported by the sandbox repository", strings.Join(v.Relation(), "."))
+			}
+			continue
+		case *expression.Limit, *expression.Offset, *expression.OrderBy, *expression.SearchRank, *expression.GroupBy, *expression.Select, *expression.Aggregate, *expression.Having:
+			if filtering {
+				continue
+			}
+		}
+		if c, ok := e.(expression.Condition); ok && filtering && expression.Grouped(c) {
+			continue
+		}
+		if de, ok := e.(expression.DialectExpression); ok {
+			qm.Apply(q, de.QueryModOf(internal.DialectSQLite3)...)
+			continue
+		}
+		qm.Apply(q, e.QueryMod()...)
+	}
+
+	return q, nil
+}
+
+// count returns count of entities filtered by the expressions
+func (r *SandboxRepository[DATAKEY, DATASET]) count(executor boil.ContextExecutor, expressions []Expression) (int64, error) {
+	q, err := r.query(expressions, true)
+	if err != nil {
+		return 0, err
+	}
+	queries.SetCount(q)
+
+	var count int64
+	if err = q.QueryRow(executor).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// obtainAll returns entities obtained by the expressions
+func (r *SandboxRepository[DATAKEY, DATASET]) obtainAll(executor boil.ContextExecutor, expressions []Expression) ([]*DATASET, error) {
+	q, err := r.query(expressions, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []*DATASET
+	if err = q.Bind(context.Background(), executor, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// insert inserts entities by the given statement
+func (r *SandboxRepository[DATAKEY, DATASET]) insert(statement string, model *DATASET, moreModels ...*DATASET) error {
+	statement = fmt.Sprintf(
+		"%s INTO %s (%s) VALUES (%s);",
+		statement,
+		strmangle.IdentQuote('"', '"', r.table),
+		strings.Join(strmangle.IdentQuoteSlice('"', '"', r.columns), ", "),
+		strmangle.Placeholders(false, len(r.columns), 1, 1),
+	)
+
+	for i := -1; i < len(moreModels); i++ {
+		if i >= 0 {
+			model = moreModels[i]
+		}
+		values, _, err := r.values(model)
+		if err != nil {
+			return err
+		}
+		if _, err = r.vault.Exec(statement, values...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// erase deletes entity by key
+func (r *SandboxRepository[DATAKEY, DATASET]) erase(key interface{}) error {
+	statement := fmt.Sprintf(
+		"DELETE FROM %s WHERE %s = ?;",
+		strmangle.IdentQuote('"', '"', r.table),
+		strmangle.IdentQuote('"', '"', r.key),
+	)
+	res, err := r.vault.Exec(statement, key)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		if err == nil {
+			err = fmt.Errorf("not found")
+		}
+		return err
+	}
+	return nil
+}
+
+// values returns values of the columns and the key of the entity
+func (r *SandboxRepository[DATAKEY, DATASET]) values(model *DATASET) ([]interface{}, interface{}, error) {
+	if model == nil {
+		return nil, nil, fmt.Errorf("model is nil")
+	}
+
+	v := reflect.ValueOf(model).Elem()
+	mapping := queries.MakeStructMapping(v.Type())
+
+	values := make([]interface{}, len(r.columns))
+	var key interface{}
+	for i, c := range r.columns {
+		ptr, ok := mapping[c]
+		if !ok {
+			return nil, nil, fmt.Errorf("column %s not found into %T", c, model)
+		}
+		values[i] = queries.ValuesFromMapping(v, []uint64{ptr})[0]
+		if c == r.key {
+			key = values[i]
+		}
+	}
+
+	return values, key, nil
+}
+
+// recognizeSandboxColumns returns columns and the key column of the model by boil tags
+func recognizeSandboxColumns(model interface{}) ([]string, string, error) {
+	t := reflect.TypeOf(model).Elem()
+	if t.Kind() != reflect.Struct {
+		return nil, "", fmt.Errorf("unsupported type %s of object %T", t.Kind().String(), model)
+	}
+
+	columns := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		c := strings.TrimSpace(t.Field(i).Tag.Get("boil"))
+		if c == "" || c == "-" {
+			continue
+		}
+		columns = append(columns, strings.Split(c, ",")[0])
+	}
+
+	for _, n := range []string{"id", "ID", "Id", "iD", "_id"} {
+		for _, c := range columns {
+			if c == n {
+				return columns, c, nil
+			}
+		}
+	}
+
+	return nil, "", fmt.Errorf("id field not recognized into %T", model)
+}
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"github.com/stretchr/testify/require"
 	"github.com/twinj/uuid"
+	"github.com/volatiletech/null/v8"
 	"io"
+	"net/url"
 	"testing"
 )
 
@@ -90,6 +92,62 @@ func TestNewSandboxOfPostgreSQL(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, expectedValue02, actually)
 	})
+	t.Run("NULL of negated conditions", func(t *testing.T) {
+		type point struct {
+			ID   int         `boil:"id"`
+			X    null.Int    `boil:"x"`
+			Y    null.Int    `boil:"y"`
+			Name null.String `boil:"name"`
+		}
+		m1, _ := NewMemoryMigration(
+			"CREATE TABLE points (id INTEGER PRIMARY KEY, x INTEGER, y INTEGER, name TEXT);"+
+				"INSERT INTO points (id, x, y, name) VALUES (1, NULL, 3, NULL), (2, 1, 2, 'ab'), (3, 2, 3, 'cd'), (4, NULL, NULL, 'ab');",
+			"DROP TABLE points;",
+			"0001",
+		)
+
+		db, err := NewSandboxOfSQLite3(m1)
+		require.NoError(t, err)
+		require.NotNil(t, db)
+		defer func(closer io.Closer) { require.NoError(t, closer.Close()) }(db)
+
+		repo, err := NewDummySqlBoilerRepository[int, point](
+			&point{ID: 1, Y: null.IntFrom(3)},
+			&point{ID: 2, X: null.IntFrom(1), Y: null.IntFrom(2), Name: null.StringFrom("ab")},
+			&point{ID: 3, X: null.IntFrom(2), Y: null.IntFrom(3), Name: null.StringFrom("cd")},
+			&point{ID: 4, Name: null.StringFrom("ab")},
+		)
+		require.NoError(t, err)
+
+		for _, c := range []Expression{
+			Not(Or(Where("x", Equal, 1), Where("y", Equal, 2))),
+			Not(And(Where("x", Equal, 1), Where("y", Equal, 2))),
+			Not(And(Where("x", Equal, 2), Not(Where("y", Equal, 3)))),
+			Not(Or(Where("x", GreaterThan, 1), Where("name", Contains, "c"))),
+			Not(Where("name", StartsWith, "a")),
+			Not(Not(Where("x", NotEqual, 1))),
+		} {
+			s, args, err := ToSQL(DialectSQLite3, "points", c, Select("id"), OrderBy("id", Ascending))
+			require.NoError(t, err)
+			rows, err := db.Query(s, args...)
+			require.NoError(t, err)
+			var expected []int
+			for rows.Next() {
+				var id int
+				require.NoError(t, rows.Scan(&id))
+				expected = append(expected, id)
+			}
+			require.NoError(t, rows.Close())
+
+			items, err := repo.ObtainAll(c, OrderBy("id", Ascending))
+			require.NoError(t, err)
+			var actually []int
+			for _, item := range items {
+				actually = append(actually, item.ID)
+			}
+			require.Equal(t, expected, actually, s)
+		}
+	})
 	t.Run("Failed Migration", func(t *testing.T) {
 		tableName01 := "t_01"
 		m1, _ := NewMemoryMigration("CREATE TABLE "+tableName01+" (m1_val: STR_VAL);", "DROP TABLE"+" "+tableName01+";", "m0001")
@@ -181,6 +239,62 @@ func TestNewSandboxOfMySQL(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, expectedValue02, actually)
 	})
+	t.Run("NULL of negated conditions", func(t *testing.T) {
+		type point struct {
+			ID   int         `boil:"id"`
+			X    null.Int    `boil:"x"`
+			Y    null.Int    `boil:"y"`
+			Name null.String `boil:"name"`
+		}
+		m1, _ := NewMemoryMigration(
+			"CREATE TABLE points (id INTEGER PRIMARY KEY, x INTEGER, y INTEGER, name TEXT);"+
+				"INSERT INTO points (id, x, y, name) VALUES (1, NULL, 3, NULL), (2, 1, 2, 'ab'), (3, 2, 3, 'cd'), (4, NULL, NULL, 'ab');",
+			"DROP TABLE points;",
+			"0001",
+		)
+
+		db, err := NewSandboxOfSQLite3(m1)
+		require.NoError(t, err)
+		require.NotNil(t, db)
+		defer func(closer io.Closer) { require.NoError(t, closer.Close()) }(db)
+
+		repo, err := NewDummySqlBoilerRepository[int, point](
+			&point{ID: 1, Y: null.IntFrom(3)},
+			&point{ID: 2, X: null.IntFrom(1), Y: null.IntFrom(2), Name: null.StringFrom("ab")},
+			&point{ID: 3, X: null.IntFrom(2), Y: null.IntFrom(3), Name: null.StringFrom("cd")},
+			&point{ID: 4, Name: null.StringFrom("ab")},
+		)
+		require.NoError(t, err)
+
+		for _, c := range []Expression{
+			Not(Or(Where("x", Equal, 1), Where("y", Equal, 2))),
+			Not(And(Where("x", Equal, 1), Where("y", Equal, 2))),
+			Not(And(Where("x", Equal, 2), Not(Where("y", Equal, 3)))),
+			Not(Or(Where("x", GreaterThan, 1), Where("name", Contains, "c"))),
+			Not(Where("name", StartsWith, "a")),
+			Not(Not(Where("x", NotEqual, 1))),
+		} {
+			s, args, err := ToSQL(DialectSQLite3, "points", c, Select("id"), OrderBy("id", Ascending))
+			require.NoError(t, err)
+			rows, err := db.Query(s, args...)
+			require.NoError(t, err)
+			var expected []int
+			for rows.Next() {
+				var id int
+				require.NoError(t, rows.Scan(&id))
+				expected = append(expected, id)
+			}
+			require.NoError(t, rows.Close())
+
+			items, err := repo.ObtainAll(c, OrderBy("id", Ascending))
+			require.NoError(t, err)
+			var actually []int
+			for _, item := range items {
+				actually = append(actually, item.ID)
+			}
+			require.Equal(t, expected, actually, s)
+		}
+	})
 	t.Run("Failed Migration", func(t *testing.T) {
 		tableName01 := "t_01"
 		m1, _ := NewMemoryMigration("CREATE TABLE "+tableName01+" (m1_val: STR_VAL);", "DROP TABLE"+" "+tableName01+";", "m0001")
@@ -272,6 +386,167 @@ func TestNewSandboxOfSQLite3(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, expectedValue02, actually)
 	})
+	t.Run("Search", func(t *testing.T) {
+		m1, _ := NewMemoryMigration("CREATE TABLE articles (id INTEGER PRIMARY KEY, title TEXT, body TEXT);", "DROP TABLE articles;", "0001")
+		m2, err := NewSearchMigration("articles", []string{"title", "body"}, DialectSQLite3)
+		require.NoError(t, err)
+		require.Equal(t, "articles_title_body_search", m2[0].ID())
+		m3, _ := NewMemoryMigration(
+			"INSERT INTO articles (id, title, body) VALUES (1, 'Go generics', 'Generics of Go'), (2, 'Rust', 'Traits and generics'), (3, 'Go channels', NULL);"+
+				"UPDATE articles SET body = 'Generics, generics and generics' WHERE id = 2;",
+			"DELETE FROM articles;",
+			"9999",
+		)
+
+		db, err := NewSandboxOfSQLite3(m1, m2, m3)
+		require.NoError(t, err)
+		require.NotNil(t, db)
+		defer func(closer io.Closer) { require.NoError(t, closer.Close()) }(db)
+
+		ids := func(e ...Expression) []int {
+			s, args, err := ToSQL(DialectSQLite3, "articles", append(e, Select("id"))...)
+			require.NoError(t, err)
+			rows, err := db.Query(s, args...)
+			require.NoError(t, err)
+			defer func(closer io.Closer) { require.NoError(t, closer.Close()) }(rows)
+			var res []int
+			for rows.Next() {
+				var id int
+				require.NoError(t, rows.Scan(&id))
+				res = append(res, id)
+			}
+			return res
+		}
+
+		require.Equal(t, []int{1, 2}, ids(Search("articles", []string{"title", "body"}, "GENERICS"), OrderBy("id", Ascending), SearchRank("articles", []string{"title", "body"}, "generics")))
+		require.Equal(t, []int{2, 1}, ids(Search("articles", []string{"title", "body"}, "GENERICS"), SearchRank("articles", []string{"title", "body"}, "generics")))
+		require.Equal(t, []int{1}, ids(Search("articles", []string{"title", "body"}, "go: generics!")))
+		require.Equal(t, []int{2}, ids(Not(Search("articles", []string{"title", "body"}, "go")), OrderBy("id", Ascending)))
+	})
+	t.Run("JSON", func(t *testing.T) {
+		m1, _ := NewMemoryMigration(
+			"CREATE TABLE products (id INTEGER PRIMARY KEY, metadata TEXT);"+
+				`INSERT INTO products (id, metadata) VALUES (1, '{"color":"red","size":{"width":10},"tags":["new"]}'), (2, '{"color":"Blue","size":{"width":25},"tags":[]}'), (3, NULL);`,
+			"DROP TABLE products;",
+			"0001",
+		)
+
+		db, err := NewSandboxOfSQLite3(m1)
+		require.NoError(t, err)
+		require.NotNil(t, db)
+		defer func(closer io.Closer) { require.NoError(t, closer.Close()) }(db)
+
+		ids := func(e ...Expression) []int {
+			s, args, err := ToSQL(DialectSQLite3, "products", append(e, Select("id"), OrderBy("id", Ascending))...)
+			require.NoError(t, err)
+			rows, err := db.Query(s, args...)
+			require.NoError(t, err)
+			defer func(closer io.Closer) { require.NoError(t, closer.Close()) }(rows)
+			var res []int
+			for rows.Next() {
+				var id int
+				require.NoError(t, rows.Scan(&id))
+				res = append(res, id)
+			}
+			return res
+		}
+
+		require.Equal(t, []int{1}, ids(WhereJSON("metadata", []string{"color"}, Equal, "red")))
+		require.Equal(t, []int{2}, ids(WhereJSON("metadata", []string{"size", "width"}, GreaterThan, 20)))
+		require.Equal(t, []int{1}, ids(WhereJSON("metadata", []string{"tags", "0"}, StartsWith, "ne")))
+		require.Equal(t, []int{2, 3}, ids(WhereJSON("metadata", []string{"tags", "0"}, IsNull)))
+
+		e, err := ODataExpressionWithOptions(&url.Values{"$filter": {"tolower(metadata/color) eq 'blue'"}}, ODataOptions{JSONColumns: []string{"metadata"}})
+		require.NoError(t, err)
+		require.Equal(t, []int{2}, ids(e))
+	})
+	t.Run("Aggregates", func(t *testing.T) {
+		m1, _ := NewMemoryMigration(
+			"CREATE TABLE orders (id INTEGER PRIMARY KEY, status TEXT, amount INTEGER);"+
+				"INSERT INTO orders (id, status, amount) VALUES (1, 'new', 10), (2, 'paid', 70), (3, 'paid', 50), (4, 'new', 5), (5, 'sent', 200);",
+			"DROP TABLE orders;",
+			"0001",
+		)
+
+		db, err := NewSandboxOfSQLite3(m1)
+		require.NoError(t, err)
+		require.NotNil(t, db)
+		defer func(closer io.Closer) { require.NoError(t, closer.Close()) }(db)
+
+		q := url.Values{"$apply": {"filter(amount gt 5)/groupby((status),aggregate(amount with sum as total,$count as count))/filter(total ge 60)"}, "$orderby": {"status"}}
+		e, err := ODataExpression(&q)
+		require.NoError(t, err)
+		s, args, err := ToSQL(DialectSQLite3, "orders", e)
+		require.NoError(t, err)
+
+		rows, err := db.Query(s, args...)
+		require.NoError(t, err)
+		defer func(closer io.Closer) { require.NoError(t, closer.Close()) }(rows)
+		var res []string
+		for rows.Next() {
+			var status string
+			var total, count int
+			require.NoError(t, rows.Scan(&status, &total, &count))
+			res = append(res, fmt.Sprintf("%s:%d:%d", status, total, count))
+		}
+		require.Equal(t, []string{"paid:120:2", "sent:200:1"}, res)
+	})
+	t.Run("NULL of negated conditions", func(t *testing.T) {
+		type point struct {
+			ID   int         `boil:"id"`
+			X    null.Int    `boil:"x"`
+			Y    null.Int    `boil:"y"`
+			Name null.String `boil:"name"`
+		}
+		m1, _ := NewMemoryMigration(
+			"CREATE TABLE points (id INTEGER PRIMARY KEY, x INTEGER, y INTEGER, name TEXT);"+
+				"INSERT INTO points (id, x, y, name) VALUES (1, NULL, 3, NULL), (2, 1, 2, 'ab'), (3, 2, 3, 'cd'), (4, NULL, NULL, 'ab');",
+			"DROP TABLE points;",
+			"0001",
+		)
+
+		db, err := NewSandboxOfSQLite3(m1)
+		require.NoError(t, err)
+		require.NotNil(t, db)
+		defer func(closer io.Closer) { require.NoError(t, closer.Close()) }(db)
+
+		repo, err := NewDummySqlBoilerRepository[int, point](
+			&point{ID: 1, Y: null.IntFrom(3)},
+			&point{ID: 2, X: null.IntFrom(1), Y: null.IntFrom(2), Name: null.StringFrom("ab")},
+			&point{ID: 3, X: null.IntFrom(2), Y: null.IntFrom(3), Name: null.StringFrom("cd")},
+			&point{ID: 4, Name: null.StringFrom("ab")},
+		)
+		require.NoError(t, err)
+
+		for _, c := range []Expression{
+			Not(Or(Where("x", Equal, 1), Where("y", Equal, 2))),
+			Not(And(Where("x", Equal, 1), Where("y", Equal, 2))),
+			Not(And(Where("x", Equal, 2), Not(Where("y", Equal, 3)))),
+			Not(Or(Where("x", GreaterThan, 1), Where("name", Contains, "c"))),
+			Not(Where("name", StartsWith, "a")),
+			Not(Not(Where("x", NotEqual, 1))),
+		} {
+			s, args, err := ToSQL(DialectSQLite3, "points", c, Select("id"), OrderBy("id", Ascending))
+			require.NoError(t, err)
+			rows, err := db.Query(s, args...)
+			require.NoError(t, err)
+			var expected []int
+			for rows.Next() {
+				var id int
+				require.NoError(t, rows.Scan(&id))
+				expected = append(expected, id)
+			}
+			require.NoError(t, rows.Close())
+
+			items, err := repo.ObtainAll(c, OrderBy("id", Ascending))
+			require.NoError(t, err)
+			var actually []int
+			for _, item := range items {
+				actually = append(actually, item.ID)
+			}
+			require.Equal(t, expected, actually, s)
+		}
+	})
 	t.Run("Failed Migration", func(t *testing.T) {
 		tableName01 := "t_01"
 		m1, _ := NewMemoryMigration("CREATE TABLE "+tableName01+" (m1_val: STR_VAL);", "DROP TABLE"+" "+tableName01+";", "m0001")
@@ -281,3 +556,174 @@ func TestNewSandboxOfSQLite3(t *testing.T) {
 		require.Nil(t, db)
 	})
 }
+
+var _ Repository[string, any] = &SandboxRepository[string, any]{}
+
+func TestNewSandboxSqlBoilerRepository(t *testing.T) {
+	repo, err := NewSandboxSqlBoilerRepository[string, internalSubject](
+		&internalSubject{ID: "1", Name: "SubjectName 7", IsEnabled: true},
+		&internalSubject{ID: "2", Name: "SubjectName 6", IsEnabled: true},
+		&internalSubject{ID: "3", Name: "SubjectName 5", IsEnabled: true},
+		&internalSubject{ID: "4", Name: "SubjectName 4", IsEnabled: false},
+		&internalSubject{ID: "5", Name: "subjectName 3", IsEnabled: false},
+		&internalSubject{ID: "6", Name: "SubjectName 2", IsEnabled: false},
+		&internalSubject{ID: "7", Name: "SubjectName 1", IsEnabled: true},
+	)
+	require.NoError(t, err)
+	require.NotNil(t, repo)
+	defer func(closer io.Closer) { require.NoError(t, closer.Close()) }(repo)
+
+	require.Equal(t, "internal_subjects", repo.Table())
+
+	t.Run("Count", func(t *testing.T) {
+		count, err := repo.Count()
+		require.NoError(t, err)
+		require.Equal(t, int64(7), count)
+
+		count, err = repo.Count(Where("enabled", Equal, false), Limit(1))
+		require.NoError(t, err)
+		require.Equal(t, int64(3), count)
+	})
+	t.Run("ObtainAll", func(t *testing.T) {
+		items, err := repo.ObtainAll(Where("id", In, "2", "3", "4", "5"), OrderBy("name", Ascending), Limit(3))
+		require.NoError(t, err)
+		require.Len(t, items, 3)
+		require.Equal(t, "4", items[0].ID)
+		require.Equal(t, "3", items[1].ID)
+		require.Equal(t, "2", items[2].ID)
+	})
+	t.Run("ObtainAll with LIKE is case insensitive", func(t *testing.T) {
+		items, err := repo.ObtainAll(Where("name", Contains, "subjectname"))
+		require.NoError(t, err)
+		require.Len(t, items, 7)
+	})
+	t.Run("ObtainAll with functions of $filter", func(t *testing.T) {
+		q := url.Values{defaultQueryNameWhere: {"startswith(tolower(name),'subjectname 3') or substring(name,12) eq '7' or concat(trim(name),'!') eq 'SubjectName 4!'"}}
+		e, err := ODataExpression(&q)
+		require.NoError(t, err)
+
+		items, err := repo.ObtainAll(e, OrderBy("id", Ascending))
+		require.NoError(t, err)
+		require.Len(t, items, 3)
+		require.Equal(t, "1", items[0].ID)
+		require.Equal(t, "4", items[1].ID)
+		require.Equal(t, "5", items[2].ID)
+	})
+	t.Run("ObtainAll with Matches", func(t *testing.T) {
+		items, err := repo.ObtainAll(Where("name", Matches, "^SubjectName [1-3]$"), OrderBy("id", Ascending))
+		require.NoError(t, err)
+		require.Len(t, items, 2)
+		require.Equal(t, "6", items[0].ID)
+		require.Equal(t, "7", items[1].ID)
+
+		items, err = repo.ObtainAll(Not(Where("name", Matches, "^SubjectName [1-3]$")))
+		require.NoError(t, err)
+		require.Len(t, items, 5)
+	})
+	t.Run("ObtainAll with Between without pair of values", func(t *testing.T) {
+		items, err := repo.ObtainAll(Where("name", Between, "SubjectName 1"))
+		require.NoError(t, err)
+		require.Empty(t, items)
+
+		count, err := repo.Count(Where("name", Between, "SubjectName 1"))
+		require.NoError(t, err)
+		require.Equal(t, int64(0), count)
+	})
+	t.Run("ObtainAll with in operator of $filter", func(t *testing.T) {
+		q := url.Values{defaultQueryNameWhere: {"id in ('1','2','3','4') and not (name in ('SubjectName 6','SubjectName 4'))"}}
+		e, err := ODataExpression(&q)
+		require.NoError(t, err)
+
+		items, err := repo.ObtainAll(e, OrderBy("id", Ascending))
+		require.NoError(t, err)
+		require.Len(t, items, 2)
+		require.Equal(t, "1", items[0].ID)
+		require.Equal(t, "3", items[1].ID)
+	})
+	t.Run("ObtainAll with $select", func(t *testing.T) {
+		q := url.Values{defaultQueryNameSelect: {"id,name"}}
+		e, err := ODataExpression(&q)
+		require.NoError(t, err)
+
+		items, err := repo.ObtainAll(e, Where("enabled", Equal, true), OrderBy("id", Descending), Limit(2))
+		require.NoError(t, err)
+		require.Equal(t, []*internalSubject{{ID: "7", Name: "SubjectName 1"}, {ID: "3", Name: "SubjectName 5"}}, items)
+
+		item, err := repo.ObtainOne("1", Select("name"))
+		require.NoError(t, err)
+		require.Equal(t, &internalSubject{Name: "SubjectName 7"}, item)
+	})
+	t.Run("ObtainPage", func(t *testing.T) {
+		q := url.Values{defaultQueryNameWhere: {"enabled eq 1"}, defaultQueryNameCount: {"true"}, defaultQueryNameLimit: {"3"}, defaultQueryNameOffset: {"3"}}
+		e, err := ODataExpression(&q)
+		require.NoError(t, err)
+
+		page, err := ObtainPage[string, internalSubject](repo, &url.URL{Path: "/subjects"}, e, OrderBy("id", Ascending))
+		require.NoError(t, err)
+		require.Equal(t, int64(4), *page.Count)
+		require.Len(t, page.Items, 1)
+		require.Equal(t, "7", page.Items[0].ID)
+		require.Empty(t, page.NextLink)
+	})
+	t.Run("ObtainAll with $expand", func(t *testing.T) {
+		q := url.Values{defaultQueryNameExpand: {"Comments"}}
+		e, err := ODataExpressionWithOptions(&q, ODataOptions{Expandable: []string{"Comments"}})
+		require.NoError(t, err)
+
+		_, err = repo.ObtainAll(e)
+		require.EqualError(t, err, "relation Comments is not supported by the sandbox repository")
+		_, err = repo.ObtainOne("1", Relation("Comments"))
+		require.Error(t, err)
+
+		count, err := repo.Count(e)
+		require.NoError(t, err)
+		require.Equal(t, int64(7), count)
+	})
+	t.Run("ObtainOne", func(t *testing.T) {
+		item, err := repo.ObtainOne("6")
+		require.NoError(t, err)
+		require.Equal(t, "SubjectName 2", item.Name)
+
+		_, err = repo.ObtainOne("60")
+		require.Error(t, err)
+	})
+	t.Run("Create, Update, Delete", func(t *testing.T) {
+		item := &internalSubject{ID: "8", Name: "SubjectName 8"}
+		require.NoError(t, repo.Create(item))
+		require.Error(t, repo.Create(item))
+
+		item.IsEnabled = true
+		require.NoError(t, repo.Update(item))
+		require.Error(t, repo.Update(&internalSubject{ID: "80"}))
+
+		actually, err := repo.ObtainOne("8")
+		require.NoError(t, err)
+		require.Equal(t, item, actually)
+
+		item.Name = "SubjectName 08"
+		require.NoError(t, repo.CreateOrUpdate(item, &internalSubject{ID: "9"}))
+
+		actually, err = repo.ObtainOne("8")
+		require.NoError(t, err)
+		require.Equal(t, item, actually)
+
+		require.NoError(t, repo.Delete(item))
+		require.NoError(t, repo.Erase("9"))
+		require.Error(t, repo.Erase("9"))
+	})
+	t.Run("UpdateAll, DeleteAll", func(t *testing.T) {
+		err := repo.UpdateAll(map[string]interface{}{"enabled": false}, Where("id", In, "1", "2"))
+		require.NoError(t, err)
+
+		count, err := repo.Count(Where("enabled", Equal, false))
+		require.NoError(t, err)
+		require.Equal(t, int64(5), count)
+
+		err = repo.DeleteAll(Where("enabled", Equal, false))
+		require.NoError(t, err)
+
+		count, err = repo.Count()
+		require.NoError(t, err)
+		require.Equal(t, int64(2), count)
+	})
+}